@@ -102,4 +102,152 @@ describe 'config/pcap-agent.yml global properties' do
       expect(pcap_agent_conf['listen']['port']).to be(9495)
     end
   end
+
+  context 'when pcap_agent.limits are provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        },
+        'limits' => {
+          'max_duration' => '1h',
+          'max_packets' => 1_000_000,
+          'max_bytes' => 1_073_741_824
+        }
+      }
+    end
+
+    it 'configures values correctly' do
+      expect(pcap_agent_conf['limits']['max_duration']).to eq('1h')
+      expect(pcap_agent_conf['limits']['max_packets']).to eq(1_000_000)
+      expect(pcap_agent_conf['limits']['max_bytes']).to eq(1_073_741_824)
+    end
+  end
+
+  context 'when pcap_agent.limits are not provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'configures no limits' do
+      expect(pcap_agent_conf['limits']).to be_empty
+    end
+  end
+
+  context 'when pcap_agent.metrics.port is provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        },
+        'metrics' => {
+          'port' => 9495
+        }
+      }
+    end
+
+    it 'configures the metrics listener' do
+      expect(pcap_agent_conf['metrics']['port']).to eq(9495)
+    end
+  end
+
+  context 'when pcap_agent.metrics.port is not provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'disables metrics' do
+      expect(pcap_agent_conf).not_to have_key('metrics')
+    end
+  end
+
+  context 'when pcap_agent.drain_timeout is not provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'configures the default' do
+      expect(pcap_agent_conf['drain_timeout']).to eq('10s')
+    end
+  end
+
+  context 'when pcap_agent.drain_timeout is provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'drain_timeout' => '30s',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'configures value correctly' do
+      expect(pcap_agent_conf['drain_timeout']).to eq('30s')
+    end
+  end
+
+  context 'when pcap_agent.concurrent_captures is not provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'does not limit the captures' do
+      expect(pcap_agent_conf).not_to have_key('concurrent_captures')
+    end
+  end
+
+  context 'when pcap_agent.concurrent_captures is provided' do
+    let(:agent_properties) do
+      {
+        'id' => 'f9281cda-1234-bbcd-ef12-1337cafe0048',
+        'concurrent_captures' => 2,
+        'buffer' => {
+          'size' => 1000,
+          'upper_limit' => 998,
+          'lower_limit' => 900
+        }
+      }
+    end
+
+    it 'configures value correctly' do
+      expect(pcap_agent_conf['concurrent_captures']).to eq(2)
+    end
+  end
 end
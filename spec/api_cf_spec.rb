@@ -0,0 +1,74 @@
+# frozen_string_literal: true
+
+require 'rspec'
+require 'yaml'
+
+describe 'config/pcap-api.yml cf properties' do
+  let(:template) { pcap_api_job.template('config/pcap-api.yml') }
+
+  let(:pcap_api_conf) { YAML.safe_load(template.render({ 'pcap-api' => properties }, spec: pcap_api_spec)) }
+
+  let(:properties) do
+    {
+      'concurrent_captures' => 5,
+      'buffer' => {
+        'size' => 100,
+        'upper_limit' => 98,
+        'lower_limit' => 90
+      }
+    }
+  end
+
+  context 'when pcap-api.cf is provided without TLS' do
+    let(:cf_properties) do
+      {
+        'cf' =>
+          {
+            'agent_port' => 9495,
+            'cc_url' => 'https://api.cf.example.com',
+            'tls' =>
+            {
+              'enabled' => false
+            }
+          }
+      }
+    end
+
+    it 'configures cf correctly' do
+      properties.merge!(cf_properties)
+      expect(pcap_api_conf['cf']['agent_port']).to be(9495)
+      expect(pcap_api_conf['cf']['cc_url']).to include('https://api.cf.example.com')
+      expect(pcap_api_conf['cf']['tls']).to be_nil
+    end
+  end
+
+  context 'when pcap-api.cf is provided with TLS configuration' do
+    let(:cf_properties) do
+      {
+        'cf' =>
+          {
+            'cc_url' => 'https://api.cf.example.com',
+            'tls' => {
+              'enabled' => true,
+              'common_name' => 'api.cf.example.com',
+              'skip_verify' => false
+            }
+          }
+      }
+    end
+
+    it 'configures cf correctly' do
+      properties.merge!(cf_properties)
+      expect(pcap_api_conf['cf']['cc_url']).to include('https://api.cf.example.com')
+      expect(pcap_api_conf['cf']['tls']['server_name']).to include('api.cf.example.com')
+      expect(pcap_api_conf['cf']['tls']['skip_verify']).to be(false)
+      expect(pcap_api_conf['cf']['tls']['ca']).to include('/var/vcap/jobs/pcap-api/config/certs/cf/pcap-api-cf-ca.crt')
+    end
+  end
+
+  context 'when pcap-api.cf is not provided' do
+    it 'does not configure cf' do
+      expect(pcap_api_conf['cf']).to be_nil
+    end
+  end
+end
@@ -57,6 +57,44 @@ describe 'config/pcap-api.yml global properties' do
     end
   end
 
+  context 'when pcap-api.concurrent_captures_per_user is not provided' do
+    it 'does not limit the captures per user' do
+      expect(pcap_api_conf).not_to have_key('concurrent_captures_per_user')
+    end
+  end
+
+  context 'when pcap-api.concurrent_captures_per_user is provided' do
+    let(:concurrent_captures_per_user) do
+      {
+        'concurrent_captures_per_user' => 2
+      }
+    end
+
+    it 'configures value correctly' do
+      properties.merge!(concurrent_captures_per_user)
+      expect(pcap_api_conf['concurrent_captures_per_user']).to eq(2)
+    end
+  end
+
+  context 'when pcap-api.drain_timeout is not provided' do
+    it 'configures the default' do
+      expect(pcap_api_conf['drain_timeout']).to eq('10s')
+    end
+  end
+
+  context 'when pcap-api.drain_timeout is provided' do
+    let(:drain_timeout) do
+      {
+        'drain_timeout' => '30s'
+      }
+    end
+
+    it 'configures value correctly' do
+      properties.merge!(drain_timeout)
+      expect(pcap_api_conf['drain_timeout']).to eq('30s')
+    end
+  end
+
   context 'when pcap-api.listen port is not provided' do
     it 'configures values correctly' do
       expect(pcap_api_conf['listen']['port']).to eq(8080)
@@ -134,4 +172,120 @@ describe 'config/pcap-api.yml global properties' do
       expect(pcap_api_conf['buffer']['lower_limit']).to eq(450)
     end
   end
+
+  context 'when pcap-api.metrics.port is not provided' do
+    it 'disables metrics' do
+      expect(pcap_api_conf).not_to have_key('metrics')
+    end
+  end
+
+  context 'when pcap-api.metrics.port is provided' do
+    let(:metrics) do
+      {
+        'metrics' => {
+          'port' => 8081
+        }
+      }
+    end
+
+    it 'configures the metrics listener' do
+      properties.merge!(metrics)
+      expect(pcap_api_conf['metrics']['port']).to eq(8081)
+    end
+  end
+
+  context 'when pcap-api.agent_connect is not provided' do
+    it 'configures no values' do
+      expect(pcap_api_conf['agent_connect']).to be_empty
+    end
+  end
+
+  context 'when pcap-api.agent_connect is provided' do
+    let(:agent_connect) do
+      {
+        'agent_connect' => {
+          'timeout' => '5s',
+          'parallelism' => 20
+        }
+      }
+    end
+
+    it 'configures values correctly' do
+      properties.merge!(agent_connect)
+      expect(pcap_api_conf['agent_connect']['timeout']).to eq('5s')
+      expect(pcap_api_conf['agent_connect']['parallelism']).to eq(20)
+      expect(pcap_api_conf['agent_connect']).not_to have_key('start_policy')
+    end
+  end
+
+  context 'when pcap-api.agent_connect.start_policy is provided' do
+    let(:start_policy) do
+      {
+        'agent_connect' => {
+          'start_policy' => {
+            'min_percent' => 50
+          }
+        }
+      }
+    end
+
+    it 'configures values correctly' do
+      properties.merge!(start_policy)
+      expect(pcap_api_conf['agent_connect']['start_policy']).to eq({ 'min_percent' => 50 })
+    end
+  end
+
+  context 'when pcap-api.merge is not provided' do
+    it 'configures no values' do
+      expect(pcap_api_conf['merge']).to be_empty
+    end
+  end
+
+  context 'when pcap-api.merge is provided' do
+    let(:merge) do
+      {
+        'merge' => {
+          'default_window' => '500ms',
+          'max_window' => '2s',
+          'max_bytes' => 8_388_608
+        }
+      }
+    end
+
+    it 'configures values correctly' do
+      properties.merge!(merge)
+      expect(pcap_api_conf['merge']['default_window']).to eq('500ms')
+      expect(pcap_api_conf['merge']['max_window']).to eq('2s')
+      expect(pcap_api_conf['merge']['max_bytes']).to eq(8_388_608)
+    end
+  end
+
+  context 'when pcap-api.audit is not provided' do
+    it 'configures no sinks' do
+      expect(pcap_api_conf['audit']).to eq({ 'stdout' => false })
+    end
+  end
+
+  context 'when pcap-api.audit is provided' do
+    let(:audit) do
+      {
+        'audit' => {
+          'file' => '/var/vcap/sys/log/pcap-api/audit.log',
+          'stdout' => true,
+          'syslog' => {
+            'network' => 'udp',
+            'address' => 'syslog.service.cf.internal:514',
+            'tag' => 'pcap-api-audit'
+          }
+        }
+      }
+    end
+
+    it 'configures values correctly' do
+      properties.merge!(audit)
+      expect(pcap_api_conf['audit']['file']).to eq('/var/vcap/sys/log/pcap-api/audit.log')
+      expect(pcap_api_conf['audit']['stdout']).to eq(true)
+      expect(pcap_api_conf['audit']['syslog']).to eq({ 'network' => 'udp', 'address' => 'syslog.service.cf.internal:514', 'tag' => 'pcap-api-audit' })
+    end
+  end
 end
@@ -5,37 +5,53 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"github.com/gopacket/gopacket/pcap"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 )
 
-const readPacketTimeout = 1 * time.Second
+const (
+	readPacketTimeout = 1 * time.Second
+	// statisticsInterval defines how often the capture statistics are sent to the client.
+	statisticsInterval = 10 * time.Second
+)
 
 // Agent is the central struct to which the handlers are attached.
 type Agent struct {
 	// done is used to gracefully shut down the agent, all ongoing streams terminate
 	// whenever this channel is closed.
 	done chan struct{}
-	// streamsWG tracks any running streams.
-	// TODO: expose as metric?
+	// streamsWG tracks any running streams. The number of active captures is exposed as metric.
 	streamsWG sync.WaitGroup
 	bufConf   BufferConf
+	// limits are the upper bounds for captures that clients can not exceed.
+	limits CaptureLimitsConf
 	// ID of the instance or app where the agent is co-located.
 	id string
+	// maxConcurrentCaptures is the maximum number of captures running at the same time, zero means no limit.
+	maxConcurrentCaptures int32
+	runningCaptures       atomic.Int32
+	// drainTimeout is the time running captures are given to flush once the agent is stopped, zero waits without
+	// bound.
+	drainTimeout time.Duration
 
 	UnimplementedAgentServer
 }
 
-// NewAgent creates a new ready-to-use agent.
-func NewAgent(bufConf BufferConf, id string) *Agent {
+// NewAgent creates a new ready-to-use agent with the buffer, limits and id of node and the behaviour defined by conf.
+func NewAgent(node NodeConfig, conf AgentConf) *Agent {
 	return &Agent{
-		done:    make(chan struct{}),
-		bufConf: bufConf,
-		id:      id,
+		done:                  make(chan struct{}),
+		bufConf:               node.Buffer,
+		limits:                node.Limits,
+		id:                    node.ID,
+		maxConcurrentCaptures: conf.ConcurrentCaptures,
+		drainTimeout:          conf.DrainTimeout,
 	}
 }
 
@@ -68,22 +84,38 @@ func (a *Agent) draining() bool {
 	}
 }
 
+// healthy returns true if the agent is able to accept new captures.
+func (a *Agent) healthy() bool {
+	return !a.draining()
+}
+
 // Status handler for the pcap-agent. See AgentServer.Status documentation for details.
 func (a *Agent) Status(_ context.Context, _ *StatusRequest) (*StatusResponse, error) {
 	s := &StatusResponse{
 		CompatibilityLevel: CompatibilityLevel,
-		Healthy:            true,
+		Healthy:            a.healthy(),
 		Message:            "ok",
+		RunningCaptures:    a.runningCaptures.Load(),
+		MaxCaptures:        a.maxConcurrentCaptures,
 	}
 
 	if a.draining() {
-		s.Healthy = false
 		s.Message = "agent has been stopped and is draining remaining streams"
 	}
 
 	return s, nil
 }
 
+// ListInterfaces handler for the pcap-agent. See AgentServer.ListInterfaces documentation for details.
+func (a *Agent) ListInterfaces(_ context.Context, _ *ListInterfacesRequest) (*ListInterfacesResponse, error) {
+	interfaces, err := listInterfaces()
+	if err != nil {
+		return nil, errorf(codes.Internal, "list interfaces: %w", err)
+	}
+
+	return &ListInterfacesResponse{Interfaces: interfaces}, nil
+}
+
 // Capture handler for the pcap-agent. See AgentServer.Capture documentation for details.
 func (a *Agent) Capture(stream Agent_CaptureServer) (err error) {
 	a.streamsWG.Add(1)
@@ -105,6 +137,15 @@ func (a *Agent) Capture(stream Agent_CaptureServer) (err error) {
 		return errorf(codes.Unavailable, "agent is draining")
 	}
 
+	runningCaptures := a.runningCaptures.Add(1)
+	defer a.runningCaptures.Add(-1)
+
+	if a.maxConcurrentCaptures > 0 && runningCaptures > a.maxConcurrentCaptures {
+		return errorf(codes.ResourceExhausted, "failed starting capture on %s, %d of %d captures running: %w", a.id, runningCaptures-1, a.maxConcurrentCaptures, errTooManyCaptures)
+	}
+
+	defer trackCapture()()
+
 	req, err := stream.Recv()
 	if err != nil {
 		return errorf(codes.Unknown, "unable to receive message: %w", err)
@@ -116,45 +157,67 @@ func (a *Agent) Capture(stream Agent_CaptureServer) (err error) {
 	}
 
 	opts := req.Payload.(*AgentRequest_Start).Start.Capture //nolint:errcheck //this only returns one value
-	log.Info("starting capture", zap.String("device", opts.Device), zap.Uint32("snapLen", opts.SnapLen), zap.String("filter", opts.Filter))
+	opts.Limits = a.limits.apply(opts.Limits)
+	devices := opts.captureDevices()
+	log.Info("starting capture", zap.Strings("devices", devices), zap.Uint32("snapLen", opts.SnapLen), zap.String("filter", opts.Filter), zap.Any("limits", opts.Limits))
 
-	handle, err := openHandle(opts)
+	handles, precisions, err := openHandles(devices, opts, log)
 	if err != nil {
 		return err
 	}
-	defer handle.Close()
+	defer func() {
+		for _, handle := range handles {
+			handle.Close()
+		}
+	}()
 
-	// source / producer
-	responses := readPackets(ctx, cancel, handle, a.bufConf.Size)
+	// source / producer, one per device
+	sources := make([]<-chan *CaptureResponse, 0, len(handles))
+	for i, handle := range handles {
+		sources = append(sources, readPackets(ctx, cancel, handle, devices[i], precisions[i], a.bufConf.Size, a.id))
+	}
+	responses := sequencePackets(cancel, mergeResponseChannels(sources, a.bufConf.Size), a.bufConf.Size, opts.Limits, a.id)
 
 	// sink / consumer
 	// we need a wait group only for this function because it could still be forwarding packets
 	// when we are closing the stream.
 	forwardWG := &sync.WaitGroup{}
 	forwardWG.Add(1)
-	forwardToStream(cancel, responses, stream, a.bufConf, forwardWG, a.id)
+	credits := newCreditGate(opts.FlowControl)
+	// limits are already enforced by sequencePackets
+	forwardToStream(cancel, responses, stream, a.bufConf, nil, credits, forwardWG, a.id)
 
-	agentStopCmd(cancel, stream)
+	agentStopCmd(cancel, stream, credits)
 
 	select {
 	case <-ctx.Done():
 		// nothing to do, stream was terminated
 	case <-a.done:
-		// agent shutting down
-		cancel(fmt.Errorf("agent was stopped"))
+		// agent shutting down, the capture is stopped gracefully and given the drain timeout to flush.
+		cancel(errDraining)
 		// just to be sure that the error was already propagated
 		<-ctx.Done()
 	}
 
 	err = context.Cause(ctx)
+	draining := errors.Is(err, errDraining)
 	// Cancelling the context with nil causes context.Cancelled to be set
 	// which is a non-error in our case.
-	if err != nil && !errors.Is(err, context.Canceled) {
+	if err != nil && !errors.Is(err, context.Canceled) && !draining {
 		return err
 	}
 
 	log.Debug("waiting for stream forwarding to finish")
-	forwardWG.Wait()
+	if !awaitForwarding(forwardWG, draining, a.drainTimeout) {
+		return errorf(codes.Unavailable, "capture did not drain within %s: %w", a.drainTimeout, errDraining)
+	}
+
+	if draining {
+		err = stream.Send(newCaptureStoppedResponse(StopReason_DRAINING, "pcap-agent is draining", a.id))
+		if err != nil {
+			return errorf(codes.Unknown, "send response: %w", err)
+		}
+	}
 
 	log.Info("capture done")
 	return nil
@@ -193,25 +256,134 @@ func validateAgentStartRequest(req *AgentRequest) error {
 	return nil
 }
 
+// openHandles opens one packet capturing handle per device, in the order of devices. If one of the
+// handles can not be opened, the handles opened so far are closed again. Returns the timestamp
+// precision of each handle, which may be lower than requested, see openHandle.
+func openHandles(devices []string, opts *CaptureOptions, log *zap.Logger) ([]*pcap.Handle, []TimestampPrecision, error) {
+	handles := make([]*pcap.Handle, 0, len(devices))
+	precisions := make([]TimestampPrecision, 0, len(devices))
+	for _, device := range devices {
+		handle, precision, err := openHandle(device, opts, log)
+		if err != nil {
+			for _, h := range handles {
+				h.Close()
+			}
+			return nil, nil, err
+		}
+		handles = append(handles, handle)
+		precisions = append(precisions, precision)
+	}
+
+	return handles, precisions, nil
+}
+
 // openHandle is a helper function to open the packet capturing handle that reads from the
 // network interface and returns the data. Puts the network interface into promiscuous mode.
-func openHandle(opts *CaptureOptions) (*pcap.Handle, error) {
-	handle, err := pcap.OpenLive(opts.Device, int32(opts.SnapLen), true, readPacketTimeout) //nolint:gosec //size in relation to packets, well within int32
+// Returns the precision of the timestamps of the handle: if nanosecond timestamps are requested
+// but not supported by the device, the capture falls back to microsecond timestamps.
+func openHandle(device string, opts *CaptureOptions, log *zap.Logger) (*pcap.Handle, TimestampPrecision, error) {
+	var (
+		handle    *pcap.Handle
+		precision = TimestampPrecision_MICROSECONDS
+		err       error
+	)
+	if opts.TimestampPrecision == TimestampPrecision_NANOSECONDS {
+		var inactive *pcap.InactiveHandle
+		inactive, err = pcap.NewInactiveHandle(device)
+		if err == nil {
+			handle, precision, err = openNanosecondHandle(gopacketInactiveHandle{inactive}, opts.SnapLen, log.With(zap.String("device", device)))
+		}
+	} else {
+		handle, err = pcap.OpenLive(device, int32(opts.SnapLen), true, readPacketTimeout) //nolint:gosec //size in relation to packets, well within int32
+	}
 	if err != nil {
-		return nil, errorf(codes.Internal, "open handle: %w", err)
+		return nil, 0, errorf(codes.Internal, "open handle for %s: %w", device, err)
 	}
 
 	err = handle.SetBPFFilter(opts.Filter)
 	if err != nil {
-		// TODO: this could be codes.InvalidArgument since we set the user provided filter
-		return nil, errorf(codes.Unknown, "open handle: %w", err)
+		linkType := handle.LinkType()
+		handle.Close()
+		// the filter is provided by the user, failing to compile it for the link type of the device is caused by
+		// an invalid filter.
+		return nil, 0, errorf(codes.InvalidArgument, "open handle for %s: set filter for link type %s: %w", device, linkType, err)
+	}
+
+	return handle, precision, nil
+}
+
+// inactiveHandle is a packet capturing handle that has not been activated yet, see pcap.InactiveHandle.
+type inactiveHandle interface {
+	SetSnapLen(snaplen int) error
+	SetPromisc(promisc bool) error
+	SetTimeout(timeout time.Duration) error
+	// Activate activates the handle and returns the precision of its timestamps.
+	Activate() (*pcap.Handle, TimestampPrecision, error)
+	CleanUp()
+}
+
+// openNanosecondHandle activates the inactive handle like pcap.OpenLive and returns the precision
+// of its timestamps. gopacket does not expose pcap_set_tstamp_precision, pcap.InactiveHandle.Activate
+// always requests nanosecond timestamps. If the device does not support them, the handle falls back
+// to microsecond timestamps, which is logged and returned as precision.
+func openNanosecondHandle(inactive inactiveHandle, snapLen uint32, log *zap.Logger) (*pcap.Handle, TimestampPrecision, error) {
+	defer inactive.CleanUp()
+
+	err := inactive.SetSnapLen(int(snapLen))
+	if err != nil {
+		return nil, 0, fmt.Errorf("set snaplen: %w", err)
+	}
+
+	err = inactive.SetPromisc(true)
+	if err != nil {
+		return nil, 0, fmt.Errorf("set promiscuous mode: %w", err)
+	}
+
+	err = inactive.SetTimeout(readPacketTimeout)
+	if err != nil {
+		return nil, 0, fmt.Errorf("set timeout: %w", err)
+	}
+
+	handle, precision, err := inactive.Activate()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if precision != TimestampPrecision_NANOSECONDS {
+		log.Warn("device does not support nanosecond timestamps, capturing with microsecond timestamps")
+	}
+
+	return handle, precision, nil
+}
+
+// gopacketInactiveHandle implements inactiveHandle with pcap.InactiveHandle, Activate determines the
+// precision of the timestamps the activated handle uses with handlePrecision.
+type gopacketInactiveHandle struct {
+	*pcap.InactiveHandle
+}
+
+func (h gopacketInactiveHandle) Activate() (*pcap.Handle, TimestampPrecision, error) {
+	handle, err := h.InactiveHandle.Activate()
+	if err != nil {
+		return nil, 0, err
 	}
+	return handle, handlePrecision(handle), nil
+}
 
-	return handle, nil
+// handlePrecision returns the precision of the timestamps read from handle. pcap.Handle.Resolution
+// reports the resolution inverted: handles that read nanosecond timestamps return
+// gopacket.TimestampResolutionMicrosecond.
+func handlePrecision(handle *pcap.Handle) TimestampPrecision {
+	if handle.Resolution() == gopacket.TimestampResolutionMicrosecond {
+		return TimestampPrecision_NANOSECONDS
+	}
+	return TimestampPrecision_MICROSECONDS
 }
 
 type pcapHandle interface {
 	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	Stats() (*pcap.Stats, error)
+	LinkType() layers.LinkType
 	Close()
 }
 
@@ -219,21 +391,41 @@ type pcapHandle interface {
 // channel. If the given context errors the loop breaks with the next read.
 // If an error is encountered while reading packets the cancel function is
 // called and the loop is stopped.
-func readPackets(ctx context.Context, cancel context.CancelCauseFunc, handle pcapHandle, bufSize int) <-chan *CaptureResponse {
+// Before the first packet, a CAPTURE_METADATA message with the link type of the
+// handle is written to the channel.
+// Packets are tagged with the device of the handle but are not numbered, see
+// sequencePackets.
+// The statistics of the handle are written to the channel every statisticsInterval
+// and once more when reading stops.
+func readPackets(ctx context.Context, cancel context.CancelCauseFunc, handle pcapHandle, device string, precision TimestampPrecision, bufSize int, id string) <-chan *CaptureResponse {
 	out := make(chan *CaptureResponse, bufSize)
 
 	go func() {
 		defer close(out)
+		// This will call pcap.Handle.pcapClose which sets the underlying handle to nil.
+		// doing so makes every future call to pcap.Handle.ReadPacketData return io.EOF
+		// so there is no point in trying to continue reading packets. This could
+		// result in lost packets.
+		// See: https://github.com/google/gopacket/blob/32ee38206866f44a74a6033ec26aeeb474506804/pcap/pcap_unix.go#L251-L256
 		defer handle.Close()
+		// the final statistics must be read before the handle is closed.
+		defer func() {
+			out <- newStatisticsResponse(handle, id, device)
+		}()
+
+		out <- newMetadataResponse(handle.LinkType(), precision, id, device)
+
+		statsTicker := time.NewTicker(statisticsInterval)
+		defer statsTicker.Stop()
 
 		for {
+			select {
+			case <-statsTicker.C:
+				out <- newStatisticsResponse(handle, id, device)
+			default:
+			}
+
 			if ctx.Err() != nil {
-				// This will call pcap.Handle.pcapClose which sets the underlying handle to nil.
-				// doing so makes every future call to pcap.Handle.ReadPacketData return io.EOF
-				// so there is no point in trying to continue reading packets. This could
-				// result in lost packets.
-				// See: https://github.com/google/gopacket/blob/32ee38206866f44a74a6033ec26aeeb474506804/pcap/pcap_unix.go#L251-L256
-				handle.Close()
 				return
 			}
 
@@ -249,13 +441,118 @@ func readPackets(ctx context.Context, cancel context.CancelCauseFunc, handle pca
 				continue
 			}
 
-			out <- newPacketResponse(data, captureInfo)
+			res := newPacketResponse(data, captureInfo, id, 0)
+			res.GetPacket().Interface = device
+			out <- res
+		}
+	}()
+
+	return out
+}
+
+// sequencePackets numbers the packets read from src, starting at 1, and enforces
+// limits on them. Numbering the packets after the sources of all devices have
+// been merged ensures that the sequence numbers are in the order of the stream.
+// Once one of the limits is reached, a LIMIT_REACHED message is written to the
+// returned channel and the cancel function is called without cause. Packets
+// read after that are discarded, messages are forwarded until src is closed.
+func sequencePackets(cancel context.CancelCauseFunc, src <-chan *CaptureResponse, bufSize int, limits *CaptureLimits, id string) <-chan *CaptureResponse {
+	out := make(chan *CaptureResponse, bufSize)
+
+	go func() {
+		defer close(out)
+
+		limit := newLimitTracker(limits)
+		defer limit.stop()
+
+		var sequence uint64
+		reached := false
+		for {
+			select {
+			case res, ok := <-src:
+				if !ok {
+					return
+				}
+
+				packet := res.GetPacket()
+				if packet == nil {
+					out <- res
+					continue
+				}
+
+				if reached {
+					continue
+				}
+
+				sequence++
+				packet.Sequence = sequence
+				out <- res
+
+				if limitReached := limit.count(len(packet.GetData())); limitReached != "" {
+					reached = true
+					out <- newLimitReachedResponse(limitReached, id)
+					cancel(nil)
+				}
+			case <-limit.expired():
+				if reached {
+					continue
+				}
+				reached = true
+				out <- newLimitReachedResponse(limit.durationLimit(), id)
+				cancel(nil)
+			}
 		}
 	}()
 
 	return out
 }
 
+// newMetadataResponse wraps the link type and timestamp precision of device into a CAPTURE_METADATA message.
+func newMetadataResponse(linkType layers.LinkType, precision TimestampPrecision, origin string, device string) *CaptureResponse {
+	resolution := "microsecond"
+	if precision == TimestampPrecision_NANOSECONDS {
+		resolution = "nanosecond"
+	}
+
+	res := newMessageResponse(MessageType_CAPTURE_METADATA, fmt.Sprintf("capturing on %s with link type %s and %s timestamps", device, linkType, resolution), origin)
+	res.GetMessage().Metadata = &CaptureMetadata{
+		Device:             device,
+		LinkType:           uint32(linkType),
+		TimestampPrecision: precision,
+	}
+	return res
+}
+
+// newStatisticsResponse reads the statistics of the handle of device and wraps them into a STATISTICS message.
+// If the statistics can not be read, an UNKNOWN message with the error is returned instead.
+func newStatisticsResponse(handle pcapHandle, origin string, device string) *CaptureResponse {
+	stats, err := handle.Stats()
+	if err != nil {
+		return newMessageResponse(MessageType_UNKNOWN, fmt.Sprintf("could not read capture statistics of %s: %v", device, err), origin)
+	}
+
+	statistics := &CaptureStatistics{
+		Received:  uint64(stats.PacketsReceived),  //nolint:gosec // counters are never negative
+		Dropped:   uint64(stats.PacketsDropped),   //nolint:gosec // counters are never negative
+		IfDropped: uint64(stats.PacketsIfDropped), //nolint:gosec // counters are never negative
+		Device:    device,
+	}
+
+	res := newMessageResponse(MessageType_STATISTICS, formatStatistics(statistics), origin)
+	res.GetMessage().Statistics = statistics
+	return res
+}
+
+// formatStatistics returns a human-readable summary of statistics.
+func formatStatistics(statistics *CaptureStatistics) string {
+	summary := fmt.Sprintf("%d packets received, %d dropped by kernel, %d dropped by interface", statistics.Received, statistics.Dropped, statistics.IfDropped)
+	if statistics.Device == "" {
+		return summary
+	}
+
+	return fmt.Sprintf("%s: %s", statistics.Device, summary)
+}
+
 // responseSender is an interface used by forwardToStream to simplify testing.
 type responseSender interface {
 	Send(*CaptureResponse) error
@@ -266,31 +563,42 @@ type agentRequestReceiver interface {
 	Recv() (*AgentRequest, error)
 }
 
-// agentStopCmd reads the next message from the stream. It ensures that the message
-// has a payload of StopAgentCapture. If any error is encountered or the payload is
+// agentStopCmd reads the messages from the stream until it receives StopAgentCapture.
+// Credits are granted to credits. If any error is encountered or the payload is
 // of a different type an appropriate cause is set and the cancel function is called.
-func agentStopCmd(cancel context.CancelCauseFunc, stream agentRequestReceiver) {
+func agentStopCmd(cancel context.CancelCauseFunc, stream agentRequestReceiver, credits *creditGate) {
 	go func() {
-		msg, err := stream.Recv()
-		if err != nil {
-			cancel(errorf(codes.Unknown, "read message: %w", err))
-			return
-		}
+		// the remaining packets are forwarded without credits once no more credits are granted.
+		defer credits.open()
 
-		if msg == nil || msg.Payload == nil {
-			cancel(errorf(codes.InvalidArgument, "read message: message or payload: %w", errNilField))
-			return
-		}
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				cancel(errorf(codes.Unknown, "read message: %w", err))
+				return
+			}
 
-		// request is empty, no need to save it
-		_, ok := msg.Payload.(*AgentRequest_Stop)
-		if !ok {
-			cancel(errorf(codes.InvalidArgument, "read payload: expected Payload of type StopAgentCapture: %w", errInvalidPayload))
+			if msg == nil || msg.Payload == nil {
+				cancel(errorf(codes.InvalidArgument, "read message: message or payload: %w", errNilField))
+				return
+			}
+
+			if credit, ok := msg.Payload.(*AgentRequest_Credit); ok {
+				credits.grant(credit.Credit.GetPackets())
+				continue
+			}
+
+			// request is empty, no need to save it
+			_, ok := msg.Payload.(*AgentRequest_Stop)
+			if !ok {
+				cancel(errorf(codes.InvalidArgument, "read payload: expected Payload of type StopAgentCapture: %w", errInvalidPayload))
+				return
+			}
+
+			// cancel without cause - normal exit
+			zap.L().Debug("client requested stop of capture")
+			cancel(nil)
 			return
 		}
-
-		// cancel without cause - normal exit
-		zap.L().Debug("client requested stop of capture")
-		cancel(nil)
 	}()
 }
@@ -3,11 +3,16 @@ package pcap
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcap"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -71,7 +76,7 @@ func TestAgentStopCmd(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.Background()
 			ctx, cancel := context.WithCancelCause(ctx)
-			agentStopCmd(cancel, test.recv)
+			agentStopCmd(cancel, test.recv, nil)
 			<-ctx.Done()
 
 			err := context.Cause(ctx)
@@ -92,6 +97,7 @@ type mockPcapHandle struct {
 	ci     gopacket.CaptureInfo
 	err    error
 	called bool
+	stats  *pcap.Stats
 }
 
 func (m *mockPcapHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
@@ -102,10 +108,88 @@ func (m *mockPcapHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
 	return m.data, m.ci, m.err
 }
 
+func (m *mockPcapHandle) Stats() (*pcap.Stats, error) {
+	if m.stats == nil {
+		return nil, errTestEnded
+	}
+	return m.stats, nil
+}
+
+func (m *mockPcapHandle) LinkType() layers.LinkType {
+	return layers.LinkTypeEthernet
+}
+
 func (m *mockPcapHandle) Close() {
 	// do nothing
 }
 
+// mockInactiveHandle records the calls configuring the handle and activates with precision.
+type mockInactiveHandle struct {
+	calls     []string
+	precision TimestampPrecision
+}
+
+func (m *mockInactiveHandle) SetSnapLen(snaplen int) error {
+	m.calls = append(m.calls, fmt.Sprintf("SetSnapLen(%d)", snaplen))
+	return nil
+}
+
+func (m *mockInactiveHandle) SetPromisc(promisc bool) error {
+	m.calls = append(m.calls, fmt.Sprintf("SetPromisc(%t)", promisc))
+	return nil
+}
+
+func (m *mockInactiveHandle) SetTimeout(timeout time.Duration) error {
+	m.calls = append(m.calls, fmt.Sprintf("SetTimeout(%s)", timeout))
+	return nil
+}
+
+func (m *mockInactiveHandle) Activate() (*pcap.Handle, TimestampPrecision, error) {
+	m.calls = append(m.calls, "Activate()")
+	return nil, m.precision, nil
+}
+
+func (m *mockInactiveHandle) CleanUp() {
+	m.calls = append(m.calls, "CleanUp()")
+}
+
+func TestOpenNanosecondHandle(t *testing.T) {
+	expectedCalls := []string{"SetSnapLen(65000)", "SetPromisc(true)", "SetTimeout(1s)", "Activate()", "CleanUp()"}
+
+	tests := []struct {
+		name              string
+		handle            *mockInactiveHandle
+		expectedPrecision TimestampPrecision
+	}{
+		{
+			name:              "device supports nanosecond timestamps",
+			handle:            &mockInactiveHandle{precision: TimestampPrecision_NANOSECONDS},
+			expectedPrecision: TimestampPrecision_NANOSECONDS,
+		},
+		{
+			name:              "device falls back to microsecond timestamps",
+			handle:            &mockInactiveHandle{precision: TimestampPrecision_MICROSECONDS},
+			expectedPrecision: TimestampPrecision_MICROSECONDS,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, precision, err := openNanosecondHandle(test.handle, 65000, zap.L())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if precision != test.expectedPrecision {
+				t.Errorf("expected precision %s, got %s", test.expectedPrecision, precision)
+			}
+
+			if !reflect.DeepEqual(test.handle.calls, expectedCalls) {
+				t.Errorf("expected calls %v, got %v", expectedCalls, test.handle.calls)
+			}
+		})
+	}
+}
+
 func TestReadPackets(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -113,6 +197,7 @@ func TestReadPackets(t *testing.T) {
 		contextCancelled bool
 		expectedErr      error
 		expectedData     string
+		expectedMsgType  MessageType
 	}{
 		{
 			name:             "Error during reading of packet data",
@@ -132,6 +217,13 @@ func TestReadPackets(t *testing.T) {
 			contextCancelled: false,
 			expectedData:     "ABC",
 		},
+		{
+			name:             "Statistics sent at the end",
+			handle:           mockPcapHandle{data: []byte("ABC"), ci: gopacket.CaptureInfo{}, err: nil, stats: &pcap.Stats{PacketsReceived: 3, PacketsDropped: 2, PacketsIfDropped: 1}},
+			contextCancelled: false,
+			expectedData:     "ABC",
+			expectedMsgType:  MessageType_STATISTICS,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -142,7 +234,7 @@ func TestReadPackets(t *testing.T) {
 				cancel(errContextCancelled)
 			}
 
-			out := readPackets(ctx, cancel, &test.handle, bufSize)
+			out := readPackets(ctx, cancel, &test.handle, "eth0", TimestampPrecision_MICROSECONDS, bufSize, agentOrigin)
 
 			<-ctx.Done()
 
@@ -151,19 +243,142 @@ func TestReadPackets(t *testing.T) {
 				t.Errorf("expectedErr = %v, got err = %v", test.expectedErr, err)
 			}
 
-			if test.expectedData != "" {
-				data := ""
-				for s := range out {
-					data += string(s.GetPacket().Data)
+			data := ""
+			var messages []*Message
+
+			first := <-out
+			if first.GetMessage().GetType() != MessageType_CAPTURE_METADATA || first.GetMessage().GetMetadata().GetDevice() != "eth0" {
+				t.Errorf("expected metadata of eth0 as first message, got %v", first)
+			}
+
+			for s := range out {
+				data += string(s.GetPacket().GetData())
+				if packet := s.GetPacket(); packet != nil {
+					if packet.Origin != agentOrigin || packet.Interface != "eth0" {
+						t.Errorf("expected packet from %s on eth0, got packet from %s on %s", agentOrigin, packet.Origin, packet.Interface)
+					}
 				}
-				if test.expectedData != data {
-					t.Errorf("Invalid data response %s", data)
+				if s.GetMessage() != nil {
+					messages = append(messages, s.GetMessage())
 				}
 			}
+
+			if test.expectedData != "" && test.expectedData != data {
+				t.Errorf("Invalid data response %s", data)
+			}
+
+			if test.expectedMsgType != MessageType_UNKNOWN && !containsMessageType(messages, test.expectedMsgType) {
+				t.Errorf("expectedMsgType = %v, got messages = %v", test.expectedMsgType, messages)
+			}
+
+			if len(messages) == 0 {
+				t.Fatalf("expected statistics at the end of the capture")
+			}
+
+			// the statistics are always the last message, if they can not be read an UNKNOWN message is sent instead.
+			last := messages[len(messages)-1]
+			if test.handle.stats == nil {
+				if last.Type != MessageType_UNKNOWN {
+					t.Errorf("expected UNKNOWN message for unavailable statistics, got %v", last.Type)
+				}
+				return
+			}
+			if last.Type != MessageType_STATISTICS || last.Statistics.GetReceived() != uint64(test.handle.stats.PacketsReceived) || last.Statistics.GetDevice() != "eth0" {
+				t.Errorf("expected statistics %v, got %v", test.handle.stats, last)
+			}
 		})
 	}
 }
 
+func TestSequencePackets(t *testing.T) {
+	tests := []struct {
+		name            string
+		packets         int
+		limits          *CaptureLimits
+		expectedPackets int
+		expectLimit     bool
+	}{
+		{
+			name:            "no limits",
+			packets:         5,
+			expectedPackets: 5,
+		},
+		{
+			name:            "packet limit reached",
+			packets:         5,
+			limits:          &CaptureLimits{MaxPackets: 3},
+			expectedPackets: 3,
+			expectLimit:     true,
+		},
+		{
+			name:            "byte limit reached",
+			packets:         5,
+			limits:          &CaptureLimits{MaxBytes: 4},
+			expectedPackets: 2,
+			expectLimit:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			// packets of two devices, followed by the final statistics.
+			src := make(chan *CaptureResponse, test.packets+1)
+			for i := 0; i < test.packets; i++ {
+				res := newPacketResponse([]byte("AB"), gopacket.CaptureInfo{}, agentOrigin, 0)
+				res.GetPacket().Interface = fmt.Sprintf("eth%d", i%2)
+				src <- res
+			}
+			src <- newMessageResponse(MessageType_STATISTICS, "statistics", agentOrigin)
+			close(src)
+
+			var packets []*Packet
+			var messages []*Message
+			for res := range sequencePackets(cancel, src, bufSize, test.limits, agentOrigin) {
+				if packet := res.GetPacket(); packet != nil {
+					packets = append(packets, packet)
+				}
+				if message := res.GetMessage(); message != nil {
+					messages = append(messages, message)
+				}
+			}
+
+			if len(packets) != test.expectedPackets {
+				t.Fatalf("expected %d packets, got %d", test.expectedPackets, len(packets))
+			}
+
+			for i, packet := range packets {
+				if packet.Sequence != uint64(i+1) {
+					t.Errorf("expected sequence %d, got %d", i+1, packet.Sequence)
+				}
+			}
+
+			if containsMessageType(messages, MessageType_LIMIT_REACHED) != test.expectLimit {
+				t.Errorf("expected limit reached = %v, got messages = %v", test.expectLimit, messages)
+			}
+
+			if test.expectLimit && ctx.Err() == nil {
+				t.Errorf("expected capture to be cancelled after limit was reached")
+			}
+
+			// messages are forwarded even after a limit has been reached.
+			if !containsMessageType(messages, MessageType_STATISTICS) {
+				t.Errorf("expected statistics to be forwarded, got messages = %v", messages)
+			}
+		})
+	}
+}
+
+func containsMessageType(messages []*Message, messageType MessageType) bool {
+	for _, message := range messages {
+		if message.Type == messageType {
+			return true
+		}
+	}
+	return false
+}
+
 func TestValidateAgentStartRequest(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -252,7 +467,7 @@ func TestAgentDraining(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a := NewAgent(BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, agentOrigin)
+			a := NewAgent(NodeConfig{Buffer: BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, ID: agentOrigin}, AgentConf{})
 			if tt.expectedDone {
 				a.Stop()
 			}
@@ -285,7 +500,7 @@ func TestAgentStatus(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			a := NewAgent(BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, agentOrigin)
+			a := NewAgent(NodeConfig{Buffer: BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, ID: agentOrigin}, AgentConf{})
 			if tt.agentDraining {
 				a.Stop()
 			}
@@ -300,6 +515,28 @@ func TestAgentStatus(t *testing.T) {
 	}
 }
 
+func TestAgentConcurrentCaptures(t *testing.T) {
+	a := NewAgent(NodeConfig{Buffer: BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, ID: agentOrigin}, AgentConf{ConcurrentCaptures: 1})
+	// a capture is already running
+	a.runningCaptures.Add(1)
+
+	got, err := a.Status(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Status() unexpected error: %v", err)
+	}
+	if got.RunningCaptures != 1 || got.MaxCaptures != 1 {
+		t.Errorf("Status() expected 1 of 1 captures running, got %d of %d", got.RunningCaptures, got.MaxCaptures)
+	}
+
+	err = a.Capture(&mockCaptureServer{context: context.Background()})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Capture() expected ResourceExhausted, got %v", err)
+	}
+	if a.runningCaptures.Load() != 1 {
+		t.Errorf("Capture() expected the rejected capture not to be counted, got %d", a.runningCaptures.Load())
+	}
+}
+
 type mockCaptureServer struct {
 	req *AgentRequest
 	err error
@@ -359,7 +596,7 @@ func TestAgentCapture(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			a := NewAgent(BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, agentOrigin)
+			a := NewAgent(NodeConfig{Buffer: BufferConf{bufSize, bufUpperLimit, bufLowerLimit}, ID: agentOrigin}, AgentConf{})
 
 			if !test.agentRunning {
 				a.Stop()
@@ -0,0 +1,173 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	// agentConnIdleTimeout is the time after which connections to agents without running requests are closed.
+	agentConnIdleTimeout = 5 * time.Minute
+	// agentKeepaliveTime is the interval in which the api pings agents while requests are running.
+	agentKeepaliveTime = 30 * time.Second
+	// agentKeepaliveTimeout is the time the api waits for the response to a ping before closing the connection.
+	agentKeepaliveTimeout = 10 * time.Second
+)
+
+var errPoolClosed = errors.New("agent connection pool is closed")
+
+// AgentServerKeepalive returns the server option for pcap-agents that permits the keepalive pings of the pcap-api.
+func AgentServerKeepalive() grpc.ServerOption {
+	return grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+		MinTime: agentKeepaliveTime / 2, //nolint:mnd // allow some jitter of the client
+	})
+}
+
+// agentConnKey identifies a connection by the address of the agent and the credentials used to connect to it.
+// Connections are only shared if they use the same TLS identity.
+type agentConnKey struct {
+	address string
+	creds   credentials.TransportCredentials
+}
+
+// pooledConn is a connection to an agent, which is shared by all requests to this agent.
+type pooledConn struct {
+	cc *grpc.ClientConn
+	// refs is the number of requests using the connection.
+	refs int
+	// idle closes the connection once it has not been used for the idle timeout.
+	idle *time.Timer
+}
+
+// agentConnPool manages the connections from the api to the agents. Connections are shared by concurrent and
+// subsequent requests to the same agent and closed once they have been idle for idleTimeout.
+type agentConnPool struct {
+	mu          sync.Mutex
+	conns       map[agentConnKey]*pooledConn
+	idleTimeout time.Duration
+	closed      bool
+	dial        func(target string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+}
+
+func newAgentConnPool(idleTimeout time.Duration) *agentConnPool {
+	return &agentConnPool{
+		conns:       make(map[agentConnKey]*pooledConn),
+		idleTimeout: idleTimeout,
+		dial:        grpc.Dial,
+	}
+}
+
+// get returns the connection to target and creates it if necessary. The connection must be released by calling
+// the returned function once it is no longer used, the connection must not be closed by the caller.
+func (p *agentConnPool) get(target AgentEndpoint, creds credentials.TransportCredentials) (*grpc.ClientConn, func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, nil, errPoolClosed
+	}
+
+	key := agentConnKey{address: target.String(), creds: creds}
+	conn, ok := p.conns[key]
+	if !ok {
+		cc, err := p.dial(key.address,
+			grpc.WithTransportCredentials(creds),
+			grpc.WithKeepaliveParams(keepalive.ClientParameters{
+				Time:    agentKeepaliveTime,
+				Timeout: agentKeepaliveTimeout,
+			}),
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect to '%s': %w", target, err)
+		}
+
+		conn = &pooledConn{cc: cc}
+		p.conns[key] = conn
+		agentConnections.Inc()
+	}
+
+	if conn.idle != nil {
+		conn.idle.Stop()
+		conn.idle = nil
+	}
+	conn.refs++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			p.release(key, conn)
+		})
+	}
+
+	return conn.cc, release, nil
+}
+
+// release marks one request of conn as done. Connections without requests are closed after the idle timeout, or
+// immediately if the pool has been closed.
+func (p *agentConnPool) release(key agentConnKey, conn *pooledConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn.refs--
+	if conn.refs > 0 {
+		return
+	}
+
+	if p.closed {
+		p.remove(key, conn)
+		return
+	}
+
+	conn.idle = time.AfterFunc(p.idleTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		// the connection might have been used again in the meantime.
+		if conn.refs == 0 && p.conns[key] == conn {
+			p.remove(key, conn)
+		}
+	})
+}
+
+// close closes all idle connections and prevents new connections. Connections that are still used are closed once
+// they are released, which allows running requests to finish gracefully. Further calls to close have no effect.
+func (p *agentConnPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for key, conn := range p.conns {
+		if conn.refs == 0 {
+			p.remove(key, conn)
+		}
+	}
+}
+
+// remove closes conn and removes it from the pool. Must be called with the lock held.
+func (p *agentConnPool) remove(key agentConnKey, conn *pooledConn) {
+	if conn.idle != nil {
+		conn.idle.Stop()
+	}
+	delete(p.conns, key)
+	agentConnections.Dec()
+
+	err := conn.cc.Close()
+	if err != nil {
+		zap.L().Warn("closing connection to agent failed", zap.String(LogKeyTarget, key.address), zap.Error(err))
+	}
+}
+
+// size returns the number of open connections.
+func (p *agentConnPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.conns)
+}
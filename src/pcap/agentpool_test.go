@@ -0,0 +1,142 @@
+package pcap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestAgentConnPoolReusesConnections(t *testing.T) {
+	pool := newAgentConnPool(time.Minute)
+	defer pool.close()
+
+	creds := insecure.NewCredentials()
+	agent1 := AgentEndpoint{IP: "localhost", Port: 9494, Identifier: "router/1"}
+	agent2 := AgentEndpoint{IP: "localhost", Port: 9495, Identifier: "router/2"}
+
+	for i := 0; i < 3; i++ {
+		for _, target := range []AgentEndpoint{agent1, agent2} {
+			_, release, err := pool.get(target, creds)
+			if err != nil {
+				t.Fatalf("get() unexpected error: %v", err)
+			}
+			release()
+		}
+	}
+
+	if pool.size() != 2 {
+		t.Errorf("expected 2 connections, got %d", pool.size())
+	}
+
+	cc1, release1, err := pool.get(agent1, creds)
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+	defer release1()
+
+	cc2, release2, err := pool.get(agent1, creds)
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+	defer release2()
+
+	if cc1 != cc2 {
+		t.Errorf("expected concurrent requests to share the connection")
+	}
+}
+
+func TestAgentConnPoolSeparatesCredentials(t *testing.T) {
+	pool := newAgentConnPool(time.Minute)
+	defer pool.close()
+
+	target := AgentEndpoint{IP: "localhost", Port: 9494, Identifier: "router/1"}
+	tlsConf, err := (&ClientTLS{ServerName: "pcap-agent.service.cf.internal"}).Config()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, release1, err := pool.get(target, insecure.NewCredentials())
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+	defer release1()
+
+	_, release2, err := pool.get(target, credentials.NewTLS(tlsConf))
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+	defer release2()
+
+	if pool.size() != 2 {
+		t.Errorf("expected one connection per TLS identity, got %d", pool.size())
+	}
+}
+
+func TestAgentConnPoolEvictsIdleConnections(t *testing.T) {
+	pool := newAgentConnPool(10 * time.Millisecond)
+	defer pool.close()
+
+	target := AgentEndpoint{IP: "localhost", Port: 9494, Identifier: "router/1"}
+	cc, release, err := pool.get(target, insecure.NewCredentials())
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+
+	// connections that are in use are never evicted.
+	time.Sleep(50 * time.Millisecond)
+	if pool.size() != 1 {
+		t.Fatalf("expected connection in use to be kept, got %d connections", pool.size())
+	}
+
+	release()
+	time.Sleep(50 * time.Millisecond)
+
+	if pool.size() != 0 {
+		t.Errorf("expected idle connection to be evicted, got %d connections", pool.size())
+	}
+	if cc.GetState() != connectivity.Shutdown {
+		t.Errorf("expected evicted connection to be closed, got state %v", cc.GetState())
+	}
+}
+
+func TestAgentConnPoolClose(t *testing.T) {
+	pool := newAgentConnPool(time.Minute)
+
+	creds := insecure.NewCredentials()
+	idle, releaseIdle, err := pool.get(AgentEndpoint{IP: "localhost", Port: 9494}, creds)
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+	releaseIdle()
+
+	used, releaseUsed, err := pool.get(AgentEndpoint{IP: "localhost", Port: 9495}, creds)
+	if err != nil {
+		t.Fatalf("get() unexpected error: %v", err)
+	}
+
+	pool.close()
+
+	if idle.GetState() != connectivity.Shutdown {
+		t.Errorf("expected idle connection to be closed, got state %v", idle.GetState())
+	}
+	if used.GetState() == connectivity.Shutdown {
+		t.Errorf("expected connection in use to be kept until released")
+	}
+
+	releaseUsed()
+	if used.GetState() != connectivity.Shutdown {
+		t.Errorf("expected released connection to be closed, got state %v", used.GetState())
+	}
+	if pool.size() != 0 {
+		t.Errorf("expected no connections, got %d", pool.size())
+	}
+
+	_, _, err = pool.get(AgentEndpoint{IP: "localhost", Port: 9494}, creds)
+	if !errors.Is(err, errPoolClosed) {
+		t.Errorf("expected %v, got %v", errPoolClosed, err)
+	}
+}
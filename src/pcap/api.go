@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
@@ -20,38 +22,71 @@ type API struct {
 	// done is used to gracefully shut down the api, all captures terminate
 	// whenever this channel is closed.
 	done chan struct{}
-	// captureWG tracks any running capture requests.
-	// TODO: expose as metric?
+	// captureWG tracks any running capture requests. The number of active captures is exposed as metric.
 	captureWG sync.WaitGroup
 	bufConf   BufferConf
+	// limits are the upper bounds for captures that clients can not exceed.
+	limits CaptureLimitsConf
+	// mu guards resolvers and tlsCredentials, which are replaced when the configuration is reloaded, auditSinks and
+	// the health of the resolvers.
+	mu        sync.RWMutex
 	resolvers map[string]AgentResolver
+	// resolverHealth is the health of the resolvers as of the last refresh, see refreshResolverHealth.
+	resolverHealth map[string]bool
+	// resolverGeneration is incremented whenever resolvers change, so outdated health results are discarded.
+	resolverGeneration uint64
+	// auditSinks receive the audit records of all captures.
+	auditSinks []AuditSink
 	// id of the instance where the api is located.
 	id string
 
+	// maxConcurrentCaptures is the maximum number of captures of all clients.
 	maxConcurrentCaptures int32
 	concurrentStreams     atomic.Int32
 	tlsCredentials        credentials.TransportCredentials
+	// identityCaptures limits the number of captures per authenticated client.
+	identityCaptures *identityCaptures
+	// agentConns are the connections to the agents, which are shared by all requests.
+	agentConns *agentConnPool
+	// connectConf defines how captures are started on the agents.
+	connectConf AgentConnectConf
+	// mergeConf bounds the ordered merge of packets requested by clients.
+	mergeConf MergeConf
+	// startPolicy is applied to captures that do not request a policy, nil requires at least one target to start.
+	startPolicy *StartPolicy
+	// drainTimeout is the time running captures are given to flush once the api is stopped, zero waits without bound.
+	drainTimeout time.Duration
 
 	UnimplementedAPIServer
 }
 
-func NewAPI(bufConf BufferConf, clientTLS *ClientTLS, id string, maxConcurrentCaptures int32) (*API, error) {
-	clientTLSCreds := insecure.NewCredentials()
-	if clientTLS != nil {
-		clientTLSConf, err := clientTLS.Config()
-		if err != nil {
-			return nil, fmt.Errorf("create api failed: %w", err)
-		}
-		clientTLSCreds = credentials.NewTLS(clientTLSConf)
+// NewAPI creates a new api with the buffer, limits and id of node and the behaviour defined by conf.
+func NewAPI(node NodeConfig, conf APIConf) (*API, error) {
+	clientTLSCreds, err := agentCredentials(conf.AgentsMTLS)
+	if err != nil {
+		return nil, fmt.Errorf("create api failed: %w", err)
+	}
+
+	startPolicy, err := conf.AgentConnect.StartPolicy.policy()
+	if err != nil {
+		return nil, fmt.Errorf("create api failed: %w", err)
 	}
 
 	return &API{
 		done:                  make(chan struct{}),
-		bufConf:               bufConf,
+		bufConf:               node.Buffer,
+		limits:                node.Limits,
 		resolvers:             make(map[string]AgentResolver),
-		id:                    id,
-		maxConcurrentCaptures: maxConcurrentCaptures,
+		resolverHealth:        make(map[string]bool),
+		id:                    node.ID,
+		maxConcurrentCaptures: conf.ConcurrentCaptures,
+		identityCaptures:      newIdentityCaptures(conf.ConcurrentCapturesPerUser),
 		tlsCredentials:        clientTLSCreds,
+		agentConns:            newAgentConnPool(agentConnIdleTimeout),
+		connectConf:           conf.AgentConnect.withDefaults(),
+		mergeConf:             conf.Merge.withDefaults(),
+		startPolicy:           startPolicy,
+		drainTimeout:          conf.DrainTimeout,
 	}, nil
 }
 
@@ -78,22 +113,85 @@ type AgentResolver interface {
 	Healthy() bool
 }
 
+// agentCredentials creates the credentials the api uses to connect to the agents.
+func agentCredentials(clientTLS *ClientTLS) (credentials.TransportCredentials, error) {
+	if clientTLS == nil {
+		return insecure.NewCredentials(), nil
+	}
+
+	clientTLSConf, err := clientTLS.Config()
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(clientTLSConf), nil
+}
+
+// RegisterResolver adds resolver to the registered resolvers and checks the health of the resolvers.
 func (api *API) RegisterResolver(resolver AgentResolver) {
+	api.mu.Lock()
 	api.resolvers[resolver.Name()] = resolver
+	api.resolverGeneration++
+	api.mu.Unlock()
+
+	api.refreshResolverHealth(resolverHealthTimeout)
+}
+
+// ReplaceResolvers replaces all registered resolvers by resolvers and checks their health. Running captures keep
+// the agents they have resolved already.
+func (api *API) ReplaceResolvers(resolvers ...AgentResolver) {
+	replacement := make(map[string]AgentResolver, len(resolvers))
+	for _, resolver := range resolvers {
+		replacement[resolver.Name()] = resolver
+	}
+
+	api.mu.Lock()
+	api.resolvers = replacement
+	api.resolverGeneration++
+	api.mu.Unlock()
+
+	api.refreshResolverHealth(resolverHealthTimeout)
+}
+
+// ReloadAgentTLS replaces the credentials used to connect to the agents by ones created from clientTLS. New
+// connections to agents use the new credentials, running captures keep their connections.
+func (api *API) ReloadAgentTLS(clientTLS *ClientTLS) error {
+	creds, err := agentCredentials(clientTLS)
+	if err != nil {
+		return fmt.Errorf("reload agent TLS failed: %w", err)
+	}
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.tlsCredentials = creds
+	return nil
+}
+
+// agentCreds returns the credentials currently used to connect to the agents.
+func (api *API) agentCreds() credentials.TransportCredentials {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	return api.tlsCredentials
+}
+
+// resolverList returns a snapshot of the registered resolvers.
+func (api *API) resolverList() map[string]AgentResolver {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	return maps.Clone(api.resolvers)
 }
 
 // Status provides the current status information for the pcap-api service.
 //
 // The service is marked unhealthy when there are no healthy resolvers available, or the API is draining (shutting down).
 func (api *API) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
-	healthyResolvers := api.HealthyResolverNames()
-	isHealthy := !api.draining() && len(healthyResolvers) > 0
-
 	apiStatus := &StatusResponse{
-		Healthy:            isHealthy,
+		Healthy:            api.healthy(),
 		CompatibilityLevel: 0,
 		Message:            "Ready.",
-		Resolvers:          healthyResolvers,
+		Resolvers:          api.HealthyResolverNames(),
 	}
 
 	if api.draining() {
@@ -103,16 +201,21 @@ func (api *API) Status(context.Context, *StatusRequest) (*StatusResponse, error)
 	return apiStatus, nil
 }
 
-// HealthyResolverNames provides a list of resolver names that are configured and marked healthy.
+// healthy returns true if the api is not draining and at least one resolver is healthy.
+func (api *API) healthy() bool {
+	return !api.draining() && len(api.HealthyResolverNames()) > 0
+}
+
+// HealthyResolverNames provides a list of resolver names that are configured and marked healthy. The health of the
+// resolvers is refreshed in the background, see WatchResolverHealth.
 func (api *API) HealthyResolverNames() []string {
-	resolverNames := make([]string, len(api.resolvers))
-	i := 0
-	for name, resolver := range api.resolvers {
-		if !resolver.Healthy() {
+	health := api.resolverHealthList()
+	resolverNames := make([]string, 0, len(health))
+	for name, healthy := range health {
+		if !healthy {
 			continue
 		}
-		resolverNames[i] = name
-		i++
+		resolverNames = append(resolverNames, name)
 	}
 	return resolverNames
 }
@@ -121,12 +224,16 @@ func (api *API) HealthyResolverNames() []string {
 //
 // Returns false, if the handler is not registered.
 func (api *API) HasResolver(handler string) bool {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
 	_, ok := api.resolvers[handler]
 	return ok
 }
 
 // Stop the server. This will gracefully stop any captures that are currently running
-// by closing API.done. Further calls to Stop have no effect.
+// by closing API.done. Connections to agents are closed once the captures using them
+// are done. Further calls to Stop have no effect.
 func (api *API) Stop() {
 	select {
 	case <-api.done:
@@ -134,9 +241,15 @@ func (api *API) Stop() {
 	default:
 		// otherwise the channel is still open and we close it
 		close(api.done)
+		api.agentConns.close()
 	}
 }
 
+// AgentConnections returns the number of open connections to agents.
+func (api *API) AgentConnections() int {
+	return api.agentConns.size()
+}
+
 // Wait for all open capture requests to terminate.
 func (api *API) Wait() {
 	api.captureWG.Wait()
@@ -183,14 +296,17 @@ func (api *API) Capture(stream API_CaptureServer) (err error) {
 	defer api.concurrentStreams.Add(-1)
 
 	if currentStreams > api.maxConcurrentCaptures {
+		// the request is rejected before it is read, so only the client certificate identifies the client.
 		vcapID, ok := ctx.Value(HeaderVcapID).(string)
 		if !ok {
-			return errorf(codes.ResourceExhausted, "failed starting capture: %w", errTooManyCaptures)
+			return api.auditRejected(ctx, nil, nil, errorf(codes.ResourceExhausted, "failed starting capture, %d of %d captures running: %w", currentStreams-1, api.maxConcurrentCaptures, errTooManyCaptures), log)
 		}
 
-		return errorf(codes.ResourceExhausted, "failed starting capture with vcap-id %s: %w", vcapID, errTooManyCaptures)
+		return api.auditRejected(ctx, nil, nil, errorf(codes.ResourceExhausted, "failed starting capture with vcap-id %s, %d of %d captures running: %w", vcapID, currentStreams-1, api.maxConcurrentCaptures, errTooManyCaptures), log)
 	}
 
+	defer trackCapture()()
+
 	log.Info("started capture stream")
 
 	req, err := stream.Recv()
@@ -205,34 +321,89 @@ func (api *API) Capture(stream API_CaptureServer) (err error) {
 
 	targets, resolveErr := api.resolveAgentEndpoints(opts.Start.Request, log)
 	if errors.Is(resolveErr, ErrValidationFailed) {
-		return errorf(codes.InvalidArgument, "capture targets not found: %w", resolveErr)
+		return api.auditRejected(ctx, opts.Start.Request, opts.Start.Options, errorf(codes.InvalidArgument, "capture targets not found: %w", resolveErr), log)
 	} else if resolveErr != nil {
-		return errorf(codes.InvalidArgument, "could not resolve agent endpoints: %w", resolveErr)
+		return api.auditRejected(ctx, opts.Start.Request, opts.Start.Options, errorf(codes.InvalidArgument, "could not resolve agent endpoints: %w", resolveErr), log)
 	}
 
+	// the identity is only counted once the request has been authenticated by resolving it.
+	identity := captureIdentity(ctx, opts.Start.Request)
+	log = log.With(zap.String(LogKeyIdentity, identity))
+	identityStreams, release := api.identityCaptures.acquire(identity)
+	if release == nil {
+		return api.auditRejected(ctx, opts.Start.Request, opts.Start.Options, errorf(codes.ResourceExhausted, "failed starting capture for %s, %d of %d captures running: %w", identity, identityStreams, api.identityCaptures.limit, errTooManyCaptures), log)
+	}
+	defer release()
+
+	// the filter is patched before the capture is audited, so the record contains the filter that is applied.
+	patchedFilter, err := patchFilter(opts.Start.Options.GetFilter())
+	if err != nil {
+		return api.auditRejected(ctx, opts.Start.Request, opts.Start.Options, errorf(codes.FailedPrecondition, "expanding the pcap filter to exclude traffic to pcap-api failed: %w", err), log)
+	}
+	opts.Start.Options.Filter = patchedFilter
+
+	// the request is authorized, whether the capture starts on the targets is recorded once it is known.
+	audit := newCaptureAudit(ctx, api.id, identity, opts.Start.Request, opts.Start.Options, targets)
+	api.audit(audit.started(), log)
+
 	// Start capture
-	out, err := api.capture(ctx, stream, opts.Start.Options, targets, log, connectToTarget)
+	out, err := api.capture(ctx, stream, opts.Start.Options, opts.Start.Policy, targets, log, api.connectToTarget)
 	if err != nil {
+		api.audit(audit.failed(status.Convert(err).Message()), log)
 		return err
 	}
 
+	if merge := opts.Start.Options.GetOrderedMerge(); merge != nil {
+		window := api.mergeConf.window(merge.Window)
+		log.Debug("merging packets in order of their timestamps", zap.Duration("window", window))
+		out = orderByTimestamp(out, window, api.mergeConf.MaxBytes, api.bufConf.Size, log)
+	}
+
 	forwardWG := &sync.WaitGroup{}
 	forwardWG.Add(1)
 
-	forwardToStream(cancel, out, stream, api.bufConf, forwardWG, api.id)
+	summary := newCaptureSummary(targets, api.id)
+	credits := newCreditGate(opts.Start.Options.GetFlowControl())
+	forwardToStream(cancel, out, summarySender{responseSender: stream, summary: summary}, api.bufConf, opts.Start.Options.GetLimits(), credits, forwardWG, api.id)
 
 	// Wait for capture stop
-	stopCmd(cancel, stream)
+	stopCmd(cancel, stream, credits)
 
 	select {
 	case <-ctx.Done():
 		// nothing to do, stream was terminated
 	case <-api.done:
-		// api shutting down
+		// api shutting down, the capture is stopped gracefully and given the drain timeout to flush.
 		cancel(errDraining)
 		// just to be sure that the error was already propagated
 		<-ctx.Done()
 	}
+
+	draining := errors.Is(context.Cause(ctx), errDraining)
+	log.Debug("waiting for stream forwarding to finish")
+	if !awaitForwarding(forwardWG, draining, api.drainTimeout) {
+		err = errorf(codes.Unavailable, "capture did not drain within %s: %w", api.drainTimeout, errDraining)
+		// the summary is incomplete while data is still being forwarded.
+		api.audit(audit.ended(nil, StopReason_DRAINING, err.Error()), log)
+		return err
+	}
+
+	if draining {
+		sendErr := stream.Send(newCaptureStoppedResponse(StopReason_DRAINING, "pcap-api is draining", api.id))
+		if sendErr != nil {
+			log.Debug("unable to inform client about draining", zap.Error(sendErr))
+		}
+	}
+
+	// the summary is the last response, it is sent even if the capture failed as long as the stream is intact.
+	reason, detail := summary.stopReason(context.Cause(ctx))
+	summaryRes := summary.response(reason, detail)
+	sendErr := stream.Send(summaryRes)
+	if sendErr != nil {
+		log.Debug("unable to send capture summary", zap.Error(sendErr))
+	}
+	api.audit(audit.ended(summaryRes.GetSummary(), reason, detail), log)
+
 	err = context.Cause(ctx)
 	// Cancelling the context with nil causes context.Cancelled to be set
 	// which is a non-error in our case.
@@ -240,34 +411,90 @@ func (api *API) Capture(stream API_CaptureServer) (err error) {
 		return err
 	}
 
-	log.Debug("waiting for stream forwarding to finish")
-	forwardWG.Wait()
-
 	log.Info("capture done")
 
 	return nil
 }
 
+// ListInterfaces resolves the agents selected by the EndpointRequest and lists the network interfaces of each of them.
+// Agents that can not be queried are part of the response, with the error instead of interfaces.
+func (api *API) ListInterfaces(ctx context.Context, req *ListInstanceInterfacesRequest) (*ListInstanceInterfacesResponse, error) {
+	log := zap.L().With(zap.String(LogKeyHandler, "list-interfaces"))
+
+	if api.draining() {
+		return nil, errorf(codes.Unavailable, "api is draining")
+	}
+
+	if req == nil || req.Request == nil {
+		return nil, errorf(codes.InvalidArgument, "endpoint request: %w", errNilField)
+	}
+
+	ctx, log = setVcapID(ctx, log, nil)
+
+	targets, err := api.resolveAgentEndpoints(req.Request, log)
+	if errors.Is(err, ErrValidationFailed) {
+		return nil, errorf(codes.InvalidArgument, "targets not found: %w", err)
+	} else if err != nil {
+		return nil, errorf(codes.InvalidArgument, "could not resolve agent endpoints: %w", err)
+	}
+
+	return &ListInstanceInterfacesResponse{
+		Instances: api.listInterfaces(ctx, targets, log, api.listAgentInterfaces),
+	}, nil
+}
+
+// listInterfaces queries all targets in parallel using listAgent. The result contains one entry per target in the
+// order of targets.
+func (api *API) listInterfaces(ctx context.Context, targets []AgentEndpoint, log *zap.Logger, listAgent interfaceLister) []*InstanceInterfaces {
+	instances := make([]*InstanceInterfaces, len(targets))
+	creds := api.agentCreds()
+
+	var wg sync.WaitGroup
+	wg.Add(len(targets))
+	for i, target := range targets {
+		go func(i int, target AgentEndpoint) {
+			defer wg.Done()
+
+			instance := &InstanceInterfaces{Identifier: target.Identifier}
+			interfaces, err := listAgent(ctx, target, creds)
+			if err != nil {
+				log.Warn("listing interfaces failed", zap.String(LogKeyTarget, target.String()), zap.Error(err))
+				instance.Error = err.Error()
+			} else {
+				instance.Interfaces = interfaces
+			}
+
+			instances[i] = instance
+		}(i, target)
+	}
+	wg.Wait()
+
+	return instances
+}
+
 // resolveAgentEndpoints tries all registered api.resolvers until one responds or none can be found that
 // support this EndpointRequest. The responsible resolver is then queried for the applicable pcap-agent endpoints corresponding to this EndpointRequest.
 func (api *API) resolveAgentEndpoints(request *EndpointRequest, log *zap.Logger) ([]AgentEndpoint, error) {
-	for name, resolver := range api.resolvers {
+	for name, resolver := range api.resolverList() {
 		if resolver.CanResolve(request) {
 			log.Debug("resolving agent endpoints")
 			if !resolver.Healthy() {
 				return nil, fmt.Errorf("error while resolving request via %s: %w", name, ErrResolverUnhealthy)
 			}
 
+			start := time.Now()
 			agents, err := resolver.Resolve(request, log)
 			if err != nil {
+				resolveDuration.WithLabelValues(name, "error").Observe(time.Since(start).Seconds())
 				return nil, fmt.Errorf("error while resolving request via %s: %w", name, err)
 			}
+			resolveDuration.WithLabelValues(name, "success").Observe(time.Since(start).Seconds())
 
 			return agents, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no resolver for %v", request)
+	return nil, fmt.Errorf("no resolver for %v", RedactProto(request))
 }
 
 func checkAgentStatus(statusRes *StatusResponse, err error, target AgentEndpoint) error {
@@ -285,6 +512,10 @@ func checkAgentStatus(statusRes *StatusResponse, err error, target AgentEndpoint
 		statusErr := fmt.Errorf("incompatible versions for '%s': expected compatibility level >= %d but got %d", target, CompatibilityLevel, statusRes.CompatibilityLevel)
 		return statusErr
 	}
+
+	if statusRes.MaxCaptures > 0 && statusRes.RunningCaptures >= statusRes.MaxCaptures {
+		return errorf(codes.ResourceExhausted, "agent '%s' is full, %d of %d captures running: %w", target, statusRes.RunningCaptures, statusRes.MaxCaptures, errTooManyCaptures)
+	}
 	return nil
 }
 
@@ -316,14 +547,19 @@ func mergeResponseChannels(cs []<-chan *CaptureResponse, bufSize int) <-chan *Ca
 	return out
 }
 
-// connectToTarget creates connection to the agent. If the agent is available and healthy
-// a new capture is started using Agent.Capture.
-func connectToTarget(ctx context.Context, req *CaptureOptions, target AgentEndpoint, creds credentials.TransportCredentials, log *zap.Logger) (captureStream, error) {
-	cc, err := grpc.Dial(target.String(), grpc.WithTransportCredentials(creds))
+// connectToTarget gets the connection to the agent from the pool. If the agent is available and healthy
+// a new capture is started using Agent.Capture. The connection is released once the capture stream ends.
+func (api *API) connectToTarget(ctx context.Context, req *CaptureOptions, target AgentEndpoint, creds credentials.TransportCredentials, log *zap.Logger) (_ captureStream, err error) {
+	cc, release, err := api.agentConns.get(target, creds)
 	if err != nil {
 		err = fmt.Errorf("start capture from '%s': %w", target, err)
 		return nil, err
 	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
 
 	agent := NewAgentClient(cc)
 
@@ -356,6 +592,13 @@ func connectToTarget(ctx context.Context, req *CaptureOptions, target AgentEndpo
 	if err != nil {
 		return nil, err
 	}
+
+	// the context of the stream is done once the stream has ended.
+	go func() {
+		<-agentStream.Context().Done()
+		release()
+	}()
+
 	return agentStream, nil
 }
 
@@ -380,16 +623,22 @@ func readMsgFromStream(captureStream captureReceiver, target AgentEndpoint, bufS
 				out <- newMessageResponse(MessageType_CAPTURE_STOPPED, msg, target.Identifier)
 				return
 			}
+			// the agent only reports errors starting the capture, e.g. that it is full, with the first response.
 			if err != nil {
-				msg := fmt.Sprintf("Capturing stopped on agent pcap-agent %s: %v", target, err.Error())
-				out <- newMessageResponse(MessageType_INSTANCE_UNAVAILABLE, msg, target.Identifier)
-				return
-			}
-			code := status.Code(err)
-			if code != codes.OK {
 				out <- convertAgentStatusCodeToMsg(err, target.Identifier)
 				return
 			}
+			// the identifier of the target is used as origin to distinguish the responses of all targets.
+			// The metadata, statistics and discards describe the packets of the target and must carry the same origin.
+			if packet := msg.GetPacket(); packet != nil {
+				packet.Origin = target.Identifier
+			}
+			if message := msg.GetMessage(); message != nil {
+				message.Origin = target.Identifier
+				for _, discards := range message.GetDiscards().GetOrigins() {
+					discards.Origin = target.Identifier
+				}
+			}
 			out <- msg
 		}
 	}()
@@ -409,15 +658,28 @@ type requestReceiver interface {
 	Recv() (*CaptureRequest, error)
 }
 
-// stopCmd reads the next message from the stream. It ensures that the message
-// has a payload of StopCapture. If any error is encountered or the payload is
+// stopCmd reads the messages from the stream until it receives StopCapture.
+// Credits are granted to credits. If any error is encountered or the payload is
 // of a different type an appropriate cause is set and the cancel function is called.
-func stopCmd(cancel context.CancelCauseFunc, stream requestReceiver) {
+func stopCmd(cancel context.CancelCauseFunc, stream requestReceiver, credits *creditGate) {
 	go func() {
-		msg, err := stream.Recv()
-		if err != nil {
-			cancel(errorf(codes.Unknown, "read message: %w", err))
-			return
+		// the remaining packets are forwarded without credits once no more credits are granted.
+		defer credits.open()
+
+		var msg *CaptureRequest
+		for {
+			var err error
+			msg, err = stream.Recv()
+			if err != nil {
+				cancel(errorf(codes.Unknown, "read message: %w", err))
+				return
+			}
+
+			credit := msg.GetCredit()
+			if credit == nil {
+				break
+			}
+			credits.grant(credit.GetPackets())
 		}
 
 		if msg == nil || msg.Operation == nil {
@@ -447,49 +709,203 @@ type captureStream interface {
 
 type streamPreparer func(context.Context, *CaptureOptions, AgentEndpoint, credentials.TransportCredentials, *zap.Logger) (captureStream, error)
 
-func (api *API) capture(ctx context.Context, clientStream responseSender, opts *CaptureOptions, targets []AgentEndpoint, log *zap.Logger, prepareStream streamPreparer) (<-chan *CaptureResponse, error) {
-	var captureCs []<-chan *CaptureResponse
+// capture starts the capture on all targets concurrently, with at most AgentConnectConf.Parallelism targets being
+// connected to at the same time. It returns as soon as the capture has been started on as many targets as the
+// start policy requires, the other targets are added to the returned channel once they are ready. Targets that fail
+// or do not respond within AgentConnectConf.Timeout are reported as messages in the returned channel.
+//
+// If the request does not contain a policy the default of the api applies. Once the policy can not be met anymore,
+// the capture is stopped on all targets that did start and an error listing every failed target is returned.
+//
+// The filter of opts must already be patched with patchFilter to exclude the traffic of the pcap-api.
+func (api *API) capture(ctx context.Context, clientStream responseSender, opts *CaptureOptions, policy *StartPolicy, targets []AgentEndpoint, log *zap.Logger, prepareStream streamPreparer) (<-chan *CaptureResponse, error) {
+	// the filter is validated once for all targets. As the link types of their devices are not known yet, only filters
+	// that are invalid for every link type agents capture with are rejected.
+	err := validateFilterForLinkTypes(opts.Filter, captureLinkTypes, opts.SnapLen)
+	if err != nil {
+		return nil, errorf(codes.InvalidArgument, "invalid filter: %w", err)
+	}
+
+	opts.Limits = api.limits.apply(opts.Limits)
+	log.Debug("capture limits in effect", zap.Any("limits", opts.Limits))
 
-	runningCaptures := 0
-	patchedFilter, err := patchFilter(opts.Filter)
+	if policy.GetPolicy() == nil {
+		policy = api.startPolicy
+	}
+	required, err := requiredTargets(policy, len(targets))
 	if err != nil {
-		return nil, errorf(codes.FailedPrecondition, "expanding the pcap filter to exclude traffic to pcap-api failed: %w", err)
+		return nil, err
 	}
+	log.Debug("start policy in effect", zap.String("policy", describeStartPolicy(policy)), zap.Int("required", required))
+
+	results := api.connectTargets(ctx, opts, targets, log, prepareStream)
 
-	opts.Filter = patchedFilter
+	// wait until enough targets are ready, failures until then are kept to be forwarded later.
+	var started, failures []connectResult
+	var failed []*CaptureResponse
+	pending := len(targets)
+	for ; pending > 0 && len(started) < required; pending-- {
+		res := <-results
+		if res.err == nil {
+			started = append(started, res)
+			continue
+		}
+
+		failed = append(failed, res.failure(log))
+		failures = append(failures, res)
+		if len(failures) > len(targets)-required {
+			return nil, api.startPolicyFailed(clientStream, policy, started, failures, failed, results, pending-1, log)
+		}
+	}
+
+	out := make(chan *CaptureResponse, api.bufConf.Size)
+	go api.forwardTargets(ctx, out, started, failed, results, pending, opts.FlowControl, log)
+	return out, nil
+}
+
+// startPolicyFailed stops the capture on all started targets as well as on the pending targets that start later,
+// sends the failures to the client and returns the error describing all failed targets.
+func (api *API) startPolicyFailed(clientStream responseSender, policy *StartPolicy, started, failures []connectResult, failed []*CaptureResponse, results <-chan connectResult, pending int, log *zap.Logger) error {
+	for _, res := range started {
+		stopAndDrain(res, log)
+	}
+
+	// the remaining targets are waited for to report every failed target, each of them is bound by the timeout.
+	for ; pending > 0; pending-- {
+		res := <-results
+		if res.err == nil {
+			stopAndDrain(res, log)
+			continue
+		}
+		failed = append(failed, res.failure(log))
+		failures = append(failures, res)
+	}
+
+	for _, errMsg := range failed {
+		sendErr := clientStream.Send(errMsg)
+		if sendErr != nil {
+			log.Error(fmt.Sprintf("cannot send error to receiver: %s", errMsg.String()))
+		}
+	}
+
+	failedTargets := make([]string, 0, len(failures))
+	for _, res := range failures {
+		failedTargets = append(failedTargets, fmt.Sprintf("%s (%s): %v", res.target.Identifier, res.target, res.err))
+	}
+
+	log.Error("start policy not met", zap.String("policy", describeStartPolicy(policy)), zap.Strings("failed", failedTargets))
+	return errorf(codes.FailedPrecondition, "start policy (%s) not met, capture failed on %d targets: %s",
+		describeStartPolicy(policy), len(failures), strings.Join(failedTargets, "; "))
+}
+
+// stopAndDrain stops the capture on a started target and discards its remaining responses until the agent closes
+// the stream, which releases the connection to the agent.
+func stopAndDrain(res connectResult, log *zap.Logger) {
+	err := res.stream.Send(&AgentRequest{Payload: &AgentRequest_Stop{Stop: &StopAgentCapture{}}})
+	if err != nil {
+		log.Warn("unable to send stop request to agent", zap.String(LogKeyTarget, res.target.String()), zap.Error(err))
+	}
+
+	go func() {
+		for range readMsgFromStream(res.stream, res.target, 0) {
+			// the responses of stopped targets are discarded.
+		}
+	}()
+}
+
+// connectResult is the outcome of starting the capture on a single target.
+type connectResult struct {
+	target AgentEndpoint
+	stream captureStream
+	err    error
+}
+
+// failure records the failed connection attempt and converts it to the message for the client.
+func (r connectResult) failure(log *zap.Logger) *CaptureResponse {
+	countAgentConnectionFailure(r.err)
+	log.Info("capture cannot be started", zap.String(LogKeyTarget, r.target.String()), zap.Error(r.err))
+	return convertAgentStatusCodeToMsg(r.err, r.target.Identifier)
+}
+
+// connectTargets starts the capture on all targets using a bounded number of workers. Each target is given
+// AgentConnectConf.Timeout to respond. The returned channel receives exactly one result per target.
+func (api *API) connectTargets(ctx context.Context, opts *CaptureOptions, targets []AgentEndpoint, log *zap.Logger, prepareStream streamPreparer) <-chan connectResult {
+	results := make(chan connectResult, len(targets))
+
+	queue := make(chan AgentEndpoint, len(targets))
 	for _, target := range targets {
-		log = log.With(zap.String(LogKeyTarget, target.String()))
-		log.Info("starting capture")
+		queue <- target
+	}
+	close(queue)
 
-		var agentStream captureStream
-		agentStream, err = prepareStream(ctx, opts, target, api.tlsCredentials, log)
-		if err != nil {
-			errMsg := convertAgentStatusCodeToMsg(err, target.Identifier)
-			sendErr := clientStream.Send(errMsg)
-			if sendErr != nil {
-				log.Error(fmt.Sprintf("cannot send error to receiver: %s", errMsg.String()))
+	creds := api.agentCreds()
+	workers := min(api.connectConf.Parallelism, len(targets))
+	for i := 0; i < workers; i++ {
+		go func() {
+			for target := range queue {
+				targetLog := log.With(zap.String(LogKeyTarget, target.String()))
+				targetLog.Info("starting capture")
+
+				connectCtx, cancel := context.WithTimeout(ctx, api.connectConf.Timeout)
+				stream, err := prepareStream(connectCtx, opts, target, creds, targetLog)
+				cancel()
+
+				results <- connectResult{target: target, stream: stream, err: err}
 			}
+		}()
+	}
 
-			log.Info("capture cannot be started")
+	return results
+}
 
-			continue
+// forwardTargets forwards the responses of the started targets, the failures that occurred until they were ready
+// and the remaining results of pending targets to out. Closes out once all targets are done.
+//
+// With FlowControl CREDIT, the agents are granted credits for the packets that have been forwarded to out.
+func (api *API) forwardTargets(ctx context.Context, out chan<- *CaptureResponse, started []connectResult, failed []*CaptureResponse, results <-chan connectResult, pending int, flowControl FlowControl, log *zap.Logger) {
+	var wg sync.WaitGroup
+
+	forward := func(res connectResult) {
+		stream := &syncStream{captureStream: res.stream}
+
+		var granter *creditGranter
+		if flowControl == FlowControl_CREDIT {
+			granter = newCreditGranter(stream, api.bufConf.Size, log.With(zap.String(LogKeyTarget, res.target.String())))
 		}
 
-		runningCaptures++
+		c := readMsgFromStream(stream, res.target, api.bufConf.Size)
+		go stopAgentOnCancel(ctx, stream)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range c {
+				out <- msg
+				if granter != nil && msg.GetPacket() != nil {
+					granter.packetForwarded()
+				}
+			}
+		}()
+	}
 
-		c := readMsgFromStream(agentStream, target, api.bufConf.Size)
-		go stopAgentOnCancel(ctx, agentStream)
-		captureCs = append(captureCs, c)
+	for _, res := range started {
+		forward(res)
+	}
+	for _, errMsg := range failed {
+		out <- errMsg
 	}
 
-	if runningCaptures == 0 {
-		log.Error("starting of all captures failed during stream preparation")
-		return nil, errorf(codes.FailedPrecondition, "Starting of all captures failed")
+	for ; pending > 0; pending-- {
+		res := <-results
+		if res.err != nil {
+			out <- res.failure(log)
+			continue
+		}
+		forward(res)
 	}
 
-	// merge channels to one channel and send to forward to stream
-	out := mergeResponseChannels(captureCs, api.bufConf.Size)
-	return out, nil
+	wg.Wait()
+	close(out)
 }
 
 type captureSender interface {
@@ -513,6 +929,9 @@ func convertAgentStatusCodeToMsg(err error, targetIdentifier string) *CaptureRes
 			code = status.Code(unwrappedError)
 		}
 	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		code = codes.DeadlineExceeded
+	}
 
 	err = fmt.Errorf("capturing from agent %s: %w", targetIdentifier, err)
 
@@ -529,7 +948,7 @@ func convertAgentStatusCodeToMsg(err error, targetIdentifier string) *CaptureRes
 		return newMessageResponse(MessageType_START_CAPTURE_FAILED, err.Error(), targetIdentifier)
 	case codes.ResourceExhausted:
 		return newMessageResponse(MessageType_LIMIT_REACHED, err.Error(), targetIdentifier)
-	case codes.Unavailable:
+	case codes.Unavailable, codes.DeadlineExceeded:
 		return newMessageResponse(MessageType_INSTANCE_UNAVAILABLE, err.Error(), targetIdentifier)
 	default:
 		return newMessageResponse(MessageType_UNKNOWN, err.Error(), targetIdentifier)
@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -65,6 +70,13 @@ func TestReadMsg(t *testing.T) {
 			contextCancelled: false,
 			expectedData:     MessageType_INSTANCE_UNAVAILABLE,
 		},
+		{
+			name:             "Agent reached its capture limit",
+			captureStream:    &mockCaptureStream{nil, status.Error(codes.ResourceExhausted, "too many concurrent captures")},
+			target:           AgentEndpoint{IP: "172.20.0.2"},
+			contextCancelled: false,
+			expectedData:     MessageType_LIMIT_REACHED,
+		},
 		{
 			name:             "Capture stop request from client and capture stopped with EOF",
 			captureStream:    &mockCaptureStream{nil, io.EOF},
@@ -87,6 +99,54 @@ func TestReadMsg(t *testing.T) {
 	}
 }
 
+// mockSequenceCaptureStream returns the responses in order and io.EOF afterwards.
+type mockSequenceCaptureStream struct {
+	mockCaptureStream
+	responses []*CaptureResponse
+}
+
+func (m *mockSequenceCaptureStream) Recv() (*CaptureResponse, error) {
+	if len(m.responses) == 0 {
+		return nil, io.EOF
+	}
+	res := m.responses[0]
+	m.responses = m.responses[1:]
+	return res, nil
+}
+
+func TestReadMsgSetsPacketOrigin(t *testing.T) {
+	stream := &mockSequenceCaptureStream{
+		responses: []*CaptureResponse{
+			newMetadataResponse(layers.LinkTypeEthernet, TimestampPrecision_MICROSECONDS, agentOrigin, "eth0"),
+			newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
+			newPacketResponse([]byte("DEF"), gopacket.CaptureInfo{}, agentOrigin, 1),
+		},
+	}
+	target := AgentEndpoint{IP: "172.20.0.2", Port: 9494, Identifier: agentIdentifier}
+
+	out := readMsgFromStream(stream, target, bufSize)
+
+	packets := 0
+	for res := range out {
+		if message := res.GetMessage(); message.GetType() == MessageType_CAPTURE_METADATA && message.Origin != agentIdentifier {
+			t.Errorf("expected metadata origin %q, got %q", agentIdentifier, message.Origin)
+		}
+
+		packet := res.GetPacket()
+		if packet == nil {
+			continue
+		}
+		packets++
+		if packet.Origin != agentIdentifier {
+			t.Errorf("expected origin %q, got %q", agentIdentifier, packet.Origin)
+		}
+	}
+
+	if packets != 2 {
+		t.Errorf("expected 2 packets, got %d", packets)
+	}
+}
+
 func TestCheckAgentStatus(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -119,6 +179,18 @@ func TestCheckAgentStatus(t *testing.T) {
 			err:       nil,
 			wantErr:   false,
 		},
+		{
+			name:      "agent below its capture limit",
+			statusRes: &StatusResponse{Healthy: true, CompatibilityLevel: CompatibilityLevel, RunningCaptures: 1, MaxCaptures: 2},
+			err:       nil,
+			wantErr:   false,
+		},
+		{
+			name:      "agent at its capture limit",
+			statusRes: &StatusResponse{Healthy: true, CompatibilityLevel: CompatibilityLevel, RunningCaptures: 2, MaxCaptures: 2},
+			err:       nil,
+			wantErr:   true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -195,7 +267,7 @@ func TestStopCmd(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			ctx, cancel := context.WithCancelCause(ctx)
-			stopCmd(cancel, tt.recv)
+			stopCmd(cancel, tt.recv, nil)
 			<-ctx.Done()
 
 			err := context.Cause(ctx)
@@ -230,15 +302,15 @@ func TestMergeResponseChannels(t *testing.T) {
 	}{
 		{
 			name:       "each channel has one capture response",
-			crAgent1:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{})},
-			crAgent2:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{})},
+			crAgent1:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1)},
+			crAgent2:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1)},
 			wantOutLen: 2,
 		},
 
 		{
 			name:       "one channel is empty",
 			crAgent1:   []*CaptureResponse{},
-			crAgent2:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{})},
+			crAgent2:   []*CaptureResponse{newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1)},
 			wantOutLen: 1,
 		},
 
@@ -278,6 +350,7 @@ func TestCapture(t *testing.T) {
 	tests := []struct {
 		name           string
 		targets        []AgentEndpoint
+		opts           *CaptureOptions
 		stream         captureStream
 		err            error
 		wantStatusCode codes.Code
@@ -297,24 +370,47 @@ func TestCapture(t *testing.T) {
 			err:     nil,
 			wantErr: false,
 		},
+		{
+			name:           "Capture is rejected before contacting targets due to invalid filter",
+			targets:        []AgentEndpoint{{"localhost", 8083, agentIdentifier}, {"localhost", 8084, "router/2abc"}},
+			opts:           &CaptureOptions{Filter: "port 443 and"},
+			err:            errNilField,
+			wantStatusCode: codes.InvalidArgument,
+			wantErr:        true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			log := zap.L()
-			api, err := NewAPI(BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, nil, origin, 1)
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
 			if err != nil {
 				t.Errorf("capture() unexpected error during api creation: %v", err)
 			}
 
+			var connected atomic.Bool
 			var connectToTargetFn = func(ctx context.Context, req *CaptureOptions, target AgentEndpoint, creds credentials.TransportCredentials, log *zap.Logger) (captureStream, error) { //nolint:revive //keep vars even if unused for better context
+				connected.Store(true)
 				return tt.stream, tt.err
 			}
 
-			got, err := api.capture(context.Background(), &mockResponseSender{}, &CaptureOptions{}, tt.targets, log, connectToTargetFn)
+			opts := tt.opts
+			if opts == nil {
+				opts = &CaptureOptions{}
+			}
+
+			got, err := api.capture(context.Background(), &mockResponseSender{}, opts, nil, tt.targets, log, connectToTargetFn)
 			if (err != nil) != tt.wantErr && status.Code(err) != tt.wantStatusCode {
 				t.Errorf("capture() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantStatusCode == codes.InvalidArgument {
+				if status.Code(err) != codes.InvalidArgument {
+					t.Errorf("capture() status code = %v, want %v", status.Code(err), codes.InvalidArgument)
+				}
+				if connected.Load() {
+					t.Errorf("capture() connected to targets despite invalid filter")
+				}
+			}
 			if got != nil && !containsMsgType(got, MessageType_CAPTURE_STOPPED) {
 				t.Errorf("capture() expected message type = %v", MessageType_CAPTURE_STOPPED)
 			}
@@ -350,6 +446,214 @@ func (h HealthyResolver) Healthy() bool {
 	return true
 }
 
+// TestCaptureStartsWithFirstReadyTarget checks that the capture starts streaming as soon as one target is ready,
+// and that targets which fail or do not respond in time are reported as unavailable.
+func TestCaptureStartsWithFirstReadyTarget(t *testing.T) {
+	connectTimeout := 200 * time.Millisecond
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1, AgentConnect: AgentConnectConf{Timeout: connectTimeout}})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+
+	ready := AgentEndpoint{IP: "localhost", Port: 8083, Identifier: "router/ready"}
+	hanging := AgentEndpoint{IP: "localhost", Port: 8084, Identifier: "router/hanging"}
+	failing := AgentEndpoint{IP: "localhost", Port: 8085, Identifier: "router/failing"}
+
+	prepare := func(ctx context.Context, _ *CaptureOptions, target AgentEndpoint, _ credentials.TransportCredentials, _ *zap.Logger) (captureStream, error) {
+		switch target {
+		case ready:
+			return &mockCaptureStream{nil, io.EOF}, nil
+		case hanging:
+			<-ctx.Done()
+			return nil, fmt.Errorf("status request: %w", ctx.Err())
+		default:
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+	}
+
+	start := time.Now()
+	out, err := api.capture(context.Background(), &mockResponseSender{}, &CaptureOptions{}, nil, []AgentEndpoint{hanging, failing, ready}, zap.L(), prepare)
+	if err != nil {
+		t.Fatalf("capture() unexpected error: %v", err)
+	}
+	if time.Since(start) >= connectTimeout {
+		t.Errorf("capture() waited %v for the hanging target, expected to start with the first ready target", time.Since(start))
+	}
+
+	messages := map[string]MessageType{}
+	for res := range out {
+		if msg := res.GetMessage(); msg != nil {
+			messages[msg.Origin] = msg.Type
+		}
+	}
+
+	want := map[string]MessageType{
+		ready.Identifier:   MessageType_CAPTURE_STOPPED,
+		hanging.Identifier: MessageType_INSTANCE_UNAVAILABLE,
+		failing.Identifier: MessageType_INSTANCE_UNAVAILABLE,
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("capture() messages = %v, want %v", messages, want)
+	}
+}
+
+// TestCaptureConnectParallelism checks that no more than the configured number of targets are connected to at the
+// same time, while all targets are connected eventually.
+func TestCaptureConnectParallelism(t *testing.T) {
+	parallelism := 2
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1, AgentConnect: AgentConnectConf{Parallelism: parallelism}})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+
+	var targets []AgentEndpoint
+	for i := 0; i < 6; i++ {
+		targets = append(targets, AgentEndpoint{IP: "localhost", Port: 8083 + i, Identifier: fmt.Sprintf("router/%d", i)})
+	}
+
+	var running, maxRunning, connected atomic.Int32
+	prepare := func(_ context.Context, _ *CaptureOptions, _ AgentEndpoint, _ credentials.TransportCredentials, _ *zap.Logger) (captureStream, error) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			highest := maxRunning.Load()
+			if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		connected.Add(1)
+		return &mockCaptureStream{nil, io.EOF}, nil
+	}
+
+	out, err := api.capture(context.Background(), &mockResponseSender{}, &CaptureOptions{}, nil, targets, zap.L(), prepare)
+	if err != nil {
+		t.Fatalf("capture() unexpected error: %v", err)
+	}
+	for range out {
+		// drain until all targets are done
+	}
+
+	if int(connected.Load()) != len(targets) {
+		t.Errorf("connected to %d targets, want %d", connected.Load(), len(targets))
+	}
+	if int(maxRunning.Load()) > parallelism {
+		t.Errorf("connected to %d targets at the same time, want at most %d", maxRunning.Load(), parallelism)
+	}
+}
+
+// TestCaptureStartPolicy checks that the capture fails with FailedPrecondition if the start policy is not met, and
+// that the capture is stopped on the targets that did start.
+func TestCaptureStartPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        *StartPolicy
+		defaultPolicy StartPolicyConf
+		failing       int
+		wantCode      codes.Code
+	}{
+		{name: "default requires one target", failing: 3},
+		{name: "default fails without any target", failing: 4, wantCode: codes.FailedPrecondition},
+		{name: "all targets started", policy: &StartPolicy{Policy: &StartPolicy_All{All: true}}},
+		{name: "not all targets started", policy: &StartPolicy{Policy: &StartPolicy_All{All: true}}, failing: 1, wantCode: codes.FailedPrecondition},
+		{name: "minimum number of targets started", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 2}}, failing: 2},
+		{name: "minimum number of targets not started", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 2}}, failing: 3, wantCode: codes.FailedPrecondition},
+		{name: "percentage of targets started", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 75}}, failing: 1},
+		{name: "percentage of targets not started", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 75}}, failing: 2, wantCode: codes.FailedPrecondition},
+		{name: "api default applies", defaultPolicy: StartPolicyConf{All: true}, failing: 1, wantCode: codes.FailedPrecondition},
+		{name: "request overrides api default", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 3}}, defaultPolicy: StartPolicyConf{All: true}, failing: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1, AgentConnect: AgentConnectConf{StartPolicy: tt.defaultPolicy}})
+			if err != nil {
+				t.Fatalf("unexpected error during api creation: %v", err)
+			}
+
+			var targets []AgentEndpoint
+			streams := map[AgentEndpoint]*stoppableCaptureStream{}
+			for i := 0; i < 4; i++ {
+				target := AgentEndpoint{IP: "localhost", Port: 8083 + i, Identifier: fmt.Sprintf("router/%d", i)}
+				targets = append(targets, target)
+				if i >= tt.failing {
+					streams[target] = newStoppableCaptureStream()
+				}
+			}
+
+			prepare := func(_ context.Context, _ *CaptureOptions, target AgentEndpoint, _ credentials.TransportCredentials, _ *zap.Logger) (captureStream, error) {
+				stream, ok := streams[target]
+				if !ok {
+					return nil, status.Error(codes.Unavailable, "connection refused")
+				}
+				return stream, nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			out, err := api.capture(ctx, &mockResponseSender{}, &CaptureOptions{}, tt.policy, targets, zap.L(), prepare)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("capture() code = %v, wantCode %v (%v)", status.Code(err), tt.wantCode, err)
+			}
+
+			if err == nil {
+				// stop the capture, which is forwarded to the started targets.
+				cancel()
+				for range out {
+					// drain until all targets are done
+				}
+				return
+			}
+
+			for _, target := range targets[:tt.failing] {
+				if !strings.Contains(err.Error(), target.Identifier) {
+					t.Errorf("capture() error %q does not list failed target %s", err, target.Identifier)
+				}
+			}
+			for target, stream := range streams {
+				select {
+				case <-stream.stopped:
+				case <-time.After(time.Second):
+					t.Errorf("capture on started target %s was not stopped", target.Identifier)
+				}
+			}
+		})
+	}
+}
+
+// stoppableCaptureStream is a capture stream that ends once the stop request has been sent.
+type stoppableCaptureStream struct {
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newStoppableCaptureStream() *stoppableCaptureStream {
+	return &stoppableCaptureStream{stopped: make(chan struct{})}
+}
+
+func (s *stoppableCaptureStream) Recv() (*CaptureResponse, error) {
+	<-s.stopped
+	return nil, io.EOF
+}
+
+func (s *stoppableCaptureStream) Send(req *AgentRequest) error {
+	if req.GetStop() != nil {
+		s.stopOnce.Do(func() {
+			close(s.stopped)
+		})
+	}
+	return nil
+}
+
+func (s *stoppableCaptureStream) CloseSend() error {
+	return nil
+}
+
+func (s *stoppableCaptureStream) Context() context.Context {
+	return context.Background()
+}
+
 func TestAPIStatus(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -369,7 +673,7 @@ func TestAPIStatus(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			api, err := NewAPI(BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, nil, origin, 1)
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
 			api.RegisterResolver(HealthyResolver{})
 			if err != nil {
 				t.Errorf("Status() unexpected error during api creation: %v", err)
@@ -389,6 +693,107 @@ func TestAPIStatus(t *testing.T) {
 	}
 }
 
+func TestAPIReplaceResolvers(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+	api.RegisterResolver(HealthyResolver{})
+
+	api.ReplaceResolvers()
+	if api.HasResolver(HealthyResolver{}.Name()) || api.healthy() {
+		t.Errorf("ReplaceResolvers() expected the previous resolvers to be removed")
+	}
+
+	api.ReplaceResolvers(HealthyResolver{})
+	if !api.HasResolver(HealthyResolver{}.Name()) || !api.healthy() {
+		t.Errorf("ReplaceResolvers() expected the resolver to be registered")
+	}
+}
+
+func TestAPIListInterfaces(t *testing.T) {
+	tests := []struct {
+		name           string
+		draining       bool
+		req            *ListInstanceInterfacesRequest
+		wantStatusCode codes.Code
+	}{
+		{
+			name:           "api draining",
+			draining:       true,
+			req:            &ListInstanceInterfacesRequest{Request: &EndpointRequest{}},
+			wantStatusCode: codes.Unavailable,
+		},
+		{
+			name:           "missing endpoint request",
+			req:            &ListInstanceInterfacesRequest{},
+			wantStatusCode: codes.InvalidArgument,
+		},
+		{
+			name:           "no targets",
+			req:            &ListInstanceInterfacesRequest{Request: &EndpointRequest{}},
+			wantStatusCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
+			if err != nil {
+				t.Fatalf("ListInterfaces() unexpected error during api creation: %v", err)
+			}
+			api.RegisterResolver(HealthyResolver{})
+
+			if tt.draining {
+				api.Stop()
+			}
+
+			_, err = api.ListInterfaces(context.Background(), tt.req)
+			if status.Code(err) != tt.wantStatusCode {
+				t.Errorf("ListInterfaces() status code = %v, want %v, error = %v", status.Code(err), tt.wantStatusCode, err)
+			}
+		})
+	}
+}
+
+func TestListInterfacesPerTarget(t *testing.T) {
+	targets := []AgentEndpoint{{"localhost", 8083, agentIdentifier}, {"localhost", 8084, "router/2abc"}, {"localhost", 8085, "router/3def"}}
+
+	listAgent := func(_ context.Context, target AgentEndpoint, _ credentials.TransportCredentials) ([]*NetworkInterface, error) {
+		if target.Port == 8084 {
+			return nil, errors.New("agent unavailable")
+		}
+		return []*NetworkInterface{{Name: "eth0"}, {Name: fmt.Sprintf("veth%d", target.Port)}}, nil
+	}
+
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("listInterfaces() unexpected error during api creation: %v", err)
+	}
+
+	instances := api.listInterfaces(context.Background(), targets, zap.L(), listAgent)
+	if len(instances) != len(targets) {
+		t.Fatalf("listInterfaces() returned %d instances, want %d", len(instances), len(targets))
+	}
+
+	for i, target := range targets {
+		instance := instances[i]
+		if instance.Identifier != target.Identifier {
+			t.Errorf("listInterfaces()[%d] identifier = %s, want %s", i, instance.Identifier, target.Identifier)
+		}
+
+		if target.Port == 8084 {
+			if instance.Error == "" || len(instance.Interfaces) != 0 {
+				t.Errorf("listInterfaces()[%d] expected error without interfaces, got %v", i, instance)
+			}
+			continue
+		}
+
+		if instance.Error != "" || len(instance.Interfaces) != 2 {
+			t.Errorf("listInterfaces()[%d] expected two interfaces without error, got %v", i, instance)
+		}
+	}
+}
+
 func TestAPICapture(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -421,7 +826,7 @@ func TestAPICapture(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			api, err := NewAPI(BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, nil, origin, 1)
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
 			if err != nil {
 				t.Errorf("Capture() unexpected error during api creation: %v", err)
 			}
@@ -443,6 +848,31 @@ func TestAPICapture(t *testing.T) {
 	}
 }
 
+func TestAPICaptureLimitPerIdentity(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 2, ConcurrentCapturesPerUser: 1})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+	api.RegisterResolver(HealthyResolver{})
+
+	_, release := api.identityCaptures.acquire("alice")
+	defer release()
+
+	token := signedToken(t, jwt.MapClaims{"user_name": "alice"})
+	stream := &mockRequestReceiver{
+		req:     &CaptureRequest{Operation: &CaptureRequest_Start{Start: &StartCapture{Request: &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{Token: token}}}}}},
+		context: context.Background(),
+	}
+
+	err = api.Capture(stream)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Capture() expected ResourceExhausted, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "alice, 1 of 1 captures running") {
+		t.Errorf("Capture() expected the count and the limit of alice in the error, got %v", err)
+	}
+}
+
 func TestConvertStatusCodeToMsg(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -479,6 +909,21 @@ func TestConvertStatusCodeToMsg(t *testing.T) {
 			err:         errorf(codes.ResourceExhausted, "read message: %w", fmt.Errorf("limit reached")),
 			wantMsgType: MessageType_LIMIT_REACHED,
 		},
+		{
+			name:        "Agent is at its capture limit",
+			err:         checkAgentStatus(&StatusResponse{Healthy: true, CompatibilityLevel: CompatibilityLevel, RunningCaptures: 1, MaxCaptures: 1}, nil, AgentEndpoint{IP: "localhost", Port: 8083}),
+			wantMsgType: MessageType_LIMIT_REACHED,
+		},
+		{
+			name:        "Agent did not respond in time",
+			err:         fmt.Errorf("status request: %w", status.Error(codes.DeadlineExceeded, "context deadline exceeded")),
+			wantMsgType: MessageType_INSTANCE_UNAVAILABLE,
+		},
+		{
+			name:        "Agent connect timeout",
+			err:         fmt.Errorf("start capture: %w", context.DeadlineExceeded),
+			wantMsgType: MessageType_INSTANCE_UNAVAILABLE,
+		},
 		{
 			name:        "Agent unknown error",
 			err:         errorf(codes.Unknown, "read message: %w", fmt.Errorf("unknown")),
@@ -0,0 +1,246 @@
+package pcap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/status"
+)
+
+// AuditEvent is the event of a capture an AuditRecord is written for.
+type AuditEvent string
+
+const (
+	AuditCaptureStarted AuditEvent = "capture_started"
+	AuditCaptureEnded   AuditEvent = "capture_ended"
+	// AuditCaptureRejected is written for requests that are denied before a capture is started, e.g. because the
+	// client is not authorized or a limit has been reached.
+	AuditCaptureRejected AuditEvent = "capture_rejected"
+	// AuditCaptureFailed is written for authorized captures that could not be started on the targets.
+	AuditCaptureFailed AuditEvent = "capture_failed"
+
+	auditFileMode = 0o600
+)
+
+// AuditRecord documents who captured what, where and for how long. The pcap-api writes one record when a capture
+// starts and one when it ends or fails to start to all registered AuditSinks. Requests that are denied are recorded
+// as rejected, their identity is the user claimed by the token if the token could not be verified.
+type AuditRecord struct {
+	Event AuditEvent `json:"event"`
+	Time  time.Time  `json:"time"`
+	// API is the id of the pcap-api instance that handled the capture.
+	API      string `json:"api"`
+	VcapID   string `json:"vcap_id,omitempty"`
+	Identity string `json:"identity"`
+	// Deployment and Groups are set for BOSH captures.
+	Deployment string   `json:"deployment,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	// AppID is set for Cloud Foundry captures.
+	AppID   string   `json:"app_id,omitempty"`
+	Targets []string `json:"targets"`
+	Devices []string `json:"devices"`
+	// Filter is the effective filter, which excludes the traffic of the capture itself. Rejected records contain
+	// the filter requested by the client.
+	Filter  string `json:"filter"`
+	SnapLen uint32 `json:"snaplen"`
+	// Reason is only set for rejected and failed captures.
+	Reason string `json:"reason,omitempty"`
+	// End is only set once the capture has ended.
+	End *AuditEnd `json:"end,omitempty"`
+}
+
+// AuditEnd is the outcome of a capture.
+type AuditEnd struct {
+	DurationSeconds float64 `json:"duration_seconds"`
+	Packets         uint64  `json:"packets"`
+	Bytes           uint64  `json:"bytes"`
+	StopReason      string  `json:"stop_reason"`
+	StopDetail      string  `json:"stop_detail,omitempty"`
+}
+
+// AuditSink writes AuditRecords, e.g. to a file. Sinks must be safe for concurrent use.
+type AuditSink interface {
+	Write(record *AuditRecord) error
+}
+
+// RegisterAuditSink adds sink to the sinks all audit records are written to.
+func (api *API) RegisterAuditSink(sink AuditSink) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	api.auditSinks = append(api.auditSinks, sink)
+}
+
+// audit writes record to all registered sinks. Failing sinks are logged, they do not affect the capture.
+func (api *API) audit(record *AuditRecord, log *zap.Logger) {
+	api.mu.RLock()
+	sinks := api.auditSinks
+	api.mu.RUnlock()
+
+	for _, sink := range sinks {
+		err := sink.Write(record)
+		if err != nil {
+			log.Error("unable to write audit record", zap.String("event", string(record.Event)), zap.Error(err))
+		}
+	}
+}
+
+// auditRejected writes the record of a request that has been denied with err and returns err. Request and opts are
+// nil if the request has been denied before it was read.
+func (api *API) auditRejected(ctx context.Context, request *EndpointRequest, opts *CaptureOptions, err error, log *zap.Logger) error {
+	audit := newCaptureAudit(ctx, api.id, captureIdentity(ctx, request), request, opts, nil)
+	api.audit(audit.rejected(status.Convert(err).Message()), log)
+	return err
+}
+
+// captureAudit creates the audit records of a single capture.
+type captureAudit struct {
+	record AuditRecord
+	start  time.Time
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+// newCaptureAudit creates the audit of a capture of targets with opts, requested by identity with request. Request,
+// opts and targets are nil if the request is rejected before they are known.
+func newCaptureAudit(ctx context.Context, apiID string, identity string, request *EndpointRequest, opts *CaptureOptions, targets []AgentEndpoint) *captureAudit {
+	record := AuditRecord{
+		API:        apiID,
+		Identity:   identity,
+		Deployment: request.GetBosh().GetDeployment(),
+		Groups:     request.GetBosh().GetGroups(),
+		AppID:      request.GetCf().GetAppId(),
+		Targets:    make([]string, 0, len(targets)),
+		Devices:    []string{},
+		Filter:     opts.GetFilter(),
+		SnapLen:    opts.GetSnapLen(),
+	}
+	if opts != nil {
+		record.Devices = opts.captureDevices()
+	}
+	if vcapID, ok := ctx.Value(HeaderVcapID).(string); ok {
+		record.VcapID = vcapID
+	}
+	for _, target := range targets {
+		record.Targets = append(record.Targets, target.Identifier)
+	}
+
+	return &captureAudit{record: record, start: time.Now(), now: time.Now}
+}
+
+// started returns the record of the start of the capture.
+func (a *captureAudit) started() *AuditRecord {
+	record := a.record
+	record.Event = AuditCaptureStarted
+	record.Time = a.start
+	return &record
+}
+
+// rejected returns the record of a request that has been denied for reason.
+func (a *captureAudit) rejected(reason string) *AuditRecord {
+	record := a.record
+	record.Event = AuditCaptureRejected
+	record.Time = a.now()
+	record.Reason = reason
+	return &record
+}
+
+// failed returns the record of a capture that could not be started for reason.
+func (a *captureAudit) failed(reason string) *AuditRecord {
+	record := a.record
+	record.Event = AuditCaptureFailed
+	record.Time = a.now()
+	record.Reason = reason
+	return &record
+}
+
+// ended returns the record of the end of the capture. The packets and bytes are taken from summary, which may be
+// nil if the capture ended before any data has been forwarded.
+func (a *captureAudit) ended(summary *CaptureSummary, reason StopReason, detail string) *AuditRecord {
+	record := a.record
+	record.Event = AuditCaptureEnded
+	record.Time = a.now()
+	record.End = &AuditEnd{
+		DurationSeconds: record.Time.Sub(a.start).Seconds(),
+		StopReason:      reason.String(),
+		StopDetail:      detail,
+	}
+	for _, agent := range summary.GetAgents() {
+		record.End.Packets += agent.GetPackets()
+		record.End.Bytes += agent.GetBytes()
+	}
+	return &record
+}
+
+// writerAuditSink writes each record as a line of JSON to w.
+type writerAuditSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterAuditSink creates an AuditSink that writes each record as a line of JSON to w, e.g. to os.Stdout.
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerAuditSink{w: w}
+}
+
+func (s *writerAuditSink) Write(record *AuditRecord) error {
+	line, err := marshalAuditRecord(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(line)
+	return err
+}
+
+// fileAuditSink appends each record as a line of JSON to a file.
+type fileAuditSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileAuditSink creates an AuditSink that appends each record as a line of JSON to the file at path. The file is
+// opened for each record, so it can be rotated without notifying the pcap-api.
+func NewFileAuditSink(path string) AuditSink {
+	return &fileAuditSink{path: path}
+}
+
+func (s *fileAuditSink) Write(record *AuditRecord) error {
+	line, err := marshalAuditRecord(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, auditFileMode)
+	if err != nil {
+		return fmt.Errorf("open audit file: %w", err)
+	}
+
+	_, err = f.Write(line)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write audit file: %w", err)
+	}
+	return f.Close()
+}
+
+// marshalAuditRecord returns record as a line of JSON.
+func marshalAuditRecord(record *AuditRecord) ([]byte, error) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("marshal audit record: %w", err)
+	}
+	return append(line, '\n'), nil
+}
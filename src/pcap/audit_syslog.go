@@ -0,0 +1,30 @@
+//go:build unix
+
+package pcap
+
+import (
+	"log/syslog"
+)
+
+// syslogAuditSink sends each record as JSON to syslog.
+type syslogAuditSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogAuditSink creates an AuditSink that sends each record as JSON to the syslog server at address, using
+// network, e.g. "udp". The local syslog server is used if network and address are empty.
+func NewSyslogAuditSink(network, address, tag string) (AuditSink, error) {
+	w, err := syslog.Dial(network, address, syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogAuditSink{w: w}, nil
+}
+
+func (s *syslogAuditSink) Write(record *AuditRecord) error {
+	line, err := marshalAuditRecord(record)
+	if err != nil {
+		return err
+	}
+	return s.w.Info(string(line))
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package pcap
+
+import (
+	"errors"
+)
+
+// errSyslogUnsupported is returned by NewSyslogAuditSink on platforms without syslog.
+var errSyslogUnsupported = errors.New("syslog audit sink is not supported on this platform")
+
+// NewSyslogAuditSink is not supported on this platform, see the unix implementation.
+func NewSyslogAuditSink(_, _, _ string) (AuditSink, error) {
+	return nil, errSyslogUnsupported
+}
@@ -0,0 +1,262 @@
+package pcap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+)
+
+func TestCaptureAudit(t *testing.T) {
+	ctx := context.WithValue(context.Background(), HeaderVcapID, "1234")
+	request := &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{Deployment: "cf", Groups: []string{"router"}}}}
+	opts := &CaptureOptions{Devices: []string{"eth0", "eth1"}, Filter: "not (ip host 10.0.0.1) and (tcp port 443)", SnapLen: 65000}
+	targets := []AgentEndpoint{{Identifier: "router/1"}, {Identifier: "router/2"}}
+
+	audit := newCaptureAudit(ctx, "pcap-api/1", "alice", request, opts, targets)
+	audit.start = time.Unix(1000, 0)
+	audit.now = func() time.Time { return time.Unix(1060, 0) }
+
+	started := audit.started()
+	expected := &AuditRecord{
+		Event:      AuditCaptureStarted,
+		Time:       time.Unix(1000, 0),
+		API:        "pcap-api/1",
+		VcapID:     "1234",
+		Identity:   "alice",
+		Deployment: "cf",
+		Groups:     []string{"router"},
+		Targets:    []string{"router/1", "router/2"},
+		Devices:    []string{"eth0", "eth1"},
+		Filter:     "not (ip host 10.0.0.1) and (tcp port 443)",
+		SnapLen:    65000,
+	}
+	if !reflect.DeepEqual(started, expected) {
+		t.Errorf("started() = %+v, want %+v", started, expected)
+	}
+
+	summary := &CaptureSummary{Agents: []*AgentSummary{{Packets: 2, Bytes: 20}, {Packets: 1, Bytes: 5}}}
+	ended := audit.ended(summary, StopReason_LIMIT, "max packets reached")
+	expectedEnd := &AuditEnd{DurationSeconds: 60, Packets: 3, Bytes: 25, StopReason: "LIMIT", StopDetail: "max packets reached"}
+	if ended.Event != AuditCaptureEnded || !reflect.DeepEqual(ended.End, expectedEnd) {
+		t.Errorf("ended() = %+v with %+v, want %+v", ended, ended.End, expectedEnd)
+	}
+	if started.End != nil {
+		t.Errorf("ended() expected the start record to be unchanged")
+	}
+
+	failed := audit.failed("agent unavailable")
+	if failed.Event != AuditCaptureFailed || failed.Reason != "agent unavailable" || failed.End != nil || !failed.Time.Equal(time.Unix(1060, 0)) {
+		t.Errorf("failed() = %+v, want a failure record", failed)
+	}
+
+	rejected := newCaptureAudit(ctx, "pcap-api/1", anonymousIdentity, nil, nil, nil).rejected("limit reached")
+	expectedRejected := &AuditRecord{
+		Event:    AuditCaptureRejected,
+		Time:     rejected.Time,
+		API:      "pcap-api/1",
+		VcapID:   "1234",
+		Identity: anonymousIdentity,
+		Targets:  []string{},
+		Devices:  []string{},
+		Reason:   "limit reached",
+	}
+	if !reflect.DeepEqual(rejected, expectedRejected) {
+		t.Errorf("rejected() = %+v, want %+v", rejected, expectedRejected)
+	}
+}
+
+func TestWriterAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterAuditSink(&buf)
+
+	err := sink.Write(&AuditRecord{Event: AuditCaptureStarted, Identity: "alice"})
+	if err != nil {
+		t.Fatalf("Write() unexpected error: %v", err)
+	}
+
+	var record AuditRecord
+	err = json.Unmarshal(buf.Bytes(), &record)
+	if err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	if record.Identity != "alice" || buf.Bytes()[buf.Len()-1] != '\n' {
+		t.Errorf("expected the record as a line of JSON, got %q", buf.String())
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := NewFileAuditSink(path)
+
+	for _, event := range []AuditEvent{AuditCaptureStarted, AuditCaptureEnded} {
+		err := sink.Write(&AuditRecord{Event: event, Identity: "alice"})
+		if err != nil {
+			t.Fatalf("Write() unexpected error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record AuditRecord
+		err = json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			t.Fatalf("expected a JSON record per line, got %q: %v", scanner.Text(), err)
+		}
+		events = append(events, record.Event)
+	}
+
+	if !reflect.DeepEqual(events, []AuditEvent{AuditCaptureStarted, AuditCaptureEnded}) {
+		t.Errorf("expected the records to be appended, got %v", events)
+	}
+}
+
+type recordingAuditSink struct {
+	mu      sync.Mutex
+	records []*AuditRecord
+}
+
+func (s *recordingAuditSink) Write(record *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestAPICaptureAudit(t *testing.T) {
+	tests := []struct {
+		name                  string
+		resolver              AgentResolver
+		maxConcurrentCaptures int32
+		identityRunning       bool
+		expectedEvents        []AuditEvent
+		expectedIdentity      string
+		expectedReason        string
+	}{
+		{
+			name:                  "capture fails to start without targets",
+			resolver:              HealthyResolver{},
+			maxConcurrentCaptures: 1,
+			expectedEvents:        []AuditEvent{AuditCaptureStarted, AuditCaptureFailed},
+			expectedIdentity:      "alice",
+			expectedReason:        "start policy requires 1 targets",
+		},
+		{
+			name:                  "request can not be resolved",
+			maxConcurrentCaptures: 1,
+			expectedEvents:        []AuditEvent{AuditCaptureRejected},
+			expectedIdentity:      "alice",
+			expectedReason:        "could not resolve agent endpoints",
+		},
+		{
+			name:                  "limit per identity reached",
+			resolver:              HealthyResolver{},
+			maxConcurrentCaptures: 2,
+			identityRunning:       true,
+			expectedEvents:        []AuditEvent{AuditCaptureRejected},
+			expectedIdentity:      "alice",
+			expectedReason:        "1 of 1 captures running",
+		},
+		{
+			name:             "global limit reached",
+			resolver:         HealthyResolver{},
+			expectedEvents:   []AuditEvent{AuditCaptureRejected},
+			expectedIdentity: anonymousIdentity,
+			expectedReason:   "0 of 0 captures running",
+		},
+	}
+
+	defer func(addrs func() ([]net.Addr, error)) { interfaceAddrs = addrs }(interfaceAddrs)
+	interfaceAddrs = func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.IPv4(100, 100, 100, 100)}}, nil
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: tt.maxConcurrentCaptures, ConcurrentCapturesPerUser: 1})
+			if err != nil {
+				t.Fatalf("unexpected error during api creation: %v", err)
+			}
+			if tt.resolver != nil {
+				api.RegisterResolver(tt.resolver)
+			}
+			if tt.identityRunning {
+				_, release := api.identityCaptures.acquire("alice")
+				defer release()
+			}
+			sink := &recordingAuditSink{}
+			api.RegisterAuditSink(sink)
+
+			token := signedToken(t, jwt.MapClaims{"user_name": "alice"})
+			stream := &mockRequestReceiver{
+				req:     &CaptureRequest{Operation: &CaptureRequest_Start{Start: &StartCapture{Request: &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{Token: token, Deployment: "cf"}}}, Options: &CaptureOptions{Device: "eth0", Filter: "port 443", SnapLen: 65000}}}},
+				context: context.Background(),
+			}
+
+			err = api.Capture(stream)
+			if err == nil {
+				t.Fatalf("Capture() expected an error")
+			}
+
+			if len(sink.records) != len(tt.expectedEvents) {
+				t.Fatalf("expected %d records, got %+v", len(tt.expectedEvents), sink.records)
+			}
+			for i, record := range sink.records {
+				if record.Event != tt.expectedEvents[i] || record.Identity != tt.expectedIdentity || record.VcapID == "" {
+					t.Errorf("unexpected record %+v, want event %s of %s", record, tt.expectedEvents[i], tt.expectedIdentity)
+				}
+				// captures that are authorized record the effective filter, which excludes the traffic of the pcap-api.
+				if record.Event != AuditCaptureRejected && record.Filter != "not (ip host 100.100.100.100) and (port 443)" {
+					t.Errorf("expected the effective filter in %s record, got %q", record.Event, record.Filter)
+				}
+			}
+
+			last := sink.records[len(sink.records)-1]
+			if !strings.Contains(last.Reason, tt.expectedReason) {
+				t.Errorf("expected the reason to contain %q, got %q", tt.expectedReason, last.Reason)
+			}
+			if last.Event != AuditCaptureRejected || tt.maxConcurrentCaptures == 0 {
+				return
+			}
+			// the request is known for captures rejected after it has been read.
+			if last.Deployment != "cf" || last.Filter != "port 443" || !reflect.DeepEqual(last.Devices, []string{"eth0"}) {
+				t.Errorf("expected the request in the rejected record, got %+v", last)
+			}
+		})
+	}
+}
+
+func TestAPIAuditFailingSink(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+	api.RegisterAuditSink(NewFileAuditSink(filepath.Join(t.TempDir(), "missing", "audit.log")))
+	sink := &recordingAuditSink{}
+	api.RegisterAuditSink(sink)
+
+	api.audit(&AuditRecord{Event: AuditCaptureStarted}, zap.L())
+
+	if len(sink.records) != 1 {
+		t.Errorf("expected the other sinks to receive the record, got %d records", len(sink.records))
+	}
+}
@@ -219,7 +219,8 @@ func (br *BoshResolver) Validate(endpointRequest *EndpointRequest) error {
 //
 // Returns an error if the connection to the BOSH Director is not possible.
 func (br *BoshResolver) setup() error {
-	br.logger.Debug("setting up BoshResolver", zap.Any("resolver-config", br.Config))
+	// the config is not logged as a whole, so future secrets in it are not logged by accident.
+	br.logger.Debug("setting up BoshResolver", zap.String("director-url", br.Config.RawDirectorURL), zap.Int("agent-port", br.Config.AgentPort), zap.String("required-scope", br.Config.TokenScope))
 
 	timeout := 500 * time.Millisecond //nolint:mnd // reasonable value.
 
@@ -292,7 +293,7 @@ func (br *BoshResolver) Authenticate(authToken string) error {
 	}
 
 	if errors.Is(err, ErrNotAuthorized) {
-		return fmt.Errorf("token %s does not have the permissions or is not supported: %w", authToken, err)
+		return fmt.Errorf("token does not have the permissions or is not supported: %w", err)
 	}
 	return fmt.Errorf("could not verify token: %w", err)
 }
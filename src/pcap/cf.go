@@ -1,42 +1,236 @@
 package pcap
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
+var CloudfoundryResolverName = "cf"
+
+// DefaultCloudfoundryAppType is the process type that is used when a CloudfoundryRequest does not define an appType.
+// It matches the default process type used by the cf CLI.
+const DefaultCloudfoundryAppType = "web"
+
+// cfInstanceStateRunning is the state of a process instance that is up and can be captured from.
+const cfInstanceStateRunning = "RUNNING"
+
+// cfErrorBodyLimit is the number of bytes of unexpected responses of the Cloud Controller that are logged.
+const cfErrorBodyLimit = 1024
+
+// cfGUIDPattern matches the GUIDs the Cloud Controller identifies apps with.
+var cfGUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// CloudfoundryResolverConfig defines the configuration for a Cloud Controller used for a CloudfoundryResolver.
+type CloudfoundryResolverConfig struct {
+	RawCcURL  string     `yaml:"cc_url" validate:"required,url"`
+	AgentPort int        `yaml:"agent_port" validate:"required,gt=0,lte=65535"`
+	TLS       *ClientTLS `yaml:"tls" validate:"omitempty"`
+}
+
+// CloudfoundryInfo corresponds to the relevant data that is provided as JSON from the Cloud Controller
+// endpoint /v3/info.
+type CloudfoundryInfo struct {
+	Name        string `json:"name"`
+	Build       string `json:"build"`
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+}
+
+// CloudfoundryApp contains the metadata about an app as returned by the Cloud Controller endpoint /v3/apps/:guid.
+type CloudfoundryApp struct {
+	GUID  string `json:"guid"`
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// CloudfoundryProcess contains the metadata about a process of an app as returned by the Cloud Controller
+// endpoint /v3/apps/:guid/processes.
+type CloudfoundryProcess struct {
+	GUID      string `json:"guid"`
+	Type      string `json:"type"`
+	Instances int    `json:"instances"`
+}
+
+// CloudfoundryProcessInstance contains the metadata about a single instance of a process as returned by the
+// Cloud Controller endpoint /v3/processes/:guid/stats.
+type CloudfoundryProcessInstance struct {
+	Type               string `json:"type"`
+	Index              int    `json:"index"`
+	State              string `json:"state"`
+	Host               string `json:"host"`
+	InstanceInternalIP string `json:"instance_internal_ip"`
+}
+
+// cfPagination holds the pagination information of Cloud Controller list responses.
+type cfPagination struct {
+	Next *struct {
+		Href string `json:"href"`
+	} `json:"next"`
+}
+
+// CloudfoundryResolver uses the Cloud Controller v3 API to resolve AgentEndpoint s.
+//
+// Must call setup() to initialize. This is done by NewCloudfoundryResolver(), which is the preferred way of initialization.
 type CloudfoundryResolver struct {
+	client  *http.Client
+	Config  CloudfoundryResolverConfig
+	CcURL   *url.URL
+	logger  *zap.Logger
+	tlsConf *tls.Config
+}
+
+// NewCloudfoundryResolver creates and initializes a CloudfoundryResolver based on the provided config.
+// NewCloudfoundryResolver calls setup() to establish the connection to the configured Cloud Controller.
+//
+// Returns an error if the configuration is incorrect (unparseable URL, incorrect or inconsistent TLS configuration)
+// or the connection to the Cloud Controller fails.
+func NewCloudfoundryResolver(config CloudfoundryResolverConfig) (*CloudfoundryResolver, error) {
+	ccURL, err := url.Parse(config.RawCcURL)
+	if err != nil {
+		return nil, fmt.Errorf("cannot initialize CloudfoundryResolver for URL %s: %w", config.RawCcURL, err)
+	}
+
+	// Workaround for URL.JoinPath, which is buggy: https://github.com/golang/go/issues/58605
+	if ccURL.Path == "" {
+		ccURL.Path = "/"
+	}
+
+	resolver := &CloudfoundryResolver{
+		logger: zap.L().With(zap.String(LogKeyHandler, CloudfoundryResolverName)),
+		Config: config,
+		CcURL:  ccURL,
+	}
+
+	if config.TLS != nil {
+		resolver.tlsConf, err = config.TLS.Config()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = resolver.setup()
+	if err != nil {
+		return nil, err
+	}
+	return resolver, nil
 }
 
 func (cf *CloudfoundryResolver) Name() string {
-	return "cf"
+	return CloudfoundryResolverName
 }
 
 func (cf *CloudfoundryResolver) CanResolve(request *EndpointRequest) bool {
+	if request == nil {
+		return false
+	}
+
 	return request.GetCf() != nil
 }
 
+// Healthy returns true if the resolver can retrieve /v3/info from the Cloud Controller.
 func (cf *CloudfoundryResolver) Healthy() bool {
-	return true
+	_, err := cf.info()
+	return err == nil
 }
 
+// Resolve returns applicable AgentEndpoint s for request.
+//
+// Fails if:
+//   - the token is rejected by the Cloud Controller or does not grant access to the app
+//   - no endpoints match the query.
+//
+// No endpoints are found if:
+//   - the app has no process of the requested type (DefaultCloudfoundryAppType if not set)
+//   - none of the process instances is running or matches the requested indices.
 func (cf *CloudfoundryResolver) Resolve(request *EndpointRequest, log *zap.Logger) ([]AgentEndpoint, error) {
-	log = log.With(zap.String("handler", cf.Name()))
-	log.Info("Handling request")
+	log = log.With(zap.String(LogKeyHandler, cf.Name()))
+	log.Info("resolving endpoints for cf request")
+
+	err := cf.validate(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cfRequest := request.GetCf()
+
+	appType := DefaultCloudfoundryAppType
+	if cfRequest.AppType != nil && *cfRequest.AppType != "" {
+		appType = *cfRequest.AppType
+	}
+
+	err = cf.Authenticate(cfRequest.Token, cfRequest.AppId)
+	if err != nil {
+		return nil, err
+	}
+
+	processes, err := cf.getProcesses(cfRequest.AppId, cfRequest.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []AgentEndpoint
+	for _, process := range processes {
+		if process.Type != appType {
+			continue
+		}
+
+		var instances []CloudfoundryProcessInstance
+		instances, err = cf.getProcessInstances(process.GUID, cfRequest.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, instance := range instances {
+			if len(cfRequest.Indices) > 0 && !matchesIndices(instance, cfRequest.Indices) {
+				continue
+			}
 
-	// TODO Validate & get targets from cloud-controller
+			if instance.State != cfInstanceStateRunning || instance.InstanceInternalIP == "" {
+				log.Debug("skipping app instance", zap.Int("index", instance.Index), zap.String("state", instance.State))
+				continue
+			}
+
+			identifier := strings.Join([]string{cfRequest.AppId, strconv.Itoa(instance.Index)}, "/")
+			endpoints = append(endpoints, AgentEndpoint{
+				IP: instance.InstanceInternalIP, Port: cf.Config.AgentPort, Identifier: identifier,
+			})
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	log.Debug("received AgentEndpoints from Cloud Controller", zap.Any("agent-endpoint", endpoints))
+	return endpoints, nil
+}
 
-	_ = request
-	// TODO: Add the static IP addresses here, if needed
-	return []AgentEndpoint{}, nil
+// matchesIndices determines whether the instance matches one of the selected indices.
+func matchesIndices(instance CloudfoundryProcessInstance, indices []int32) bool {
+	for _, index := range indices {
+		if int32(instance.Index) == index { //nolint:gosec // instance indices are well within int32
+			return true
+		}
+	}
+	return false
 }
 
 func (cf *CloudfoundryResolver) validate(request *EndpointRequest) error {
 	cfRequest := request.GetCf()
 
 	if cfRequest == nil {
-		return fmt.Errorf("invalid message: bosh: %w", errNilField)
+		return fmt.Errorf("invalid message: cf: %w", errNilField)
 	}
 
 	if cfRequest.Token == "" {
@@ -47,5 +241,175 @@ func (cf *CloudfoundryResolver) validate(request *EndpointRequest) error {
 		return fmt.Errorf("invalid message: application_id: %w", errEmptyField)
 	}
 
+	// the app id is part of the Cloud Controller URLs, so it must not contain anything but a GUID.
+	if !cfGUIDPattern.MatchString(cfRequest.AppId) {
+		return fmt.Errorf("invalid message: application_id is not a guid: %w", errInvalidPayload)
+	}
+
+	return nil
+}
+
+// setup is called in NewCloudfoundryResolver and ensures that a connection to the configured Cloud Controller is possible.
+//
+// Returns an error if the connection to the Cloud Controller is not possible.
+func (cf *CloudfoundryResolver) setup() error {
+	// the config is not logged as a whole, so future secrets in it are not logged by accident.
+	cf.logger.Debug("setting up CloudfoundryResolver", zap.String("cc-url", cf.Config.RawCcURL), zap.Int("agent-port", cf.Config.AgentPort))
+
+	timeout := 500 * time.Millisecond //nolint:mnd // reasonable value.
+
+	cf.client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: timeout,
+			}).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			ExpectContinueTimeout: timeout,
+			DisableKeepAlives:     true,
+			MaxIdleConnsPerHost:   -1,
+			TLSClientConfig:       cf.tlsConf,
+		},
+		Timeout: timeout,
+	}
+
+	info, err := cf.info()
+	if err != nil {
+		return err
+	}
+
+	cf.logger.Info("connected to cloud-controller", zap.String("cloud-controller", cf.CcURL.String()), zap.String("build", info.Build))
+	return nil
+}
+
+// info retrieves the Cloud Controller /v3/info endpoint.
+//
+// Used for startup and health check.
+//
+// Returns an error if the connection to the Cloud Controller fails or the response is not a CloudfoundryInfo.
+func (cf *CloudfoundryResolver) info() (*CloudfoundryInfo, error) {
+	var info CloudfoundryInfo
+	err := cf.get(cf.CcURL.JoinPath("/v3/info"), "", &info)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch Cloud Controller API from %v: %w", cf.Config.RawCcURL, err)
+	}
+	return &info, nil
+}
+
+// Authenticate checks with the Cloud Controller that authToken is valid and grants access to the app with appID.
+func (cf *CloudfoundryResolver) Authenticate(authToken string, appID string) error {
+	if !cfGUIDPattern.MatchString(appID) {
+		return fmt.Errorf("could not verify access to app: app id is not a guid: %w", errInvalidPayload)
+	}
+
+	var app CloudfoundryApp
+	err := cf.get(cf.CcURL.JoinPath("/v3/apps", appID), authToken, &app)
+	if err != nil {
+		return fmt.Errorf("could not verify access to app %s: %w", appID, err)
+	}
+	return nil
+}
+
+// getProcesses retrieves all processes of the app with appID using authToken.
+//
+// Returns an error if the resolver is not connected to the Cloud Controller, the request failed or the next page
+// is not served by the Cloud Controller.
+func (cf *CloudfoundryResolver) getProcesses(appID string, authToken string) ([]CloudfoundryProcess, error) {
+	var processes []CloudfoundryProcess
+
+	next := cf.CcURL.JoinPath("/v3/apps", appID, "processes")
+	for next != nil {
+		var page struct {
+			Pagination cfPagination          `json:"pagination"`
+			Resources  []CloudfoundryProcess `json:"resources"`
+		}
+
+		err := cf.get(next, authToken, &page)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch processes of app %s: %w", appID, err)
+		}
+		processes = append(processes, page.Resources...)
+
+		next = nil
+		if page.Pagination.Next != nil && page.Pagination.Next.Href != "" {
+			next, err = url.Parse(page.Pagination.Next.Href)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse next page of processes of app %s: %w", appID, err)
+			}
+			// the token is sent along with every page, so only pages served by the Cloud Controller are followed.
+			if next.Scheme != cf.CcURL.Scheme || next.Host != cf.CcURL.Host {
+				return nil, fmt.Errorf("next page of processes of app %s is not served by the Cloud Controller %s://%s: %w", appID, cf.CcURL.Scheme, cf.CcURL.Host, errUntrustedURL)
+			}
+		}
+	}
+
+	return processes, nil
+}
+
+// getProcessInstances retrieves the instances of the process with processID using authToken.
+//
+// Returns an error if the resolver is not connected to the Cloud Controller or the request failed.
+func (cf *CloudfoundryResolver) getProcessInstances(processID string, authToken string) ([]CloudfoundryProcessInstance, error) {
+	var stats struct {
+		Resources []CloudfoundryProcessInstance `json:"resources"`
+	}
+
+	err := cf.get(cf.CcURL.JoinPath("/v3/processes", processID, "stats"), authToken, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch instances of process %s: %w", processID, err)
+	}
+
+	return stats.Resources, nil
+}
+
+// get performs a GET request against the Cloud Controller and decodes the JSON response into v.
+// If authToken is not empty, it is sent as bearer token.
+//
+// Returns ErrNotAuthorized (wrapped) if the Cloud Controller rejects the token or denies access and
+// ErrNoEndpoints (wrapped) if the requested resource does not exist.
+func (cf *CloudfoundryResolver) get(endpoint *url.URL, authToken string, v any) error {
+	if cf.client == nil {
+		return ErrCloudfoundryNotConnected
+	}
+
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    endpoint,
+		Header: map[string][]string{
+			"Accept": {"application/json"},
+		},
+	}
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+
+	res, err := cf.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to Cloud Controller failed: %w", err)
+	}
+
+	defer func() { _ = res.Body.Close() }()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		// continue below
+	case http.StatusUnauthorized:
+		return fmt.Errorf("token was rejected by the Cloud Controller: %w", ErrNotAuthorized)
+	case http.StatusForbidden:
+		return fmt.Errorf("access denied by the Cloud Controller: %w", ErrNotAuthorized)
+	case http.StatusNotFound:
+		return fmt.Errorf("resource %s not found: %w", endpoint.Path, ErrNoEndpoints)
+	default:
+		// the body is only logged, as errors are returned to the client.
+		data, _ := io.ReadAll(io.LimitReader(res.Body, cfErrorBodyLimit))
+		cf.logger.Debug("unexpected response from the Cloud Controller", zap.Int("status", res.StatusCode), zap.String("body", RedactString(string(data))))
+		return fmt.Errorf("unexpected response from the Cloud Controller: status code %d (%s)", res.StatusCode, http.StatusText(res.StatusCode))
+	}
+
+	err = json.NewDecoder(res.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("could not parse Cloud Controller response: %w", err)
+	}
+
 	return nil
 }
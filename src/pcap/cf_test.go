@@ -5,6 +5,8 @@ import (
 	"testing"
 )
 
+const cfTestAppID = "6a1f7f1e-2b3c-4d5e-8f90-1a2b3c4d5e6f"
+
 func TestValidateCfCaptureRequest(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -27,7 +29,7 @@ func TestValidateCfCaptureRequest(t *testing.T) {
 
 		{
 			name:        "CF metadata Token is not present",
-			req:         &CloudfoundryRequest{AppId: "123abc"},
+			req:         &CloudfoundryRequest{AppId: cfTestAppID},
 			wantErr:     true,
 			expectedErr: errEmptyField,
 		},
@@ -37,15 +39,21 @@ func TestValidateCfCaptureRequest(t *testing.T) {
 			wantErr:     true,
 			expectedErr: errEmptyField,
 		},
+		{
+			name:        "CF metadata AppId is not a guid",
+			req:         &CloudfoundryRequest{Token: "123d24", AppId: "../../v2/info"},
+			wantErr:     true,
+			expectedErr: errInvalidPayload,
+		},
 		{
 			name:        "Valid request",
-			req:         &CloudfoundryRequest{Token: "123d24", AppId: "123abc"},
+			req:         &CloudfoundryRequest{Token: "123d24", AppId: cfTestAppID},
 			wantErr:     false,
 			expectedErr: nil,
 		},
 		{
 			name:        "Valid request with instances",
-			req:         &CloudfoundryRequest{Token: "123d24", AppId: "123abc", Indices: []int32{1, 3, 5}},
+			req:         &CloudfoundryRequest{Token: "123d24", AppId: cfTestAppID, Indices: []int32{1, 3, 5}},
 			wantErr:     false,
 			expectedErr: nil,
 		},
@@ -63,6 +71,10 @@ func TestValidateCfCaptureRequest(t *testing.T) {
 			if test.expectedErr != nil && !errors.Is(err, test.expectedErr) {
 				t.Errorf("expectedErr = %v, error = %v", test.expectedErr, err)
 			}
+			// the api reports invalid requests as invalid arguments.
+			if err != nil && !errors.Is(err, ErrValidationFailed) {
+				t.Errorf("expected error to wrap %v, error = %v", ErrValidationFailed, err)
+			}
 		})
 	}
 }
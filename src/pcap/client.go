@@ -7,12 +7,13 @@ import (
 	"io"
 	"net/url"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"code.cloudfoundry.org/bytefmt"
-	"github.com/gopacket/gopacket"
 	"github.com/gopacket/gopacket/layers"
-	"github.com/gopacket/gopacket/pcapgo"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
@@ -24,6 +25,10 @@ import (
 
 const logProgressWait = 5 * time.Second
 
+// clientCreditWindow is the number of packets the client permits the api to send ahead with FlowControl CREDIT.
+// Credits for half of the window are granted once they have been written.
+const clientCreditWindow = 1000
+
 type MessageWriter interface {
 	WriteMessage(message *Message)
 }
@@ -57,6 +62,10 @@ func MessageLogLevel(message *Message) zapcore.Level {
 		return zapcore.InfoLevel
 	case MessageType_CONNECTION_ERROR:
 		return zapcore.ErrorLevel
+	case MessageType_STATISTICS:
+		return zapcore.DebugLevel
+	case MessageType_CAPTURE_METADATA:
+		return zapcore.DebugLevel
 	}
 	return zapcore.ErrorLevel
 }
@@ -64,21 +73,30 @@ func MessageLogLevel(message *Message) zapcore.Level {
 // Client provides a reusable client for issuing capture requests against the pcap-api.
 type Client struct {
 	packetFile    *os.File
+	format        OutputFormat
 	log           *zap.Logger
 	stream        API_CaptureClient
 	messageWriter MessageWriter
-	stopped       bool
+	// sendMu protects stream and stopped, as credits and the stop request are sent from different go routines.
+	sendMu  sync.Mutex
+	stopped bool
+	// creditWindow is the number of packets credits are granted for with FlowControl CREDIT, zero otherwise.
+	creditWindow int
+	// startPolicy is sent with capture requests, the default of the pcap-api applies if it is nil.
+	startPolicy *StartPolicy
+	// summary is the summary of the last capture, as sent by the pcap-api at the end of the capture.
+	summary atomic.Pointer[CaptureSummary]
 	aPIClient
 }
 
-// NewClient sets up logging for the client and creates the outputFile.
+// NewClient sets up logging for the client and creates the outputFile, which is written in the given format.
 // It assumes that the outputFile does not pre-exist and that the path is writeable (should be checked by CLI).
 //
 // NewClient returns a new Client if there are no issues with outputFile creation.
-func NewClient(outputFile string, logger *zap.Logger, writer MessageWriter) (*Client, error) {
+func NewClient(outputFile string, format OutputFormat, logger *zap.Logger, writer MessageWriter) (*Client, error) {
 	var err error
 
-	client := &Client{log: logger, messageWriter: writer}
+	client := &Client{log: logger, messageWriter: writer, format: format}
 
 	if len(outputFile) == 0 {
 		if logsToStdout(zapConfig) {
@@ -95,6 +113,24 @@ func NewClient(outputFile string, logger *zap.Logger, writer MessageWriter) (*Cl
 	return client, nil
 }
 
+// NewInterfaceClient creates a Client that is only used to list the network interfaces of instances. It does not
+// create an output file and can not be used for captures.
+func NewInterfaceClient(logger *zap.Logger) *Client {
+	return &Client{log: logger}
+}
+
+// SetStartPolicy sets the policy for starting captures on multiple instances. If it is not met, the pcap-api
+// stops the capture on all instances and the capture request fails.
+func (c *Client) SetStartPolicy(policy *StartPolicy) {
+	c.startPolicy = policy
+}
+
+// Summary returns the summary of the capture, or nil if the pcap-api did not send one, e.g. because the capture
+// failed to start.
+func (c *Client) Summary() *CaptureSummary {
+	return c.summary.Load()
+}
+
 func (c *Client) Stop() {
 	c.StopRequest()
 }
@@ -156,8 +192,7 @@ func (c *Client) ProcessCapture(ctx context.Context, endpointRequest *EndpointRe
 		return fmt.Errorf("capture options request must not be nil: %w", errInvalidPayload)
 	}
 	// setup output/pcap-file
-	packetWriter := pcapgo.NewWriter(c.packetFile)
-	err := packetWriter.WriteFileHeader(options.SnapLen, layers.LinkTypeEthernet)
+	packetWriter, err := NewPacketWriter(c.packetFile, c.format, options.SnapLen, options.TimestampPrecision)
 	if err != nil {
 		return err
 	}
@@ -167,6 +202,7 @@ func (c *Client) ProcessCapture(ctx context.Context, endpointRequest *EndpointRe
 			Start: &StartCapture{
 				Request: endpointRequest,
 				Options: options,
+				Policy:  c.startPolicy,
 			},
 		},
 	}
@@ -182,6 +218,11 @@ func (c *Client) ProcessCapture(ctx context.Context, endpointRequest *EndpointRe
 		return err
 	}
 
+	if options.FlowControl == FlowControl_CREDIT {
+		c.creditWindow = clientCreditWindow
+		c.grantCredits(clientCreditWindow)
+	}
+
 	done := c.ReadCaptureResponse(c.stream, packetWriter, cancel)
 
 	go c.logProgress(ctx, logger)
@@ -196,6 +237,12 @@ func (c *Client) ProcessCapture(ctx context.Context, endpointRequest *EndpointRe
 		<-ctx.Done()
 	}
 
+	logger.Debug("flushing packets")
+	err = packetWriter.Flush()
+	if err != nil {
+		return err
+	}
+
 	logger.Debug("syncing file to disk")
 	err = c.packetFile.Sync()
 	if err != nil {
@@ -224,6 +271,9 @@ func logsToStdout(config zap.Config) bool {
 }
 
 func (c *Client) StopRequest() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
 	if c.stream == nil {
 		c.log.Error("client not connected, could not stop")
 		return
@@ -244,15 +294,44 @@ func (c *Client) StopRequest() {
 	c.stopped = true
 }
 
+// grantCredits permits the api to send n more packets, see FlowControl CREDIT. Credits are not granted anymore
+// once the capture has been stopped, the api then sends the remaining packets without credits.
+func (c *Client) grantCredits(n int) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.stopped {
+		return
+	}
+
+	err := c.stream.Send(&CaptureRequest{Operation: &CaptureRequest_Credit{Credit: &Credit{Packets: uint32(n)}}}) //nolint:gosec // the window is well within uint32
+	if err != nil {
+		c.log.Debug("could not grant credits", zap.Error(err))
+	}
+}
+
 // ReadCaptureResponse reads CaptureResponse's from the api in a loop and delegates writing/logging messages & packets to WriteMessage / writePacket.
 //
 // It terminates if an error or clean stop-message is received.
-func (c *Client) ReadCaptureResponse(stream API_CaptureClient, packetWriter *pcapgo.Writer, cancel context.CancelCauseFunc) chan struct{} {
+func (c *Client) ReadCaptureResponse(stream API_CaptureClient, packetWriter PacketWriter, cancel context.CancelCauseFunc) chan struct{} {
 	logger := c.log.With(zap.String(LogKeyHandler, "ReadCaptureResponse"))
 
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
+
+		gaps := gapDetector{}
+		defer gaps.report(logger)
+
+		drops := dropSummary{}
+		defer drops.report(logger)
+
+		discards := discardSummary{}
+		defer discards.report(logger)
+
+		// packets is the number of packets received since credits were granted last.
+		packets := 0
+
 		for {
 			res, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
@@ -270,9 +349,32 @@ func (c *Client) ReadCaptureResponse(stream API_CaptureClient, packetWriter *pca
 
 			switch p := res.Payload.(type) {
 			case *CaptureResponse_Message:
+				drops.add(p.Message)
+				discards.add(p.Message)
 				c.messageWriter.WriteMessage(p.Message)
+
+				if metadata := p.Message.Metadata; p.Message.Type == MessageType_CAPTURE_METADATA && metadata != nil {
+					err = packetWriter.SetLinkType(p.Message.Origin, metadata.Device, layers.LinkType(metadata.LinkType)) //nolint:gosec // link types are defined as 16 bit values
+					if err == nil {
+						err = packetWriter.SetTimestampPrecision(p.Message.Origin, metadata.Device, metadata.TimestampPrecision)
+					}
+					if err != nil {
+						cancel(fmt.Errorf("unsupported capture: %w", err))
+						return
+					}
+				}
+			case *CaptureResponse_Summary:
+				logger.Debug("received capture summary", zap.Int("agents", len(p.Summary.Agents)))
+				c.summary.Store(p.Summary)
 			case *CaptureResponse_Packet:
+				gaps.add(p.Packet)
 				writePacket(p.Packet, packetWriter)
+
+				packets++
+				if c.creditWindow > 0 && packets >= c.creditWindow/2 {
+					c.grantCredits(packets)
+					packets = 0
+				}
 			}
 		}
 	}()
@@ -281,25 +383,166 @@ func (c *Client) ReadCaptureResponse(stream API_CaptureClient, packetWriter *pca
 }
 
 // writePacket writes a Packet to the outputFile (in packetWriter).
-func writePacket(packet *Packet, packetWriter *pcapgo.Writer) {
+func writePacket(packet *Packet, packetWriter PacketWriter) {
 	log := zap.L()
 	if log.Level().Enabled(zap.DebugLevel) {
-		log.Debug("received packet", zap.Int("bytes", len(packet.Data)), zap.Time("capture-timestamp", packet.Timestamp.AsTime()))
+		log.Debug("received packet", zap.Int("bytes", len(packet.Data)), zap.Time("capture-timestamp", packet.Timestamp.AsTime()), zap.String("origin", packet.Origin))
 	}
 
-	captureInfo := gopacket.CaptureInfo{
-		Timestamp:      packet.Timestamp.AsTime(),
-		CaptureLength:  len(packet.Data),
-		Length:         int(packet.Length),
-		InterfaceIndex: 0,
-		AncillaryData:  nil,
-	}
-	err := packetWriter.WritePacket(captureInfo, packet.Data)
+	err := packetWriter.WritePacket(packet)
 	if err != nil {
 		log.Error("writing packet to file failed", zap.Error(err))
 	}
 }
 
+// originSequence tracks the sequence numbers of the packets received from one origin.
+type originSequence struct {
+	received uint64
+	last     uint64
+	missing  uint64
+	gaps     uint64
+}
+
+// gapDetector detects gaps in the sequence numbers of the received packets per origin. Gaps occur when packets
+// have been discarded due to congestion or got lost between agent and client.
+type gapDetector map[string]*originSequence
+
+// add records the sequence number of packet. Packets without sequence number are ignored.
+func (g gapDetector) add(packet *Packet) {
+	if packet.Sequence == 0 {
+		return
+	}
+
+	seq, ok := g[packet.Origin]
+	if !ok {
+		seq = &originSequence{}
+		g[packet.Origin] = seq
+	}
+
+	seq.received++
+
+	// packets are forwarded in order per origin, a lower sequence number must not happen and is skipped.
+	if packet.Sequence <= seq.last {
+		return
+	}
+
+	// the first packet of an origin starts at 1, anything after that is already missing.
+	if packet.Sequence > seq.last+1 {
+		seq.missing += packet.Sequence - seq.last - 1
+		seq.gaps++
+	}
+
+	seq.last = packet.Sequence
+}
+
+// report logs the number of received and missing packets per origin.
+func (g gapDetector) report(log *zap.Logger) {
+	origins := make([]string, 0, len(g))
+	for origin := range g {
+		origins = append(origins, origin)
+	}
+	sort.Strings(origins)
+
+	for _, origin := range origins {
+		seq := g[origin]
+		fields := []zap.Field{
+			zap.String("origin", origin),
+			zap.Uint64("received", seq.received),
+			zap.Uint64("missing", seq.missing),
+			zap.Uint64("gaps", seq.gaps),
+		}
+
+		if seq.missing > 0 {
+			log.Warn(fmt.Sprintf("%s: %d packets missing in %d gaps", origin, seq.missing, seq.gaps), fields...)
+			continue
+		}
+		log.Info(fmt.Sprintf("%s: no packets missing", origin), fields...)
+	}
+}
+
+// statisticsSource identifies the device of an origin that statistics belong to.
+type statisticsSource struct {
+	origin string
+	device string
+}
+
+// dropSummary keeps the latest capture statistics per origin and device.
+type dropSummary map[statisticsSource]*CaptureStatistics
+
+// add records the statistics of message if it is of type STATISTICS. Statistics are cumulative, the latest replace
+// any previous statistics of the same origin and device.
+func (d dropSummary) add(message *Message) {
+	if message.Type != MessageType_STATISTICS || message.Statistics == nil {
+		return
+	}
+
+	d[statisticsSource{origin: message.Origin, device: message.Statistics.Device}] = message.Statistics
+}
+
+// report logs the number of received and dropped packets per origin and device.
+func (d dropSummary) report(log *zap.Logger) {
+	sources := make([]statisticsSource, 0, len(d))
+	for source := range d {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].origin != sources[j].origin {
+			return sources[i].origin < sources[j].origin
+		}
+		return sources[i].device < sources[j].device
+	})
+
+	for _, source := range sources {
+		stats := d[source]
+		fields := []zap.Field{
+			zap.String("origin", source.origin),
+			zap.String("device", source.device),
+			zap.Uint64("received", stats.Received),
+			zap.Uint64("dropped", stats.Dropped),
+			zap.Uint64("if-dropped", stats.IfDropped),
+		}
+
+		message := fmt.Sprintf("%s: %s", source.origin, formatStatistics(stats))
+		if stats.Dropped > 0 || stats.IfDropped > 0 {
+			log.Warn(message, fields...)
+			continue
+		}
+		log.Info(message, fields...)
+	}
+}
+
+// discardSummary keeps the total packets discarded due to back pressure per participant, i.e. the origin of the
+// CONGESTED message.
+type discardSummary map[string]*DiscardStatistics
+
+// add records the discards of message if it contains the totals of a participant.
+func (d discardSummary) add(message *Message) {
+	if message.Type != MessageType_CONGESTED || !message.Discards.GetTotal() {
+		return
+	}
+
+	d[message.Origin] = message.Discards
+}
+
+// report logs the number of discarded packets and bytes per participant and origin of the packets.
+func (d discardSummary) report(log *zap.Logger) {
+	participants := make([]string, 0, len(d))
+	for participant := range d {
+		participants = append(participants, participant)
+	}
+	sort.Strings(participants)
+
+	for _, participant := range participants {
+		for _, discards := range d[participant].Origins {
+			log.Warn(fmt.Sprintf("%s: %d packets (%s) of %s discarded due to back pressure", participant, discards.Packets, bytefmt.ByteSize(discards.Bytes), discards.Origin),
+				zap.String("participant", participant),
+				zap.String("origin", discards.Origin),
+				zap.Uint64("packets", discards.Packets),
+				zap.Uint64("bytes", discards.Bytes))
+		}
+	}
+}
+
 // logProgress logs out the size of the outputFile every 5 seconds (see logProgressWait).
 func (c *Client) logProgress(ctx context.Context, logger *zap.Logger) {
 	ticker := time.NewTicker(logProgressWait)
@@ -320,6 +563,27 @@ func (c *Client) logProgress(ctx context.Context, logger *zap.Logger) {
 	}
 }
 
+// InstanceInterfaces requests the network interfaces of all instances selected by endpointRequest from the API.
+func (c *Client) InstanceInterfaces(ctx context.Context, endpointRequest *EndpointRequest) ([]*InstanceInterfaces, error) {
+	if c.cc == nil {
+		return nil, ErrNotConnected
+	}
+
+	if endpointRequest == nil {
+		return nil, fmt.Errorf("endpoint request must not be nil: %w", errInvalidPayload)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultStatusTimeout)
+	defer cancel()
+
+	res, err := c.ListInterfaces(ctx, &ListInstanceInterfacesRequest{Request: endpointRequest})
+	if err != nil {
+		return nil, fmt.Errorf("could not list interfaces: %w", err)
+	}
+
+	return res.GetInstances(), nil
+}
+
 // CheckAPIHandler checks if API is healthy and the given handler is available, if that's the case, the returned error will be nil.
 func (c *Client) CheckAPIHandler(handler string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), DefaultStatusTimeout)
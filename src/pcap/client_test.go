@@ -5,14 +5,16 @@ import (
 	"context"
 	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gopacket/gopacket"
 	"github.com/gopacket/gopacket/layers"
 	"github.com/gopacket/gopacket/pcap"
-	"github.com/gopacket/gopacket/pcapgo"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -91,7 +93,7 @@ func TestHandleStream(t *testing.T) {
 			clientError: nil,
 			messages: []MessageTuple{
 				{
-					Response: newPacketResponse(examplePacket, gopacket.CaptureInfo{}),
+					Response: newPacketResponse(examplePacket, gopacket.CaptureInfo{}, agentOrigin, 1),
 				},
 			},
 			expectedErrMessage: "context canceled",
@@ -100,7 +102,7 @@ func TestHandleStream(t *testing.T) {
 			clientError: context.Canceled,
 			messages: []MessageTuple{
 				{
-					Response: newPacketResponse(examplePacket, gopacket.CaptureInfo{}),
+					Response: newPacketResponse(examplePacket, gopacket.CaptureInfo{}, agentOrigin, 1),
 				},
 			},
 			expectedErrMessage: "context canceled",
@@ -113,23 +115,38 @@ func TestHandleStream(t *testing.T) {
 				},
 			},
 			expectedErrMessage: "receive non-OK code: PermissionDenied: error-text",
+		}, {
+			name:        "mixed link types",
+			clientError: nil,
+			messages: []MessageTuple{
+				{
+					Response: newMetadataResponse(layers.LinkTypeEthernet, TimestampPrecision_MICROSECONDS, "router/1", "eth0"),
+				},
+				{
+					Response: newMetadataResponse(layers.LinkTypeLinuxSLL, TimestampPrecision_MICROSECONDS, "router/2", "any"),
+				},
+			},
+			expectedErrMessage: "unsupported capture: router/2 captures on any with link type Linux SLL, which differs from Ethernet: use the pcapng format to mix link types",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var buf bytes.Buffer
-			writer := pcapgo.NewWriter(&buf)
+			writer, err := NewPacketWriter(&buf, FormatPcap, 65000, TimestampPrecision_MICROSECONDS)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			stream := &MockAPIWriter{messages: tt.messages}
 			ctx, cancel := context.WithCancelCause(context.Background())
-			c := Client{log: zap.L().With(zap.String("test", tt.name))}
+			c := Client{log: zap.L().With(zap.String("test", tt.name)), messageWriter: LogMessageWriter{Log: zap.L()}}
 			done := c.ReadCaptureResponse(stream, writer, cancel)
 			if tt.clientError != nil {
 				cancel(tt.clientError)
 			}
 			<-done
 
-			err := context.Cause(ctx)
+			err = context.Cause(ctx)
 			if err.Error() != tt.expectedErrMessage {
 				t.Errorf("expected = %v, actual = %v", tt.expectedErrMessage, err)
 			}
@@ -144,11 +161,7 @@ func TestWritePacket(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error: %v", err.Error())
 	}
-	packetWriter := pcapgo.NewWriter(file)
-	err = packetWriter.WriteFileHeader(65000, layers.LinkTypeEthernet)
-	if err != nil {
-		t.Errorf("unexpected error: %v", err.Error())
-	}
+	packetWriter := newPcapWriter(file, 65000, TimestampPrecision_MICROSECONDS)
 
 	// force UTC time zone, as the reading from the file is done in the local time zone. This ensures that time zone
 	// conversions are handled correctly.
@@ -192,3 +205,143 @@ func TestWritePacket(t *testing.T) {
 		t.Errorf("unexpected destination port %v", dstPort)
 	}
 }
+
+func TestGapDetector(t *testing.T) {
+	tests := []struct {
+		name            string
+		sequences       []uint64
+		expectedMissing uint64
+		expectedGaps    uint64
+		expectedLevel   zapcore.Level
+	}{
+		{
+			name:            "no gaps",
+			sequences:       []uint64{1, 2, 3, 4},
+			expectedMissing: 0,
+			expectedGaps:    0,
+			expectedLevel:   zapcore.InfoLevel,
+		},
+		{
+			name:            "missing first packets",
+			sequences:       []uint64{3, 4},
+			expectedMissing: 2,
+			expectedGaps:    1,
+			expectedLevel:   zapcore.WarnLevel,
+		},
+		{
+			name:            "multiple gaps",
+			sequences:       []uint64{1, 2, 5, 6, 9},
+			expectedMissing: 4,
+			expectedGaps:    2,
+			expectedLevel:   zapcore.WarnLevel,
+		},
+		{
+			name:            "duplicate sequence is ignored",
+			sequences:       []uint64{1, 2, 2, 3},
+			expectedMissing: 0,
+			expectedGaps:    0,
+			expectedLevel:   zapcore.InfoLevel,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gaps := gapDetector{}
+			for _, sequence := range test.sequences {
+				gaps.add(&Packet{Origin: agentOrigin, Sequence: sequence})
+			}
+			// packets without sequence number are not tracked.
+			gaps.add(&Packet{Origin: "unknown"})
+
+			seq, ok := gaps[agentOrigin]
+			if !ok {
+				t.Fatalf("expected origin %s to be tracked", agentOrigin)
+			}
+			if _, ok = gaps["unknown"]; ok {
+				t.Errorf("expected packets without sequence number to be ignored")
+			}
+			if seq.missing != test.expectedMissing {
+				t.Errorf("expectedMissing = %d, missing = %d", test.expectedMissing, seq.missing)
+			}
+			if seq.gaps != test.expectedGaps {
+				t.Errorf("expectedGaps = %d, gaps = %d", test.expectedGaps, seq.gaps)
+			}
+
+			core, observedLogs := observer.New(zapcore.DebugLevel)
+			gaps.report(zap.New(core))
+
+			if observedLogs.Len() != 1 {
+				t.Fatalf("expected one log entry, got %d", observedLogs.Len())
+			}
+			if level := observedLogs.All()[0].Level; level != test.expectedLevel {
+				t.Errorf("expectedLevel = %v, level = %v", test.expectedLevel, level)
+			}
+		})
+	}
+}
+
+func TestDiscardSummary(t *testing.T) {
+	discards := discardSummary{}
+
+	discards.add(&Message{Type: MessageType_CONGESTED, Origin: "pcap-api/1", Discards: &DiscardStatistics{Origins: []*OriginDiscards{{Origin: "router/1", Packets: 1}}}})
+	discards.add(&Message{Type: MessageType_CONGESTED, Origin: "router/2", Discards: &DiscardStatistics{Total: true, Origins: []*OriginDiscards{{Origin: "router/2", Packets: 5, Bytes: 100}}}})
+	discards.add(&Message{Type: MessageType_CONGESTED, Origin: "pcap-api/1", Discards: &DiscardStatistics{Total: true, Origins: []*OriginDiscards{{Origin: "router/1", Packets: 2}, {Origin: "router/2", Packets: 3}}}})
+	discards.add(&Message{Type: MessageType_STATISTICS, Origin: "router/3"})
+
+	if len(discards) != 2 {
+		t.Fatalf("expected totals of 2 participants, got %d", len(discards))
+	}
+
+	core, observedLogs := observer.New(zapcore.DebugLevel)
+	discards.report(zap.New(core))
+
+	entries := observedLogs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d", len(entries))
+	}
+	if !strings.HasPrefix(entries[0].Message, "pcap-api/1: 2 packets") || !strings.Contains(entries[0].Message, "of router/1") {
+		t.Errorf("expected discards of router/1 by pcap-api/1 first, got %s", entries[0].Message)
+	}
+	if !strings.HasPrefix(entries[2].Message, "router/2: 5 packets (100B)") {
+		t.Errorf("expected discards of router/2 last, got %s", entries[2].Message)
+	}
+}
+
+func TestDropSummary(t *testing.T) {
+	drops := dropSummary{}
+
+	drops.add(&Message{Type: MessageType_STATISTICS, Origin: "router/1", Statistics: &CaptureStatistics{Received: 10, Device: "eth0"}})
+	drops.add(&Message{Type: MessageType_STATISTICS, Origin: "router/1", Statistics: &CaptureStatistics{Received: 20, Dropped: 5, Device: "eth0"}})
+	drops.add(&Message{Type: MessageType_STATISTICS, Origin: "router/1", Statistics: &CaptureStatistics{Received: 15, Device: "tun0"}})
+	drops.add(&Message{Type: MessageType_STATISTICS, Origin: "router/2", Statistics: &CaptureStatistics{Received: 30}})
+	drops.add(&Message{Type: MessageType_CONGESTED, Origin: "router/3"})
+
+	if len(drops) != 3 {
+		t.Fatalf("expected statistics of 3 devices, got %d", len(drops))
+	}
+
+	router1 := drops[statisticsSource{origin: "router/1", device: "eth0"}]
+	if router1.Received != 20 || router1.Dropped != 5 {
+		t.Errorf("expected latest statistics for router/1 eth0, got %v", router1)
+	}
+
+	core, observedLogs := observer.New(zapcore.DebugLevel)
+	drops.report(zap.New(core))
+
+	entries := observedLogs.All()
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 log entries, got %d", len(entries))
+	}
+
+	if entries[0].Level != zapcore.WarnLevel {
+		t.Errorf("expected warning for router/1 eth0 with drops, got %v", entries[0].Level)
+	}
+
+	if entries[1].Level != zapcore.InfoLevel || !strings.Contains(entries[1].Message, "tun0") {
+		t.Errorf("expected info for router/1 tun0 without drops, got %v: %s", entries[1].Level, entries[1].Message)
+	}
+
+	if entries[2].Level != zapcore.InfoLevel {
+		t.Errorf("expected info for router/2 without drops, got %v", entries[2].Level)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
 
@@ -11,7 +12,7 @@ import (
 )
 
 var DefaultConfig = Config{
-	pcap.NodeConfig{
+	NodeConfig: pcap.NodeConfig{
 		Listen: pcap.Listen{Port: 9494}, //nolint:mnd // default value used for testing
 		Buffer: pcap.BufferConf{
 			Size:       1000, //nolint:mnd // default value used for testing
@@ -21,10 +22,14 @@ var DefaultConfig = Config{
 		LogLevel: "debug",
 		ID:       "test-agent",
 	},
+	AgentConf: pcap.AgentConf{
+		DrainTimeout: 10 * time.Second, //nolint:mnd // default configuration
+	},
 }
 
 type Config struct {
 	pcap.NodeConfig `yaml:"-,inline"`
+	pcap.AgentConf  `yaml:"-,inline"`
 }
 
 func (c Config) validate() error {
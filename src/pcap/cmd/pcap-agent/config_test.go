@@ -2,6 +2,7 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
 
@@ -32,8 +33,18 @@ func TestConfig(t *testing.T) {
 				UpperLimit: 95,
 				LowerLimit: 90,
 			},
+			Limits: pcap.CaptureLimitsConf{
+				MaxDuration: time.Hour,
+				MaxPackets:  1000000,
+				MaxBytes:    1073741824,
+			},
 			LogLevel: "debug",
 			ID:       "pcap-agent/123",
+			Metrics:  &pcap.MetricsConf{Port: 9495},
+		},
+		AgentConf: pcap.AgentConf{
+			DrainTimeout:       10 * time.Second,
+			ConcurrentCaptures: 2,
 		},
 	}
 
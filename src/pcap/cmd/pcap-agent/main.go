@@ -17,6 +17,7 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
@@ -51,7 +52,18 @@ func main() {
 		return
 	}
 
-	agent := pcap.NewAgent(config.Buffer, config.ID)
+	pcap.SetLogLevel(log, config.LogLevel)
+
+	agent := pcap.NewAgent(config.NodeConfig, config.AgentConf)
+
+	if config.Metrics != nil {
+		go func() {
+			metricsErr := pcap.ServeMetrics(*config.Metrics, log)
+			if metricsErr != nil {
+				log.Error("unable to serve metrics", zap.Error(metricsErr))
+			}
+		}()
+	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Listen.Port))
 	if err != nil {
@@ -59,7 +71,7 @@ func main() {
 		return
 	}
 
-	var grpcOptions []grpc.ServerOption
+	grpcOptions := []grpc.ServerOption{pcap.AgentServerKeepalive()}
 	if config.NodeConfig.Listen.TLS != nil {
 		var tlsConfig *tls.Config
 		tlsConfig, err = config.NodeConfig.Listen.TLS.Config()
@@ -73,8 +85,12 @@ func main() {
 
 	server := grpc.NewServer(grpcOptions...)
 	pcap.RegisterAgentServer(server, agent)
+	grpc_health_v1.RegisterHealthServer(server, pcap.NewAgentHealthServer(agent))
 
-	go pcap.StopOnSignal(log, agent, server, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	go pcap.StopOnSignal(log, agent, server, config.DrainTimeout, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	if len(os.Args) == 2 { //nolint:mnd // only a parsed config can be reloaded.
+		go pcap.ReloadOnSignal(log, reloadAgent(log, os.Args[1], config.Listen.TLS), syscall.SIGHUP)
+	}
 
 	log.Info("starting server")
 	err = server.Serve(lis)
@@ -85,3 +101,29 @@ func main() {
 
 	log.Info("serve returned successfully")
 }
+
+// reloadAgent returns the function that reloads the config at path. The log level is applied and the TLS files are
+// re-read. Running captures are not interrupted. Changes to the TLS configuration of the listener, listenTLS, fail
+// the reload. All other settings require a restart.
+func reloadAgent(log *zap.Logger, path string, listenTLS *pcap.ServerTLS) func() error {
+	return func() error {
+		config, err := parseConfig(path)
+		if err != nil {
+			return err
+		}
+
+		err = config.validate()
+		if err != nil {
+			return fmt.Errorf("validate config: %w", err)
+		}
+
+		err = pcap.CheckListenTLSReload(listenTLS, config.Listen.TLS)
+		if err != nil {
+			return err
+		}
+
+		pcap.SetLogLevel(log, config.LogLevel)
+
+		return pcap.ReloadTLS()
+	}
+}
@@ -22,19 +22,22 @@ var DefaultAPIConfig = APIConfig{
 		LogLevel: "debug",
 		ID:       "test-api",
 	},
-	AgentsMTLS:         nil,
-	DrainTimeout:       10 * time.Second, //nolint:mnd // default configuration
-	ConcurrentCaptures: 5,                //nolint:mnd // default configuration
+	APIConf: pcap.APIConf{
+		AgentsMTLS:         nil,
+		DrainTimeout:       10 * time.Second, //nolint:mnd // default configuration
+		ConcurrentCaptures: 5,                //nolint:mnd // default configuration
+	},
 }
 
 type APIConfig struct {
-	pcap.NodeConfig    `yaml:"-,inline"`
-	AgentsMTLS         *pcap.ClientTLS `yaml:"agents_mtls" validate:"omitempty"`
-	ConcurrentCaptures int32           `yaml:"concurrent_captures"`
-	DrainTimeout       time.Duration   `yaml:"drain_timeout"`
+	pcap.NodeConfig `yaml:"-,inline"`
+	pcap.APIConf    `yaml:"-,inline"`
+
+	// Audit defines where the audit records of all captures are written to.
+	Audit pcap.AuditConf `yaml:"audit"`
 
-	BoshResolverConfig *pcap.BoshResolverConfig `yaml:"bosh,omitempty" validate:"dive"`
-	// TODO: Add CF specific config fragments
+	BoshResolverConfig         *pcap.BoshResolverConfig         `yaml:"bosh,omitempty" validate:"dive"`
+	CloudfoundryResolverConfig *pcap.CloudfoundryResolverConfig `yaml:"cf,omitempty" validate:"dive"`
 }
 
 func (c APIConfig) validate() error {
@@ -33,18 +33,48 @@ func TestAPIConfig(t *testing.T) {
 				UpperLimit: 95,
 				LowerLimit: 90,
 			},
+			Limits: pcap.CaptureLimitsConf{
+				MaxDuration: time.Hour,
+				MaxPackets:  1000000,
+				MaxBytes:    1073741824,
+			},
 			LogLevel: "debug",
 			ID:       "pcap-api/234",
+			Metrics:  &pcap.MetricsConf{Port: 8081},
 		},
-		AgentsMTLS: &pcap.ClientTLS{
-			Certificate: "api-client-cert.pem",
-			PrivateKey:  "api-client-cert.key",
-			RootCas:     "pcap-ca.pem",
-			SkipVerify:  false,
-			ServerName:  "pcap-agent.service.cf.internal",
+		APIConf: pcap.APIConf{
+			AgentsMTLS: &pcap.ClientTLS{
+				Certificate: "api-client-cert.pem",
+				PrivateKey:  "api-client-cert.key",
+				RootCas:     "pcap-ca.pem",
+				SkipVerify:  false,
+				ServerName:  "pcap-agent.service.cf.internal",
+			},
+			ConcurrentCaptures: 5,
+			DrainTimeout:       time.Second * 10,
+			AgentConnect: pcap.AgentConnectConf{
+				Timeout:     5 * time.Second,
+				Parallelism: 20,
+				StartPolicy: pcap.StartPolicyConf{
+					MinPercent: 50,
+				},
+			},
+			Merge: pcap.MergeConf{
+				DefaultWindow: 500 * time.Millisecond,
+				MaxWindow:     2 * time.Second,
+				MaxBytes:      8388608,
+			},
+			ConcurrentCapturesPerUser: 2,
+		},
+		Audit: pcap.AuditConf{
+			File:   "audit.log",
+			Stdout: true,
+			Syslog: &pcap.SyslogConf{
+				Network: "udp",
+				Address: "localhost:514",
+				Tag:     "pcap-api-audit",
+			},
 		},
-		ConcurrentCaptures: 5,
-		DrainTimeout:       time.Second * 10,
 		BoshResolverConfig: &pcap.BoshResolverConfig{
 			RawDirectorURL: "https://bosh.service.cf.internal:8080",
 			AgentPort:      9494,
@@ -55,6 +85,15 @@ func TestAPIConfig(t *testing.T) {
 				ServerName: "bosh.service.cf.internal",
 			},
 		},
+		CloudfoundryResolverConfig: &pcap.CloudfoundryResolverConfig{
+			RawCcURL:  "https://api.cf.example.com",
+			AgentPort: 9494,
+			TLS: &pcap.ClientTLS{
+				RootCas:    "cc-ca.pem",
+				SkipVerify: false,
+				ServerName: "api.cf.example.com",
+			},
+		},
 	}
 
 	if !cmp.Equal(cfg, reference) {
@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 //nolint:funlen // the function is good readable
@@ -55,25 +56,40 @@ func main() {
 
 	pcap.SetLogLevel(log, config.LogLevel)
 
-	api, err := pcap.NewAPI(config.Buffer, config.AgentsMTLS, config.ID, config.ConcurrentCaptures)
+	api, err := pcap.NewAPI(config.NodeConfig, config.APIConf)
 	if err != nil {
 		log.Error("Unable to create api", zap.Error(err))
 		return
 	}
 
-	// set up a BoshResolver, if one is defined.
-	err = registerBoshResolver(config.BoshResolverConfig, api)
+	// set up the BoshResolver and CloudfoundryResolver, if they are defined.
+	resolvers, err := newResolvers(config)
 	if err != nil {
-		log.Error("could not register BOSH Resolver", zap.Error(err))
+		log.Error("could not register resolvers", zap.Error(err))
 		return
 	}
+	api.ReplaceResolvers(resolvers...)
 
-	//TODO: CFAgentResolver
+	err = registerAuditSinks(config.Audit, api)
+	if err != nil {
+		log.Error("could not register audit sinks", zap.Error(err))
+		return
+	}
 
 	if len(api.HealthyResolverNames()) == 0 {
 		log.Error("could not register any AgentResolvers. Please check the configuration.")
 		return
 	}
+	api.WatchResolverHealth()
+
+	if config.Metrics != nil {
+		go func() {
+			metricsErr := pcap.ServeMetrics(*config.Metrics, log, api)
+			if metricsErr != nil {
+				log.Error("unable to serve metrics", zap.Error(metricsErr))
+			}
+		}()
+	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Listen.Port))
 	if err != nil {
@@ -96,8 +112,12 @@ func main() {
 
 	server := grpc.NewServer(grpc.Creds(tlsCredentials))
 	pcap.RegisterAPIServer(server, api)
+	grpc_health_v1.RegisterHealthServer(server, pcap.NewAPIHealthServer(api))
 
-	go pcap.StopOnSignal(log, api, server, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	go pcap.StopOnSignal(log, api, server, config.DrainTimeout, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	if len(os.Args) == 2 { //nolint:mnd // only a parsed config can be reloaded.
+		go pcap.ReloadOnSignal(log, reloadAPI(log, api, os.Args[1], config.Listen.TLS), syscall.SIGHUP)
+	}
 
 	log.Info("starting server")
 	err = server.Serve(lis)
@@ -109,19 +129,94 @@ func main() {
 	log.Info("serve returned successfully")
 }
 
-// registerBoshResolver tries to register a BoshResolver for the BOSH Director defined in config and register it in the api.
-// Does nothing when no BoshResolverConfig is provided.
+// newResolvers creates a BoshResolver for the BOSH Director and a CloudfoundryResolver for the Cloud Controller
+// defined in config. Resolvers that are not configured are omitted.
 //
-// Returns an error if the resolver cannot be initialized.
-func registerBoshResolver(config *pcap.BoshResolverConfig, api *pcap.API) error {
-	if config == nil {
-		return nil
+// Returns an error if any of the resolvers cannot be initialized.
+func newResolvers(config APIConfig) ([]pcap.AgentResolver, error) {
+	var resolvers []pcap.AgentResolver
+
+	if config.BoshResolverConfig != nil {
+		resolver, err := pcap.NewBoshResolver(*config.BoshResolverConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not create BOSH resolver: %w", err)
+		}
+		resolvers = append(resolvers, resolver)
 	}
 
-	resolver, err := pcap.NewBoshResolver(*config)
-	if err != nil {
-		return err
+	if config.CloudfoundryResolverConfig != nil {
+		resolver, err := pcap.NewCloudfoundryResolver(*config.CloudfoundryResolverConfig)
+		if err != nil {
+			return nil, fmt.Errorf("could not create Cloudfoundry resolver: %w", err)
+		}
+		resolvers = append(resolvers, resolver)
 	}
-	api.RegisterResolver(resolver)
+
+	return resolvers, nil
+}
+
+// registerAuditSinks registers a sink in the api for each sink defined in config.
+//
+// Returns an error if a sink cannot be initialized.
+func registerAuditSinks(config pcap.AuditConf, api *pcap.API) error {
+	if config.File != "" {
+		api.RegisterAuditSink(pcap.NewFileAuditSink(config.File))
+	}
+
+	if config.Stdout {
+		api.RegisterAuditSink(pcap.NewWriterAuditSink(os.Stdout))
+	}
+
+	if config.Syslog != nil {
+		sink, err := pcap.NewSyslogAuditSink(config.Syslog.Network, config.Syslog.Address, config.Syslog.Tag)
+		if err != nil {
+			return fmt.Errorf("could not connect to syslog: %w", err)
+		}
+		api.RegisterAuditSink(sink)
+	}
+
 	return nil
 }
+
+// reloadAPI returns the function that reloads the config at path. The log level is applied, the TLS files are
+// re-read and the credentials for the agents as well as the resolvers are re-created, so they use rotated root CAs
+// and changed files. Running captures are not interrupted. Changes to the TLS configuration of the listener, listenTLS,
+// fail the reload. All other settings require a restart.
+func reloadAPI(log *zap.Logger, api *pcap.API, path string, listenTLS *pcap.ServerTLS) func() error {
+	return func() error {
+		config, err := parseAPIConfig(path)
+		if err != nil {
+			return err
+		}
+
+		err = config.validate()
+		if err != nil {
+			return fmt.Errorf("validate config: %w", err)
+		}
+
+		err = pcap.CheckListenTLSReload(listenTLS, config.Listen.TLS)
+		if err != nil {
+			return err
+		}
+
+		pcap.SetLogLevel(log, config.LogLevel)
+
+		err = pcap.ReloadTLS()
+		if err != nil {
+			return err
+		}
+
+		err = api.ReloadAgentTLS(config.AgentsMTLS)
+		if err != nil {
+			return err
+		}
+
+		resolvers, err := newResolvers(config)
+		if err != nil {
+			return err
+		}
+		api.ReplaceResolvers(resolvers...)
+
+		return nil
+	}
+}
@@ -14,19 +14,30 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
 
+	"code.cloudfoundry.org/bytefmt"
 	"github.com/jessevdk/go-flags"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 	"gopkg.in/yaml.v3"
 )
 
 const BoshDefaultPort = 25555
 const BoshAuthTypeUAA = "uaa"
 
+// summaryFileMode are the permissions of the JSON file of the capture summary.
+const summaryFileMode = 0o600
+
 var (
 	logger         *zap.Logger
 	atomicLogLevel zap.AtomicLevel
@@ -37,20 +48,31 @@ var (
 )
 
 type options struct {
-	File               string   `short:"o" long:"file" description:"The output file. Written in binary pcap format." required:"true"`
-	ForceOverwriteFile bool     `short:"F" long:"force-overwrite" description:"Overwrites the output file if it already exists."`
-	PcapAPIURL         string   `short:"u" long:"pcap-api-url" description:"The URL of the PCAP API, e.g. pcap.cf.$LANDSCAPE_DOMAIN" env:"PCAP_API" required:"true"`
-	Filter             string   `short:"f" long:"filter" description:"Allows to provide a filter expression in pcap filter format." required:"false"`
-	Interface          string   `short:"i" long:"interface" description:"Specifies the network interface to listen on." default:"eth0" required:"false"`
-	BoshConfigFilename string   `short:"c" long:"bosh-config" description:"Path to the BOSH config file, used for the UAA Token" default:"${HOME}/.bosh/config" required:"true"`
-	BoshEnvironment    string   `short:"e" long:"bosh-environment" description:"The BOSH environment to use for retrieving the BOSH UAA token from the BOSH config file" env:"BOSH_ENVIRONMENT" required:"true"`
-	Deployment         string   `short:"d" long:"deployment" description:"The name of the deployment in which you would like to capture." required:"true"`
-	InstanceGroups     []string `short:"g" long:"instance-group" description:"The name of an instance group in the deployment in which you would like to capture. Can be defined multiple times." required:"true"`
-	InstanceIds        []string `positional-arg-name:"ids" description:"The instance IDs of the deployment to capture." required:"false"` //nolint:revive //keep InstanceIds name (not IDs)
-	SnapLength         uint16   `short:"l" long:"snaplen" description:"Snap Length, defining the captured length of the packet, with the remainder truncated. The real packet length is recorded." default:"65535"`
-	Verbose            bool     `short:"v" long:"verbose" description:"Show verbose debug information"`
-	Insecure           bool     `short:"k" long:"insecure" description:"Allow insecure server connections" required:"false"`
-	Quiet              bool     `short:"q" long:"quiet" description:"Show only warnings and errors"`
+	File               string        `short:"o" long:"file" description:"The output file. Written in binary pcap or pcapng format, see --format. Required unless --list-interfaces is set."`
+	Format             string        `long:"format" description:"The format of the output file. pcapng records the originating instance of each packet." choice:"pcap" choice:"pcapng" default:"pcap"`
+	ForceOverwriteFile bool          `short:"F" long:"force-overwrite" description:"Overwrites the output file if it already exists."`
+	PcapAPIURL         string        `short:"u" long:"pcap-api-url" description:"The URL of the PCAP API, e.g. pcap.cf.$LANDSCAPE_DOMAIN" env:"PCAP_API" required:"true"`
+	Filter             string        `short:"f" long:"filter" description:"Allows to provide a filter expression in pcap filter format." required:"false"`
+	Interfaces         []string      `short:"i" long:"interface" description:"Specifies the network interface to listen on. Can be defined multiple times to capture on several interfaces." default:"eth0" required:"false"`
+	BoshConfigFilename string        `short:"c" long:"bosh-config" description:"Path to the BOSH config file, used for the UAA Token" default:"${HOME}/.bosh/config" required:"true"`
+	BoshEnvironment    string        `short:"e" long:"bosh-environment" description:"The BOSH environment to use for retrieving the BOSH UAA token from the BOSH config file" env:"BOSH_ENVIRONMENT" required:"true"`
+	Deployment         string        `short:"d" long:"deployment" description:"The name of the deployment in which you would like to capture." required:"true"`
+	InstanceGroups     []string      `short:"g" long:"instance-group" description:"The name of an instance group in the deployment in which you would like to capture. Can be defined multiple times." required:"true"`
+	InstanceIds        []string      `positional-arg-name:"ids" description:"The instance IDs of the deployment to capture." required:"false"` //nolint:revive //keep InstanceIds name (not IDs)
+	SnapLength         uint16        `short:"l" long:"snaplen" description:"Snap Length, defining the captured length of the packet, with the remainder truncated. The real packet length is recorded." default:"65535"`
+	MaxDuration        time.Duration `long:"max-duration" description:"Stops the capture after the given duration, e.g. 10m. The server may enforce a lower limit." required:"false"`
+	MaxPackets         uint64        `long:"max-packets" description:"Stops the capture after the given number of packets. The server may enforce a lower limit." required:"false"`
+	MaxBytes           uint64        `long:"max-bytes" description:"Stops the capture after the given number of bytes. The server may enforce a lower limit." required:"false"`
+	TimestampPrecision string        `long:"timestamp-precision" description:"The precision of packet timestamps. Agents fall back to micro if their devices do not support nano." choice:"micro" choice:"nano" default:"micro"`
+	FlowControl        string        `long:"flow-control" description:"How the capture reacts if packets can not be forwarded fast enough. credit: the PCAP API and agents only send as many packets as the client can handle and buffer the rest. threshold: packets are discarded once buffers are full, required for PCAP APIs without support for credits." choice:"credit" choice:"threshold" default:"credit"`
+	OrderedMerge       bool          `long:"ordered-merge" description:"Merges the packets of all instances in order of their timestamps. Packets are held back for the merge window to wait for earlier packets of other instances."`
+	MergeWindow        time.Duration `long:"merge-window" description:"The time packets are held back by --ordered-merge, e.g. 500ms. The PCAP API may enforce a shorter window, its default applies if not set." required:"false"`
+	StartPolicy        string        `long:"start-policy" description:"The instances the capture must start on, otherwise it fails: 'all', a number, e.g. 3, or a percentage, e.g. 50%. The default of the PCAP API applies if not set." required:"false"`
+	Verbose            bool          `short:"v" long:"verbose" description:"Show verbose debug information"`
+	Insecure           bool          `short:"k" long:"insecure" description:"Allow insecure server connections" required:"false"`
+	Quiet              bool          `short:"q" long:"quiet" description:"Show only warnings and errors"`
+	ListInterfaces     bool          `long:"list-interfaces" description:"Lists the network interfaces of the selected instances instead of capturing."`
+	SummaryJSON        bool          `long:"summary-json" description:"Writes the capture summary as JSON next to the output file, e.g. capture.summary.json for capture.pcap."`
 }
 
 // init sets up the zap.Logger. Currently outputs to stderr in Console format.
@@ -69,7 +91,7 @@ func init() {
 		OutputPaths:       outputPaths,
 		ErrorOutputPaths:  outputPaths,
 	}
-	logger = zap.Must(zapConfig.Build())
+	logger = zap.Must(zapConfig.Build(zap.WrapCore(pcap.NewRedactingCore)))
 	zap.ReplaceGlobals(logger)
 	logger.Debug("successfully set up logger", zap.Strings("output-paths", outputPaths))
 }
@@ -121,10 +143,14 @@ func main() {
 	logger.Debug("bosh-config and tokens successfully updated")
 
 	// set up pcap-client/pcap-api connection
-	client, err = pcap.NewClient(opts.File, logger, pcap.LogMessageWriter{Log: logger})
-	if err != nil {
-		err = fmt.Errorf("could not set up pcap-client: %w", err)
-		return
+	if opts.ListInterfaces {
+		client = pcap.NewInterfaceClient(logger)
+	} else {
+		client, err = pcap.NewClient(opts.File, pcap.OutputFormat(opts.Format), logger, pcap.LogMessageWriter{Log: logger})
+		if err != nil {
+			err = fmt.Errorf("could not set up pcap-client: %w", err)
+			return
+		}
 	}
 	err = client.ConnectToAPI(apiURL, opts.Insecure)
 	if err != nil {
@@ -138,12 +164,36 @@ func main() {
 
 	logger.Debug("pcap-client successfully initialized and connected to pcap-api")
 
-	go pcap.StopOnSignal(logger, client, nil, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
-
 	endpointRequest := createEndpointRequest(environment.AccessToken, opts.Deployment, opts.InstanceGroups)
-	captureOptions := createCaptureOptions(opts.Interface, opts.Filter, uint32(opts.SnapLength))
+
+	if opts.ListInterfaces {
+		var instances []*pcap.InstanceInterfaces
+		instances, err = client.InstanceInterfaces(ctx, endpointRequest)
+		if err != nil {
+			return
+		}
+
+		err = printInterfaces(os.Stdout, instances)
+		return
+	}
+
+	var policy *pcap.StartPolicy
+	policy, err = startPolicy(opts.StartPolicy)
+	if err != nil {
+		return
+	}
+	client.SetStartPolicy(policy)
+
+	go pcap.StopOnSignal(logger, client, nil, 0, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+
+	captureOptions := createCaptureOptions(opts.Interfaces, opts.Filter, uint32(opts.SnapLength), createCaptureLimits(opts.MaxDuration, opts.MaxPackets, opts.MaxBytes), timestampPrecision(opts.TimestampPrecision))
+	captureOptions.OrderedMerge = createOrderedMerge(opts.OrderedMerge, opts.MergeWindow)
+	captureOptions.FlowControl = flowControl(opts.FlowControl)
 
 	err = client.CaptureRequest(ctx, cancel, endpointRequest, captureOptions)
+	if summary := client.Summary(); summary != nil {
+		reportSummary(summary, opts)
+	}
 	if err != nil {
 		return
 	}
@@ -157,13 +207,26 @@ func initFromOptions(opts options) (*url.URL, *Environment, error) {
 		apiURL      *url.URL
 		environment *Environment
 	)
-	err := checkOutputFile(opts.File, opts.ForceOverwriteFile)
-	if err != nil {
-		return nil, nil, err
+	if !opts.ListInterfaces {
+		if opts.File == "" {
+			return nil, nil, fmt.Errorf("an output file is required, specify it with '-o'")
+		}
+
+		err := checkOutputFile(opts.File, opts.ForceOverwriteFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if opts.SummaryJSON {
+			err = checkOutputFile(summaryFile(opts.File), opts.ForceOverwriteFile)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
 	}
 
 	// update bosh tokens/config
-	apiURL, err = parseAPIURL(urlWithScheme(opts.PcapAPIURL))
+	apiURL, err := parseAPIURL(urlWithScheme(opts.PcapAPIURL))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -314,6 +377,110 @@ func connectToEnvironment(environmentAlias string, config *Config) (*Environment
 	return nil, fmt.Errorf("could not find bosh-environment %s in BOSH CLI config", environmentAlias)
 }
 
+// printInterfaces writes the network interfaces of all instances as table to w. Instances for which listing failed are
+// printed with their error.
+func printInterfaces(w io.Writer, instances []*pcap.InstanceInterfaces) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, err := fmt.Fprintln(tw, "INSTANCE\tINTERFACE\tLINK TYPE\tFLAGS\tADDRESSES")
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if instance.Error != "" {
+			_, err = fmt.Fprintf(tw, "%s\terror: %s\n", instance.Identifier, instance.Error)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, intf := range instance.Interfaces {
+			_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", instance.Identifier, intf.Name, intf.LinkType,
+				strings.Join(intf.Flags, ","), strings.Join(intf.Addresses, ","))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tw.Flush()
+}
+
+// reportSummary prints the capture summary as table and writes it as JSON if requested. Failures are only logged,
+// the capture itself has completed already.
+func reportSummary(summary *pcap.CaptureSummary, opts options) {
+	err := printSummary(os.Stdout, summary)
+	if err != nil {
+		logger.Warn("unable to print capture summary", zap.Error(err))
+	}
+
+	if !opts.SummaryJSON {
+		return
+	}
+
+	file := summaryFile(opts.File)
+	err = writeSummary(file, summary)
+	if err != nil {
+		logger.Warn("unable to write capture summary", zap.String("file", file), zap.Error(err))
+		return
+	}
+	logger.Info("wrote capture summary to file", zap.String("file", file))
+}
+
+// printSummary writes the capture summary of each agent as table to w.
+func printSummary(w io.Writer, summary *pcap.CaptureSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, err := fmt.Fprintln(tw, "INSTANCE\tPACKETS\tBYTES\tKERNEL DROPS\tDISCARDED\tSTART\tSTOP\tREASON")
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range summary.Agents {
+		_, err = fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d (%s)\t%s\t%s\t%s\n", agent.Origin, agent.Packets, bytefmt.ByteSize(agent.Bytes),
+			agent.KernelDrops, agent.DiscardedPackets, bytefmt.ByteSize(agent.DiscardedBytes),
+			formatSummaryTime(agent.Start), formatSummaryTime(agent.Stop), stopReason(agent))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+func formatSummaryTime(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return "-"
+	}
+	return t.AsTime().Local().Format(time.TimeOnly)
+}
+
+// stopReason describes why the capture of agent stopped, e.g. "limit: maximum of 100 packets reached".
+func stopReason(agent *pcap.AgentSummary) string {
+	reason := strings.ReplaceAll(strings.ToLower(agent.StopReason.String()), "_", " ")
+	if agent.StopDetail == "" {
+		return reason
+	}
+	return fmt.Sprintf("%s: %s", reason, agent.StopDetail)
+}
+
+// summaryFile returns the name of the JSON file of the capture summary next to the output file.
+func summaryFile(outputFile string) string {
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ".summary.json"
+}
+
+// writeSummary writes summary as JSON to file.
+func writeSummary(file string, summary *pcap.CaptureSummary) error {
+	data, err := protojson.MarshalOptions{Multiline: true}.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, data, summaryFileMode)
+}
+
 // createEndpointRequest is a helper function to create a pcap.EndpointRequest from parameters.
 func createEndpointRequest(token string, deployment string, instanceGroups []string) *pcap.EndpointRequest {
 	endpointRequest := &pcap.EndpointRequest{
@@ -325,21 +492,92 @@ func createEndpointRequest(token string, deployment string, instanceGroups []str
 			},
 		},
 	}
-	logger.Debug("created endpoint-request", zap.Any("endpoint-request", endpointRequest))
+	logger.Debug("created endpoint-request", zap.Any("endpoint-request", pcap.RedactProto(endpointRequest)))
 	return endpointRequest
 }
 
 // createCaptureOptions is a helper function to create a pcap.CaptureOptions struct from parameters.
-func createCaptureOptions(device string, filter string, snaplen uint32) *pcap.CaptureOptions {
+//
+// The first device is also set as CaptureOptions.Device for agents that do not support multiple devices.
+func createCaptureOptions(devices []string, filter string, snaplen uint32, limits *pcap.CaptureLimits, precision pcap.TimestampPrecision) *pcap.CaptureOptions {
 	captureOptions := &pcap.CaptureOptions{
-		Device:  device,
-		Filter:  filter,
-		SnapLen: snaplen,
+		Devices:            devices,
+		Filter:             filter,
+		SnapLen:            snaplen,
+		Limits:             limits,
+		TimestampPrecision: precision,
+	}
+	if len(devices) > 0 {
+		captureOptions.Device = devices[0]
 	}
 	logger.Debug("created capture-options", zap.Any("capture-options", captureOptions))
 	return captureOptions
 }
 
+// timestampPrecision converts the value of the --timestamp-precision option to pcap.TimestampPrecision.
+func timestampPrecision(precision string) pcap.TimestampPrecision {
+	if precision == "nano" {
+		return pcap.TimestampPrecision_NANOSECONDS
+	}
+	return pcap.TimestampPrecision_MICROSECONDS
+}
+
+// createOrderedMerge is a helper function to create pcap.OrderedMerge from parameters. Returns nil if the ordered
+// merge is not enabled.
+func createOrderedMerge(enabled bool, window time.Duration) *pcap.OrderedMerge {
+	if !enabled {
+		return nil
+	}
+	merge := &pcap.OrderedMerge{}
+	if window > 0 {
+		merge.Window = durationpb.New(window)
+	}
+	return merge
+}
+
+// startPolicy converts the value of the --start-policy option to pcap.StartPolicy. Returns nil if the option is
+// not set.
+func startPolicy(policy string) (*pcap.StartPolicy, error) {
+	switch {
+	case policy == "":
+		return nil, nil
+	case policy == "all":
+		return &pcap.StartPolicy{Policy: &pcap.StartPolicy_All{All: true}}, nil
+	case strings.HasSuffix(policy, "%"):
+		percent, err := strconv.ParseUint(strings.TrimSuffix(policy, "%"), 10, 32)
+		if err != nil || percent > 100 {
+			return nil, fmt.Errorf("invalid start policy %q: percentage must be between 0%% and 100%%", policy)
+		}
+		return &pcap.StartPolicy{Policy: &pcap.StartPolicy_MinPercent{MinPercent: uint32(percent)}}, nil
+	default:
+		targets, err := strconv.ParseUint(policy, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start policy %q: expected 'all', a number or a percentage", policy)
+		}
+		return &pcap.StartPolicy{Policy: &pcap.StartPolicy_MinTargets{MinTargets: uint32(targets)}}, nil
+	}
+}
+
+// flowControl converts the value of the --flow-control option to pcap.FlowControl.
+func flowControl(flowControl string) pcap.FlowControl {
+	if flowControl == "threshold" {
+		return pcap.FlowControl_THRESHOLD
+	}
+	return pcap.FlowControl_CREDIT
+}
+
+// createCaptureLimits is a helper function to create pcap.CaptureLimits from parameters. Zero values are not limited.
+func createCaptureLimits(maxDuration time.Duration, maxPackets uint64, maxBytes uint64) *pcap.CaptureLimits {
+	limits := &pcap.CaptureLimits{
+		MaxPackets: maxPackets,
+		MaxBytes:   maxBytes,
+	}
+	if maxDuration > 0 {
+		limits.MaxDuration = durationpb.New(maxDuration)
+	}
+	return limits
+}
+
 // writeBoshConfig writes the Config to the config-file under configFileName.
 func writeBoshConfig(config *Config, configFileName string) error {
 	configWriter, err := os.Create(os.ExpandEnv(configFileName))
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/pem"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestParseAPIURL(t *testing.T) {
@@ -274,3 +283,144 @@ func TestEnvironment_Setup(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintInterfaces(t *testing.T) {
+	instances := []*pcap.InstanceInterfaces{
+		{
+			Identifier: "router/abc",
+			Interfaces: []*pcap.NetworkInterface{
+				{Name: "eth0", LinkType: "Ethernet", Flags: []string{"up", "running"}, Addresses: []string{"10.0.1.2/24", "fe80::1/64"}},
+				{Name: "lo", LinkType: "Ethernet", Flags: []string{"up", "running", "loopback"}, Addresses: []string{"127.0.0.1/8"}},
+			},
+		},
+		{
+			Identifier: "router/def",
+			Error:      "agent unavailable",
+		},
+	}
+
+	want := `INSTANCE    INTERFACE  LINK TYPE  FLAGS                ADDRESSES
+router/abc  eth0       Ethernet   up,running           10.0.1.2/24,fe80::1/64
+router/abc  lo         Ethernet   up,running,loopback  127.0.0.1/8
+router/def  error: agent unavailable
+`
+
+	var buf bytes.Buffer
+	err := printInterfaces(&buf, instances)
+	if err != nil {
+		t.Fatalf("printInterfaces() unexpected error: %v", err)
+	}
+
+	if buf.String() != want {
+		t.Errorf("printInterfaces() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestStartPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		expected *pcap.StartPolicy
+		wantErr  bool
+	}{
+		{name: "not set", policy: "", expected: nil},
+		{name: "all", policy: "all", expected: &pcap.StartPolicy{Policy: &pcap.StartPolicy_All{All: true}}},
+		{name: "number of instances", policy: "3", expected: &pcap.StartPolicy{Policy: &pcap.StartPolicy_MinTargets{MinTargets: 3}}},
+		{name: "percentage", policy: "50%", expected: &pcap.StartPolicy{Policy: &pcap.StartPolicy_MinPercent{MinPercent: 50}}},
+		{name: "percentage above 100", policy: "101%", wantErr: true},
+		{name: "negative number", policy: "-1", wantErr: true},
+		{name: "unknown policy", policy: "most", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := startPolicy(tt.policy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("startPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !proto.Equal(got, tt.expected) {
+				t.Errorf("startPolicy() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCreateOrderedMerge(t *testing.T) {
+	if got := createOrderedMerge(false, time.Second); got != nil {
+		t.Errorf("createOrderedMerge() = %v, want nil if not enabled", got)
+	}
+	if got := createOrderedMerge(true, 0); !proto.Equal(got, &pcap.OrderedMerge{}) {
+		t.Errorf("createOrderedMerge() = %v, want the default window of the api", got)
+	}
+	if got := createOrderedMerge(true, time.Second); got.GetWindow().AsDuration() != time.Second {
+		t.Errorf("createOrderedMerge() window = %v, want %v", got.GetWindow().AsDuration(), time.Second)
+	}
+}
+
+func TestPrintSummary(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	summary := &pcap.CaptureSummary{Agents: []*pcap.AgentSummary{
+		{
+			Origin: "router/abc", Packets: 10, Bytes: 2048, KernelDrops: 1, DiscardedPackets: 2, DiscardedBytes: 100,
+			Start: timestamppb.New(start), Stop: timestamppb.New(start.Add(time.Minute)), StopReason: pcap.StopReason_CLIENT_STOP,
+		},
+		{Origin: "router/def", StopReason: pcap.StopReason_ERROR, StopDetail: "agent unavailable"},
+	}}
+
+	want := fmt.Sprintf(`INSTANCE    PACKETS  BYTES  KERNEL DROPS  DISCARDED  START     STOP      REASON
+router/abc  10       2K     1             2 (100B)   %s  %s  client stop
+router/def  0        0B     0             0 (0B)     -         -         error: agent unavailable
+`, start.Local().Format(time.TimeOnly), start.Add(time.Minute).Local().Format(time.TimeOnly))
+
+	var buf bytes.Buffer
+	err := printSummary(&buf, summary)
+	if err != nil {
+		t.Fatalf("printSummary() unexpected error: %v", err)
+	}
+
+	if buf.String() != want {
+		t.Errorf("printSummary() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestSummaryFile(t *testing.T) {
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{file: "capture.pcap", expected: "capture.summary.json"},
+		{file: "/tmp/capture.pcapng", expected: "/tmp/capture.summary.json"},
+		{file: "capture", expected: "capture.summary.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := summaryFile(tt.file); got != tt.expected {
+				t.Errorf("summaryFile() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "capture.summary.json")
+	summary := &pcap.CaptureSummary{Agents: []*pcap.AgentSummary{{Origin: "router/abc", Packets: 10, StopReason: pcap.StopReason_LIMIT}}}
+
+	err := writeSummary(file, summary)
+	if err != nil {
+		t.Fatalf("writeSummary() unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading summary file: %v", err)
+	}
+
+	got := &pcap.CaptureSummary{}
+	err = protojson.Unmarshal(data, got)
+	if err != nil {
+		t.Fatalf("summary file is not valid JSON: %v", err)
+	}
+	if !proto.Equal(got, summary) {
+		t.Errorf("writeSummary() wrote %v, want %v", got, summary)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // newTLSConfig is used to set common defaults on newly created TLS
@@ -39,6 +42,8 @@ type ServerTLS struct {
 	Verify tls.ClientAuthType `yaml:"verify"`
 }
 
+// Config creates the TLS configuration of a server. The certificate and the client CAs are provided by callbacks,
+// so new handshakes use the files re-read by ReloadTLS.
 func (c *ServerTLS) Config() (*tls.Config, error) {
 	if c == nil {
 		return nil, fmt.Errorf("server TLS config must be non-nil")
@@ -46,11 +51,11 @@ func (c *ServerTLS) Config() (*tls.Config, error) {
 
 	tlsConf := newTLSConfig()
 
-	cert, err := tls.LoadX509KeyPair(c.Certificate, c.PrivateKey)
+	keyPair, err := tlsFiles.keyPair(c.Certificate, c.PrivateKey)
 	if err != nil {
-		return nil, fmt.Errorf("load x509 key pair: %w", err)
+		return nil, err
 	}
-	tlsConf.Certificates = []tls.Certificate{cert}
+	tlsConf.GetCertificate = keyPair.getCertificate
 
 	if c.ClientCas == "" && tlsConf.ClientAuth > 0 {
 		return nil, fmt.Errorf("tls config: configuered client certificate authentication without client CA list")
@@ -64,11 +69,19 @@ func (c *ServerTLS) Config() (*tls.Config, error) {
 	// configure mTLS
 	tlsConf.ClientAuth = c.Verify
 
-	trustedCas, err := createCAPool(c.ClientCas)
+	trustedCas, err := tlsFiles.caPool(c.ClientCas)
 	if err != nil {
-		return nil, fmt.Errorf("create CA pool: %w", err)
+		return nil, err
+	}
+	tlsConf.ClientCAs = trustedCas.pool.Load()
+
+	// the client CAs can only be replaced for a handshake by a separate configuration.
+	baseConf := tlsConf.Clone()
+	tlsConf.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		handshakeConf := baseConf.Clone()
+		handshakeConf.ClientCAs = trustedCas.pool.Load()
+		return handshakeConf, nil
 	}
-	tlsConf.ClientCAs = trustedCas
 
 	return tlsConf, nil
 }
@@ -91,6 +104,9 @@ type ClientTLS struct {
 	ServerName string `yaml:"server_name"`
 }
 
+// Config creates the TLS configuration of a client. The client certificate is provided by a callback, so new
+// handshakes use the files re-read by ReloadTLS. The root CAs are taken from the files re-read by ReloadTLS when the
+// configuration is created, configurations must be re-created to use rotated root CAs.
 func (c *ClientTLS) Config() (*tls.Config, error) {
 	tlsConf := newTLSConfig()
 	if c == nil {
@@ -98,21 +114,21 @@ func (c *ClientTLS) Config() (*tls.Config, error) {
 	}
 
 	if c.Certificate != "" || c.PrivateKey != "" {
-		cert, err := tls.LoadX509KeyPair(c.Certificate, c.PrivateKey)
+		keyPair, err := tlsFiles.keyPair(c.Certificate, c.PrivateKey)
 		if err != nil {
-			return nil, fmt.Errorf("load x509 key pair: %w", err)
+			return nil, err
 		}
-		tlsConf.Certificates = []tls.Certificate{cert}
+		tlsConf.GetClientCertificate = keyPair.getClientCertificate
 	}
 
 	tlsConf.InsecureSkipVerify = c.SkipVerify
 
 	if c.RootCas != "" {
-		trustedCas, err := createCAPool(c.RootCas)
+		trustedCas, err := tlsFiles.caPool(c.RootCas)
 		if err != nil {
-			return nil, fmt.Errorf("create CA pool: %w", err)
+			return nil, err
 		}
-		tlsConf.RootCAs = trustedCas
+		tlsConf.RootCAs = trustedCas.pool.Load()
 	}
 
 	if c.ServerName != "" {
@@ -149,11 +165,197 @@ type Listen struct {
 	TLS  *ServerTLS `yaml:"tls,omitempty"`
 }
 
+// CaptureLimitsConf defines hard upper bounds for captures that clients can not exceed. Clients may
+// request lower limits. A value of zero means that no upper bound is enforced.
+type CaptureLimitsConf struct {
+	MaxDuration time.Duration `yaml:"max_duration" validate:"gte=0"`
+	MaxPackets  uint64        `yaml:"max_packets"`
+	MaxBytes    uint64        `yaml:"max_bytes"`
+}
+
+// apply returns the CaptureLimits that are in effect when the client requests limits. Requested limits that are
+// not set or exceed the configured upper bounds are replaced by the upper bound.
+func (c CaptureLimitsConf) apply(limits *CaptureLimits) *CaptureLimits {
+	effective := &CaptureLimits{
+		MaxDuration: limits.GetMaxDuration(),
+		MaxPackets:  limits.GetMaxPackets(),
+		MaxBytes:    limits.GetMaxBytes(),
+	}
+
+	if c.MaxDuration > 0 && (effective.MaxDuration.AsDuration() <= 0 || effective.MaxDuration.AsDuration() > c.MaxDuration) {
+		effective.MaxDuration = durationpb.New(c.MaxDuration)
+	}
+
+	if c.MaxPackets > 0 && (effective.MaxPackets == 0 || effective.MaxPackets > c.MaxPackets) {
+		effective.MaxPackets = c.MaxPackets
+	}
+
+	if c.MaxBytes > 0 && (effective.MaxBytes == 0 || effective.MaxBytes > c.MaxBytes) {
+		effective.MaxBytes = c.MaxBytes
+	}
+
+	return effective
+}
+
+// AgentConnectConf defines how the pcap-api connects to the pcap-agents of a capture. Zero values are replaced
+// by defaults.
+type AgentConnectConf struct {
+	// Timeout for connecting to a single agent and checking its status. Agents that do not respond in time are
+	// reported as unavailable, the capture continues with the other agents.
+	Timeout time.Duration `yaml:"timeout" validate:"gte=0"`
+	// Parallelism is the maximum number of agents that are connected to at the same time.
+	Parallelism int `yaml:"parallelism" validate:"gte=0"`
+	// StartPolicy is the default policy for captures that do not request one.
+	StartPolicy StartPolicyConf `yaml:"start_policy"`
+}
+
+// StartPolicyConf defines how many targets of a capture must start capturing for the capture to succeed. At most
+// one of the options can be set, if none is set the capture must start on at least one target.
+type StartPolicyConf struct {
+	// All requires the capture to start on all targets.
+	All bool `yaml:"all"`
+	// MinTargets is the minimum number of targets the capture must start on.
+	MinTargets uint32 `yaml:"min_targets"`
+	// MinPercent is the minimum percentage of targets the capture must start on.
+	MinPercent uint32 `yaml:"min_percent" validate:"lte=100"`
+}
+
+// policy converts c to the StartPolicy of a capture request. Returns nil if no option is set.
+func (c StartPolicyConf) policy() (*StartPolicy, error) {
+	var policies []*StartPolicy
+	if c.All {
+		policies = append(policies, &StartPolicy{Policy: &StartPolicy_All{All: true}})
+	}
+	if c.MinTargets > 0 {
+		policies = append(policies, &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: c.MinTargets}})
+	}
+	if c.MinPercent > 0 {
+		policies = append(policies, &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: c.MinPercent}})
+	}
+
+	switch len(policies) {
+	case 0:
+		return nil, nil
+	case 1:
+		err := validateStartPolicy(policies[0])
+		if err != nil {
+			return nil, err
+		}
+		return policies[0], nil
+	default:
+		return nil, fmt.Errorf("start policy: only one of all, min_targets and min_percent can be set")
+	}
+}
+
+const (
+	defaultAgentConnectTimeout     = 10 * time.Second
+	defaultAgentConnectParallelism = 10
+)
+
+// withDefaults returns a copy of c with the defaults for all values that are not set.
+func (c AgentConnectConf) withDefaults() AgentConnectConf {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultAgentConnectTimeout
+	}
+	if c.Parallelism <= 0 {
+		c.Parallelism = defaultAgentConnectParallelism
+	}
+	return c
+}
+
+// MergeConf bounds the ordered merge of the packets of all targets of a capture, which clients can request.
+// Zero values are replaced by defaults.
+type MergeConf struct {
+	// DefaultWindow is the time packets are held back if the client does not request a window.
+	DefaultWindow time.Duration `yaml:"default_window" validate:"gte=0"`
+	// MaxWindow is the upper bound for the window requested by clients.
+	MaxWindow time.Duration `yaml:"max_window" validate:"gte=0"`
+	// MaxBytes is the maximum number of packet bytes held back per capture. Once exceeded, the earliest packets
+	// are released before their window has passed.
+	MaxBytes int `yaml:"max_bytes" validate:"gte=0"`
+}
+
+const (
+	defaultMergeWindow    = time.Second
+	defaultMaxMergeWindow = 5 * time.Second
+	defaultMaxMergeBytes  = 16 * 1024 * 1024
+)
+
+// withDefaults returns a copy of c with the defaults for all values that are not set.
+func (c MergeConf) withDefaults() MergeConf {
+	if c.MaxWindow <= 0 {
+		c.MaxWindow = defaultMaxMergeWindow
+	}
+	if c.DefaultWindow <= 0 {
+		c.DefaultWindow = min(defaultMergeWindow, c.MaxWindow)
+	}
+	if c.MaxBytes <= 0 {
+		c.MaxBytes = defaultMaxMergeBytes
+	}
+	return c
+}
+
+// window returns the window that is in effect when the client requests the given window.
+func (c MergeConf) window(requested *durationpb.Duration) time.Duration {
+	window := requested.AsDuration()
+	if window <= 0 {
+		return c.DefaultWindow
+	}
+	return min(window, c.MaxWindow)
+}
+
+// AuditConf defines the sinks the audit records of all captures are written to. All configured sinks are used, no
+// records are written if none is configured.
+type AuditConf struct {
+	// File is the path of a file the records are appended to as JSON lines.
+	File string `yaml:"file"`
+	// Stdout writes the records as JSON lines to stdout.
+	Stdout bool `yaml:"stdout"`
+	// Syslog sends the records to a syslog server.
+	Syslog *SyslogConf `yaml:"syslog,omitempty"`
+}
+
+// SyslogConf defines the syslog server audit records are sent to.
+type SyslogConf struct {
+	// Network is the network of the syslog server, e.g. "udp". The local syslog server is used if Network and
+	// Address are empty.
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	// Tag is the tag of all records.
+	Tag string `yaml:"tag"`
+}
+
 type NodeConfig struct {
-	Listen   Listen     `yaml:"listen"`
-	Buffer   BufferConf `yaml:"buffer"`
-	LogLevel string     `yaml:"log_level"`
-	ID       string     `yaml:"id" validate:"required"`
+	Listen   Listen            `yaml:"listen"`
+	Buffer   BufferConf        `yaml:"buffer"`
+	Limits   CaptureLimitsConf `yaml:"limits"`
+	LogLevel string            `yaml:"log_level"`
+	ID       string            `yaml:"id" validate:"required"`
+	// Metrics enables the HTTP listener for metrics in the prometheus format if set.
+	Metrics *MetricsConf `yaml:"metrics,omitempty" validate:"omitempty"`
+}
+
+// APIConf defines the behaviour of the pcap-api in addition to its NodeConfig.
+type APIConf struct {
+	// AgentsMTLS is used to connect to the agents, plain connections are used if it is nil.
+	AgentsMTLS *ClientTLS `yaml:"agents_mtls" validate:"omitempty"`
+	// ConcurrentCaptures is the maximum number of captures of all clients.
+	ConcurrentCaptures int32 `yaml:"concurrent_captures"`
+	// ConcurrentCapturesPerUser limits the captures of each user in addition to ConcurrentCaptures, zero means no limit.
+	ConcurrentCapturesPerUser int32 `yaml:"concurrent_captures_per_user"`
+	// DrainTimeout is the time running captures are given to flush once the api is stopped, zero waits without bound.
+	DrainTimeout time.Duration    `yaml:"drain_timeout"`
+	AgentConnect AgentConnectConf `yaml:"agent_connect"`
+	Merge        MergeConf        `yaml:"merge"`
+}
+
+// AgentConf defines the behaviour of the pcap-agent in addition to its NodeConfig.
+type AgentConf struct {
+	// DrainTimeout is the time running captures are given to flush once the agent is stopped, zero waits without
+	// bound.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+	// ConcurrentCaptures is the maximum number of captures running on the agent at the same time, zero means no limit.
+	ConcurrentCaptures int32 `yaml:"concurrent_captures"`
 }
 
 func createCAPool(certificateAuthorityFile string) (*x509.CertPool, error) {
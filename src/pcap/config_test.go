@@ -3,6 +3,10 @@ package pcap
 import (
 	"crypto/x509"
 	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestCreateCAPool(t *testing.T) {
@@ -34,3 +38,128 @@ func TestCreateCAPool(t *testing.T) {
 		})
 	}
 }
+
+func TestCaptureLimitsConfApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     CaptureLimitsConf
+		limits   *CaptureLimits
+		expected *CaptureLimits
+	}{
+		{
+			name:     "no upper bounds and no limits",
+			conf:     CaptureLimitsConf{},
+			limits:   nil,
+			expected: &CaptureLimits{},
+		},
+		{
+			name:     "no upper bounds keeps requested limits",
+			conf:     CaptureLimitsConf{},
+			limits:   &CaptureLimits{MaxDuration: durationpb.New(time.Minute), MaxPackets: 10, MaxBytes: 100},
+			expected: &CaptureLimits{MaxDuration: durationpb.New(time.Minute), MaxPackets: 10, MaxBytes: 100},
+		},
+		{
+			name:     "upper bounds apply to missing limits",
+			conf:     CaptureLimitsConf{MaxDuration: time.Hour, MaxPackets: 1000, MaxBytes: 10000},
+			limits:   nil,
+			expected: &CaptureLimits{MaxDuration: durationpb.New(time.Hour), MaxPackets: 1000, MaxBytes: 10000},
+		},
+		{
+			name:     "limits below upper bounds are kept",
+			conf:     CaptureLimitsConf{MaxDuration: time.Hour, MaxPackets: 1000, MaxBytes: 10000},
+			limits:   &CaptureLimits{MaxDuration: durationpb.New(time.Minute), MaxPackets: 10, MaxBytes: 100},
+			expected: &CaptureLimits{MaxDuration: durationpb.New(time.Minute), MaxPackets: 10, MaxBytes: 100},
+		},
+		{
+			name:     "limits above upper bounds are capped",
+			conf:     CaptureLimitsConf{MaxDuration: time.Hour, MaxPackets: 1000, MaxBytes: 10000},
+			limits:   &CaptureLimits{MaxDuration: durationpb.New(2 * time.Hour), MaxPackets: 2000, MaxBytes: 20000},
+			expected: &CaptureLimits{MaxDuration: durationpb.New(time.Hour), MaxPackets: 1000, MaxBytes: 10000},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			effective := test.conf.apply(test.limits)
+			if !proto.Equal(test.expected, effective) {
+				t.Errorf("expected = %v, effective = %v", test.expected, effective)
+			}
+		})
+	}
+}
+
+func TestStartPolicyConfPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     StartPolicyConf
+		expected *StartPolicy
+		wantErr  bool
+	}{
+		{
+			name:     "no policy",
+			conf:     StartPolicyConf{},
+			expected: nil,
+		},
+		{
+			name:     "all targets",
+			conf:     StartPolicyConf{All: true},
+			expected: &StartPolicy{Policy: &StartPolicy_All{All: true}},
+		},
+		{
+			name:     "minimum number of targets",
+			conf:     StartPolicyConf{MinTargets: 3},
+			expected: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 3}},
+		},
+		{
+			name:     "minimum percentage of targets",
+			conf:     StartPolicyConf{MinPercent: 50},
+			expected: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 50}},
+		},
+		{
+			name:    "percentage above 100",
+			conf:    StartPolicyConf{MinPercent: 101},
+			wantErr: true,
+		},
+		{
+			name:    "multiple options",
+			conf:    StartPolicyConf{All: true, MinTargets: 3},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := test.conf.policy()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("policy() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !proto.Equal(test.expected, policy) {
+				t.Errorf("expected = %v, policy = %v", test.expected, policy)
+			}
+		})
+	}
+}
+
+func TestMergeConfWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		conf      MergeConf
+		requested *durationpb.Duration
+		expected  time.Duration
+	}{
+		{name: "defaults", conf: MergeConf{}, requested: nil, expected: defaultMergeWindow},
+		{name: "configured default", conf: MergeConf{DefaultWindow: time.Millisecond}, requested: nil, expected: time.Millisecond},
+		{name: "default capped by max window", conf: MergeConf{MaxWindow: 100 * time.Millisecond}, requested: nil, expected: 100 * time.Millisecond},
+		{name: "requested window", conf: MergeConf{}, requested: durationpb.New(2 * time.Second), expected: 2 * time.Second},
+		{name: "requested window above max window", conf: MergeConf{MaxWindow: 3 * time.Second}, requested: durationpb.New(time.Minute), expected: 3 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window := test.conf.withDefaults().window(test.requested)
+			if window != test.expected {
+				t.Errorf("expected = %v, window = %v", test.expected, window)
+			}
+		})
+	}
+}
@@ -21,13 +21,16 @@ var (
 	errTooManyCaptures   = fmt.Errorf("too many concurrent captures")
 	errDraining          = fmt.Errorf("draining")
 	errUnexpectedMessage = fmt.Errorf("unexpected message")
+	errUntrustedURL      = fmt.Errorf("untrusted url")
+	errTLSChanged        = fmt.Errorf("tls configuration changed")
 	ErrNoEndpoints       = fmt.Errorf("no matching endpoints found")
 	ErrNotConnected      = fmt.Errorf("client not connected to api")
 	ErrResolverUnhealthy = fmt.Errorf("resolver unhealthy")
 	ErrNotAuthorized     = fmt.Errorf("not authorized")
 	ErrTokenUnsupported  = fmt.Errorf("token unsupported: %w", ErrNotAuthorized)
 
-	ErrBoshNotConnected = fmt.Errorf("not connected to bosh director")
+	ErrBoshNotConnected         = fmt.Errorf("not connected to bosh director")
+	ErrCloudfoundryNotConnected = fmt.Errorf("not connected to cloud controller")
 )
 
 // pcapError is an attempt to work around the shortcomings of error handling in the gRPC
@@ -53,11 +56,13 @@ func (e pcapError) Unwrap() error {
 
 // errorf creates a new error that is compatible with gRPC status and has support for
 // wrapping errors. It works like fmt.Errorf (because that's what's used under the hood).
+//
+// Secrets are redacted from the message, as it is returned to clients, see RedactString.
 func errorf(c codes.Code, format string, a ...interface{}) error {
 	// we simply utilize fmt.Errorf instead of struggling with a custom implementation
 	e := fmt.Errorf(format, a...)
 	return pcapError{
-		status: status.New(c, e.Error()),
+		status: status.New(c, RedactString(e.Error())),
 		// if something has been wrapped, get it
 		inner: errors.Unwrap(e),
 	}
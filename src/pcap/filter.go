@@ -5,8 +5,22 @@ import (
 	"net"
 	"strings"
 	"unicode"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcap"
 )
 
+// defaultFilterSnapLen is used to compile filters when no snaplen is provided.
+const defaultFilterSnapLen = 65535
+
+// linkTypeRaw is DLT_RAW as reported by libpcap on Linux for devices without link layer, e.g. tun devices. It differs
+// from layers.LinkTypeRaw, which is the value used in capture files.
+const linkTypeRaw layers.LinkType = 12
+
+// captureLinkTypes are the link types of the devices agents capture on: Ethernet devices, the "any" device (Linux
+// cooked capture v1 or v2, depending on libpcap) and devices without link layer.
+var captureLinkTypes = []layers.LinkType{layers.LinkTypeEthernet, layers.LinkTypeLinuxSLL, layers.LinkTypeLinuxSLL2, linkTypeRaw}
+
 // interfaceAddrs provides a list of all known network addresses.
 var interfaceAddrs = net.InterfaceAddrs
 
@@ -78,6 +92,43 @@ func generateAPIFilter() (string, error) {
 	return strings.Join(ipFilters, " or "), nil
 }
 
+// validateFilter compiles filter for linkType with the offline compiler of libpcap, which does not require
+// a network interface. This detects invalid filters before they are sent to any agent.
+//
+// Returns an error containing the compiler's message if the filter is invalid.
+func validateFilter(filter string, linkType layers.LinkType, snapLen uint32) error {
+	captureLength := defaultFilterSnapLen
+	if snapLen > 0 {
+		captureLength = int(snapLen)
+	}
+
+	_, err := pcap.CompileBPFFilter(linkType, captureLength, filter)
+	if err != nil {
+		return fmt.Errorf("compile filter %q: %w", filter, err)
+	}
+
+	return nil
+}
+
+// validateFilterForLinkTypes checks that filter compiles for at least one of linkTypes. The link types of the devices
+// are not known before the agents open them, which is why a filter is only rejected if it can not be used for any
+// device. Agents compile the filter for the link type of each device they capture on.
+//
+// Returns the error of the first link type if the filter is invalid for all of them.
+func validateFilterForLinkTypes(filter string, linkTypes []layers.LinkType, snapLen uint32) error {
+	var firstErr error
+	for _, linkType := range linkTypes {
+		err := validateFilter(filter, linkType, snapLen)
+		if err == nil {
+			return nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // validateDevice is a go implementation of dev_valid_name from the linux kernel.
 //
 // See: https://lxr.linux.no/linux+v6.0.9/net/core/dev.c#L995
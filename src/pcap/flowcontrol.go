@@ -0,0 +1,340 @@
+package pcap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// creditGate limits the packets that are forwarded to the credits granted by the receiver, see FlowControl CREDIT.
+// A nil creditGate does not limit anything, which is used for FlowControl THRESHOLD.
+type creditGate struct {
+	mu        sync.Mutex
+	available uint64
+	// unlimited is set once the receiver does not grant credits anymore, e.g. after the stop request.
+	unlimited bool
+	// granted is signaled whenever credits are granted or the gate is opened.
+	granted chan struct{}
+}
+
+// newCreditGate returns the creditGate for the given flow control. Returns nil for FlowControl THRESHOLD.
+func newCreditGate(flowControl FlowControl) *creditGate {
+	if flowControl != FlowControl_CREDIT {
+		return nil
+	}
+	return &creditGate{granted: make(chan struct{}, 1)}
+}
+
+// grant adds n credits.
+func (g *creditGate) grant(n uint32) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	g.available += uint64(n)
+	g.mu.Unlock()
+	g.signal()
+}
+
+// open stops limiting packets, further packets do not require credits.
+func (g *creditGate) open() {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	g.unlimited = true
+	g.mu.Unlock()
+	g.signal()
+}
+
+// tryAcquire consumes one credit. Returns false if no credit is available.
+func (g *creditGate) tryAcquire() bool {
+	if g == nil {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.unlimited {
+		return true
+	}
+	if g.available == 0 {
+		return false
+	}
+	g.available--
+	return true
+}
+
+func (g *creditGate) signal() {
+	select {
+	case g.granted <- struct{}{}:
+	default:
+		// a signal is pending already.
+	}
+}
+
+// awaitCredit waits until a credit for the next packet is available. If the sender is congested, i.e. its buffer
+// is full while waiting, the receiver is informed with a CONGESTED message once waiting starts and once it ends.
+// Returns the limit that expired while waiting, if any.
+func awaitCredit(credits *creditGate, limit *limitTracker, stream responseSender, congested bool, id string) (string, error) {
+	if credits.tryAcquire() {
+		return "", nil
+	}
+
+	start := time.Now()
+	if congested {
+		err := stream.Send(newMessageResponse(MessageType_CONGESTED, "receiver does not keep up, waiting for credits", id))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for !credits.tryAcquire() {
+		select {
+		case <-credits.granted:
+		case <-limit.expired():
+			return limit.durationLimit(), nil
+		}
+	}
+
+	if congested {
+		msg := fmt.Sprintf("resumed forwarding after waiting %s for credits", time.Since(start).Round(time.Millisecond))
+		err := stream.Send(newMessageResponse(MessageType_CONGESTED, msg, id))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// thresholdDiscarder discards packets while the buffer of the sender is congested, see FlowControl THRESHOLD.
+//
+// Each period of discarding is a congestion episode. The packets and bytes discarded during an episode are reported
+// per origin once it ends, the totals of all episodes are reported at the end of the capture.
+type thresholdDiscarder struct {
+	bufConf    BufferConf
+	discarding bool
+	// episode contains the packets discarded since discarding started.
+	episode discardCounter
+	// episodeStart is the time at which discarding started.
+	episodeStart time.Time
+	// total contains the packets discarded during all episodes.
+	total discardCounter
+	id    string
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+func newThresholdDiscarder(bufConf BufferConf, id string) *thresholdDiscarder {
+	return &thresholdDiscarder{bufConf: bufConf, id: id, now: time.Now}
+}
+
+// filter returns the responses to send for res, depending on the fill level of the buffer. Messages are never
+// discarded.
+//
+// example (values are probably a bad choice):
+// buffer size: 10
+// lower limit: 2
+// upper limit: 8
+// fill          => fill level of buffer
+// discarding    => are we currently discarding packet responses?
+// messages sent => how many messages have been sent up until now
+// fill | discarding | messages sent
+// 2    | false      | 0
+// 1    | false      | 1
+// 7    | false      | 2
+// 6    | false      | 3
+// 9    | true       | 4 // last packet was DISCARDING_MESSAGES
+// 8    | true       | 4
+// 7    | true       | 4
+// ...
+// 3    | true       | 4
+// 2    | false      | 6 // the discarded packets of the episode are sent before the packet
+// 1    | false      | 7
+func (d *thresholdDiscarder) filter(res *CaptureResponse, fill int) []*CaptureResponse {
+	// we never discard messages, only data
+	_, isMsg := res.Payload.(*CaptureResponse_Message)
+
+	switch {
+	case fill <= d.bufConf.LowerLimit: // if buffer size is zero this case will always match
+		if summary := d.summary(); summary != nil {
+			return []*CaptureResponse{summary, res}
+		}
+	case d.discarding && !isMsg:
+		d.discard(res)
+		return nil
+	case fill >= d.bufConf.UpperLimit && !isMsg:
+		d.discarding = true
+		d.episodeStart = d.now()
+		d.discard(res)
+		// this only is sent when we start discarding (and discards the current data packet)
+		return []*CaptureResponse{newMessageResponse(MessageType_CONGESTED, "too much back pressure, discarding packets", d.id)}
+	}
+
+	return []*CaptureResponse{res}
+}
+
+func (d *thresholdDiscarder) discard(res *CaptureResponse) {
+	origin := res.GetPacket().GetOrigin()
+	size := len(res.GetPacket().GetData())
+
+	d.episode.add(origin, size)
+	d.total.add(origin, size)
+	discardedPackets.WithLabelValues(origin).Inc()
+	discardedBytes.WithLabelValues(origin).Add(float64(size))
+}
+
+// summary ends the current congestion episode. Returns the CONGESTED message with the packets discarded during the
+// episode and its duration, or nil if no packets have been discarded.
+func (d *thresholdDiscarder) summary() *CaptureResponse {
+	d.discarding = false
+	if d.episode.empty() {
+		return nil
+	}
+
+	duration := d.now().Sub(d.episodeStart)
+	packets, bytes := d.episode.sum()
+	msg := fmt.Sprintf("discarded %d packets (%s) in %s due to back pressure", packets, bytefmt.ByteSize(bytes), duration.Round(time.Millisecond))
+
+	res := newMessageResponse(MessageType_CONGESTED, msg, d.id)
+	res.GetMessage().Discards = &DiscardStatistics{Origins: d.episode.origins(), Duration: durationpb.New(duration)}
+	d.episode = discardCounter{}
+
+	return res
+}
+
+// totals ends the current congestion episode and returns its summary along with the CONGESTED message that contains
+// the packets discarded during the whole capture. Returns nothing if no packets have been discarded.
+func (d *thresholdDiscarder) totals() []*CaptureResponse {
+	if d.total.empty() {
+		return nil
+	}
+
+	var responses []*CaptureResponse
+	if summary := d.summary(); summary != nil {
+		responses = append(responses, summary)
+	}
+
+	packets, bytes := d.total.sum()
+	msg := fmt.Sprintf("discarded %d packets (%s) in total due to back pressure", packets, bytefmt.ByteSize(bytes))
+
+	res := newMessageResponse(MessageType_CONGESTED, msg, d.id)
+	res.GetMessage().Discards = &DiscardStatistics{Origins: d.total.origins(), Total: true}
+
+	return append(responses, res)
+}
+
+// discardCounter counts discarded packets and bytes per origin.
+type discardCounter map[string]*OriginDiscards
+
+func (c *discardCounter) add(origin string, size int) {
+	if *c == nil {
+		*c = discardCounter{}
+	}
+
+	discards, ok := (*c)[origin]
+	if !ok {
+		discards = &OriginDiscards{Origin: origin}
+		(*c)[origin] = discards
+	}
+	discards.Packets++
+	discards.Bytes += uint64(size) //nolint:gosec // packet sizes are non negative
+}
+
+func (c discardCounter) empty() bool {
+	return len(c) == 0
+}
+
+// sum returns the packets and bytes discarded for all origins.
+func (c discardCounter) sum() (uint64, uint64) {
+	var packets, bytes uint64
+	for _, discards := range c {
+		packets += discards.Packets
+		bytes += discards.Bytes
+	}
+	return packets, bytes
+}
+
+// origins returns copies of the discards per origin, sorted by origin.
+func (c discardCounter) origins() []*OriginDiscards {
+	origins := make([]*OriginDiscards, 0, len(c))
+	for _, discards := range c {
+		origins = append(origins, &OriginDiscards{Origin: discards.Origin, Packets: discards.Packets, Bytes: discards.Bytes})
+	}
+	slices.SortFunc(origins, func(a, b *OriginDiscards) int {
+		return strings.Compare(a.Origin, b.Origin)
+	})
+	return origins
+}
+
+// creditGranter grants credits to a pcap-agent as the api forwards its packets, see FlowControl CREDIT. Credits
+// are granted in batches to avoid a request per packet.
+type creditGranter struct {
+	stream captureSender
+	batch  uint32
+	// forwarded is the number of packets forwarded since credits were granted last.
+	forwarded uint32
+	log       *zap.Logger
+}
+
+// newCreditGranter creates a creditGranter and grants the initial window of credits to the agent. The agent can
+// send window packets before it has to wait for further credits.
+func newCreditGranter(stream captureSender, window int, log *zap.Logger) *creditGranter {
+	g := &creditGranter{
+		stream: stream,
+		batch:  uint32(max(window/2, 1)), //nolint:gosec // buffer sizes are well within uint32
+		log:    log,
+	}
+	g.grant(uint32(max(window, 1))) //nolint:gosec // buffer sizes are well within uint32
+	return g
+}
+
+// packetForwarded records that a packet of the agent has been forwarded and grants credits once a batch is full.
+func (g *creditGranter) packetForwarded() {
+	g.forwarded++
+	if g.forwarded < g.batch {
+		return
+	}
+
+	g.grant(g.forwarded)
+	g.forwarded = 0
+}
+
+func (g *creditGranter) grant(n uint32) {
+	err := g.stream.Send(&AgentRequest{Payload: &AgentRequest_Credit{Credit: &Credit{Packets: n}}})
+	if err != nil {
+		// the stream has ended, the error is reported when reading from it.
+		g.log.Debug("unable to grant credits to agent", zap.Error(err))
+	}
+}
+
+// syncStream allows to send requests to a capture stream from multiple go routines, which gRPC streams do not
+// support on their own. Credits, the stop request and closing the stream are sent from different go routines.
+type syncStream struct {
+	captureStream
+	mu sync.Mutex
+}
+
+func (s *syncStream) Send(req *AgentRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.captureStream.Send(req)
+}
+
+func (s *syncStream) CloseSend() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.captureStream.CloseSend()
+}
@@ -0,0 +1,312 @@
+package pcap
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCreditGate(t *testing.T) {
+	var unlimited *creditGate
+	if !unlimited.tryAcquire() {
+		t.Errorf("tryAcquire() nil gate must not limit packets")
+	}
+
+	if newCreditGate(FlowControl_THRESHOLD) != nil {
+		t.Errorf("newCreditGate() expected no gate for threshold flow control")
+	}
+
+	credits := newCreditGate(FlowControl_CREDIT)
+	if credits.tryAcquire() {
+		t.Errorf("tryAcquire() succeeded without credits")
+	}
+
+	credits.grant(2)
+	select {
+	case <-credits.granted:
+	default:
+		t.Errorf("grant() did not signal the granted credits")
+	}
+	if !credits.tryAcquire() || !credits.tryAcquire() {
+		t.Errorf("tryAcquire() failed with granted credits")
+	}
+	if credits.tryAcquire() {
+		t.Errorf("tryAcquire() succeeded after all credits were consumed")
+	}
+
+	credits.open()
+	if !credits.tryAcquire() {
+		t.Errorf("tryAcquire() failed after the gate was opened")
+	}
+}
+
+func TestThresholdDiscarder(t *testing.T) {
+	discarder := newThresholdDiscarder(BufferConf{Size: 10, UpperLimit: 8, LowerLimit: 2}, "test")
+	now := time.Unix(1000, 0)
+	discarder.now = func() time.Time { return now }
+
+	packet := newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, "router/1", 1)
+	other := newPacketResponse([]byte("ABCDE"), gopacket.CaptureInfo{}, "router/2", 1)
+	message := newMessageResponse(MessageType_STATISTICS, "statistics", "router/1")
+
+	if got := discarder.filter(packet, 5); len(got) != 1 || got[0] != packet {
+		t.Errorf("filter() expected the packet to be forwarded, got %v", got)
+	}
+
+	got := discarder.filter(packet, 9)
+	if len(got) != 1 || got[0].GetMessage().GetType() != MessageType_CONGESTED {
+		t.Errorf("filter() expected the packet to be replaced by a CONGESTED message, got %v", got)
+	}
+	if got := discarder.filter(other, 5); len(got) != 0 {
+		t.Errorf("filter() expected the packet to be discarded, got %v", got)
+	}
+	if got := discarder.filter(message, 5); len(got) != 1 || got[0] != message {
+		t.Errorf("filter() expected the message to be forwarded while discarding, got %v", got)
+	}
+
+	now = now.Add(1500 * time.Millisecond)
+	got = discarder.filter(packet, 2)
+	if len(got) != 2 || got[1] != packet {
+		t.Fatalf("filter() expected the summary and the packet once discarding stopped, got %v", got)
+	}
+	summary := got[0].GetMessage()
+	if summary.GetType() != MessageType_CONGESTED || !strings.Contains(summary.GetMessage(), "discarded 2 packets (8B) in 1.5s") {
+		t.Errorf("filter() expected the discarded packets of the episode to be reported, got %v", summary)
+	}
+	expected := []*OriginDiscards{{Origin: "router/1", Packets: 1, Bytes: 3}, {Origin: "router/2", Packets: 1, Bytes: 5}}
+	if !equalDiscards(summary.GetDiscards().GetOrigins(), expected) || summary.GetDiscards().GetTotal() {
+		t.Errorf("filter() expected discards %v, got %v", expected, summary.GetDiscards())
+	}
+	if summary.GetDiscards().GetDuration().AsDuration() != 1500*time.Millisecond {
+		t.Errorf("filter() expected the episode duration 1.5s, got %v", summary.GetDiscards().GetDuration())
+	}
+	if discarder.summary() != nil {
+		t.Errorf("summary() expected the episode to be reset")
+	}
+
+	// second episode, which is still ongoing when the totals are requested.
+	discarder.filter(packet, 9)
+	now = now.Add(time.Second)
+
+	totals := discarder.totals()
+	if len(totals) != 2 {
+		t.Fatalf("totals() expected the summary of the ongoing episode and the totals, got %v", totals)
+	}
+	if !strings.Contains(totals[0].GetMessage().GetMessage(), "discarded 1 packets (3B) in 1s") {
+		t.Errorf("totals() expected the summary of the ongoing episode, got %v", totals[0].GetMessage())
+	}
+	total := totals[1].GetMessage()
+	if !total.GetDiscards().GetTotal() || !strings.Contains(total.GetMessage(), "discarded 3 packets (11B) in total") {
+		t.Errorf("totals() expected the totals of the capture, got %v", total)
+	}
+	expected = []*OriginDiscards{{Origin: "router/1", Packets: 2, Bytes: 6}, {Origin: "router/2", Packets: 1, Bytes: 5}}
+	if !equalDiscards(total.GetDiscards().GetOrigins(), expected) {
+		t.Errorf("totals() expected discards %v, got %v", expected, total.GetDiscards().GetOrigins())
+	}
+}
+
+func TestThresholdDiscarderWithoutDiscards(t *testing.T) {
+	discarder := newThresholdDiscarder(BufferConf{Size: 10, UpperLimit: 8, LowerLimit: 2}, "test")
+
+	if got := discarder.totals(); len(got) != 0 {
+		t.Errorf("totals() expected nothing to be reported without discards, got %v", got)
+	}
+}
+
+func equalDiscards(got []*OriginDiscards, expected []*OriginDiscards) bool {
+	return slices.EqualFunc(got, expected, func(a, b *OriginDiscards) bool {
+		return proto.Equal(a, b)
+	})
+}
+
+// TestForwardToStreamCredits checks that packets are only forwarded with credits, while messages do not require
+// credits.
+func TestForwardToStreamCredits(t *testing.T) {
+	src := make(chan *CaptureResponse, 10)
+	stream := &recordingSender{sent: make(chan *CaptureResponse, 10)}
+	credits := newCreditGate(FlowControl_CREDIT)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	forwardToStream(cancel, src, stream, BufferConf{Size: 10, UpperLimit: 8, LowerLimit: 2}, nil, credits, wg, "test")
+
+	src <- newMessageResponse(MessageType_STATISTICS, "statistics", "router/1")
+	src <- newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, "router/1", 1)
+
+	expectSent(t, stream.sent, MessageType_STATISTICS)
+	select {
+	case res := <-stream.sent:
+		t.Fatalf("forwardToStream() forwarded %v without credits", res)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	credits.grant(1)
+	res := <-stream.sent
+	if res.GetPacket() == nil {
+		t.Errorf("forwardToStream() expected the packet once credits were granted, got %v", res)
+	}
+
+	close(src)
+	<-ctx.Done()
+	wg.Wait()
+}
+
+// TestForwardToStreamCreditsCongested checks that the receiver is informed if the buffer is full while waiting for
+// credits.
+func TestForwardToStreamCreditsCongested(t *testing.T) {
+	src := make(chan *CaptureResponse, 5)
+	for i := 0; i < 5; i++ {
+		src <- newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, "router/1", uint64(i+1))
+	}
+	stream := &recordingSender{sent: make(chan *CaptureResponse, 10)}
+	credits := newCreditGate(FlowControl_CREDIT)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	forwardToStream(cancel, src, stream, BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, nil, credits, wg, "test")
+
+	expectSent(t, stream.sent, MessageType_CONGESTED)
+
+	// once no more credits are granted, e.g. after the stop request, the remaining packets are forwarded.
+	credits.open()
+	expectSent(t, stream.sent, MessageType_CONGESTED)
+	close(src)
+	<-ctx.Done()
+	wg.Wait()
+}
+
+// TestForwardToStreamDiscardTotals checks that the totals of discarded packets are sent before the capture stops
+// due to a limit.
+func TestForwardToStreamDiscardTotals(t *testing.T) {
+	src := make(chan *CaptureResponse, 5)
+	for i := 0; i < 5; i++ {
+		src <- newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, "router/1", uint64(i+1))
+	}
+	stream := &recordingSender{sent: make(chan *CaptureResponse, 10)}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	forwardToStream(cancel, src, stream, BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 1}, &CaptureLimits{MaxPackets: 1}, nil, wg, "test")
+
+	// discarding starts at the first packet and ends once the buffer has drained to the lower limit.
+	expectSent(t, stream.sent, MessageType_CONGESTED)
+	expectSent(t, stream.sent, MessageType_CONGESTED)
+	if res := <-stream.sent; res.GetPacket() == nil {
+		t.Errorf("forwardToStream() sent %v, want a packet", res)
+	}
+
+	total := <-stream.sent
+	if total.GetMessage().GetType() != MessageType_CONGESTED || total.GetMessage().GetDiscards().GetOrigins()[0].GetPackets() != 3 {
+		t.Errorf("forwardToStream() sent %v, want the totals of 3 discarded packets", total)
+	}
+	expectSent(t, stream.sent, MessageType_LIMIT_REACHED)
+
+	<-ctx.Done()
+	wg.Wait()
+}
+
+func TestCreditGranter(t *testing.T) {
+	stream := &mockCaptureSender{}
+	granter := newCreditGranter(stream, 10, zap.L())
+
+	for i := 0; i < 12; i++ {
+		granter.packetForwarded()
+	}
+
+	want := []uint32{10, 5, 5}
+	if len(stream.credits) != len(want) {
+		t.Fatalf("granted credits %v, want %v", stream.credits, want)
+	}
+	for i := range want {
+		if stream.credits[i] != want[i] {
+			t.Errorf("granted credits %v, want %v", stream.credits, want)
+		}
+	}
+}
+
+func TestStopCmdCredits(t *testing.T) {
+	credits := newCreditGate(FlowControl_CREDIT)
+	recv := &sequenceRequestReceiver{requests: []*CaptureRequest{
+		{Operation: &CaptureRequest_Credit{Credit: &Credit{Packets: 2}}},
+		MakeStopRequest(),
+	}}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	stopCmd(cancel, recv, credits)
+	<-ctx.Done()
+
+	if cause := context.Cause(ctx); cause != context.Canceled {
+		t.Errorf("stopCmd() expected a normal stop, got %v", cause)
+	}
+
+	// the gate is opened once the stop has been requested, eventually.
+	deadline := time.After(time.Second)
+	for {
+		credits.mu.Lock()
+		available, unlimited := credits.available, credits.unlimited
+		credits.mu.Unlock()
+		if unlimited {
+			if available != 2 {
+				t.Errorf("stopCmd() granted %d credits, want 2", available)
+			}
+			return
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("stopCmd() did not open the credit gate after the stop request")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func expectSent(t *testing.T, sent <-chan *CaptureResponse, want MessageType) {
+	t.Helper()
+
+	select {
+	case res := <-sent:
+		if res.GetMessage().GetType() != want {
+			t.Errorf("forwardToStream() sent %v, want message of type %v", res, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("forwardToStream() did not send a message of type %v", want)
+	}
+}
+
+type recordingSender struct {
+	sent chan *CaptureResponse
+}
+
+func (r *recordingSender) Send(res *CaptureResponse) error {
+	r.sent <- res
+	return nil
+}
+
+type mockCaptureSender struct {
+	credits []uint32
+}
+
+func (m *mockCaptureSender) Send(req *AgentRequest) error {
+	m.credits = append(m.credits, req.GetCredit().GetPackets())
+	return nil
+}
+
+// sequenceRequestReceiver returns the requests in order.
+type sequenceRequestReceiver struct {
+	requests []*CaptureRequest
+}
+
+func (s *sequenceRequestReceiver) Recv() (*CaptureRequest, error) {
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
@@ -0,0 +1,196 @@
+package pcap
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// healthWatchInterval is the interval in which HealthServer.Watch re-evaluates the health of the server.
+	healthWatchInterval = time.Second
+	// resolverHealthInterval is the interval in which the health of the resolvers of the API is refreshed.
+	resolverHealthInterval = 10 * time.Second
+	// resolverHealthTimeout bounds the health check of a single resolver. Resolvers that do not respond in time are
+	// considered unhealthy.
+	resolverHealthTimeout = 5 * time.Second
+)
+
+// HealthServer implements the standard gRPC health checking protocol (grpc.health.v1.Health) for pcap-api and
+// pcap-agent, so load balancers and probes can use it instead of the custom Status RPC.
+//
+// The serving status is not set explicitly but evaluated on each request using healthy, which must not block. Once
+// stopped is closed, watchers are informed immediately.
+type HealthServer struct {
+	// service is the name of the gRPC service that is checked, e.g. pcap.API.
+	service string
+	healthy func() bool
+	stopped <-chan struct{}
+
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+// NewHealthServer creates a HealthServer for service. The empty service name, which refers to the server as a whole,
+// is supported as well.
+func NewHealthServer(service string, healthy func() bool, stopped <-chan struct{}) *HealthServer {
+	return &HealthServer{
+		service: service,
+		healthy: healthy,
+		stopped: stopped,
+	}
+}
+
+// NewAPIHealthServer creates a HealthServer for the api, which is serving while it has healthy resolvers and is not
+// draining. The health of the resolvers is read from the state refreshed by API.WatchResolverHealth, so health checks
+// do not query the BOSH director or Cloud Controller.
+func NewAPIHealthServer(api *API) *HealthServer {
+	return NewHealthServer(API_ServiceDesc.ServiceName, api.healthy, api.done)
+}
+
+// NewAgentHealthServer creates a HealthServer for the agent, which is serving while it is not draining.
+func NewAgentHealthServer(agent *Agent) *HealthServer {
+	return NewHealthServer(Agent_ServiceDesc.ServiceName, agent.healthy, agent.done)
+}
+
+// Check returns the current serving status. Returns NotFound for unknown services.
+func (h *HealthServer) Check(_ context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if !h.knownService(req.GetService()) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.GetService())
+	}
+
+	return &grpc_health_v1.HealthCheckResponse{Status: h.servingStatus()}, nil
+}
+
+// Watch sends the current serving status and then any change of it until the client cancels the stream. Unknown
+// services are reported as SERVICE_UNKNOWN without terminating the stream, as required by the health checking
+// protocol.
+func (h *HealthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	if !h.knownService(req.GetService()) {
+		err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN})
+		if err != nil {
+			return err
+		}
+		<-stream.Context().Done()
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	stopped := h.stopped
+	last := grpc_health_v1.HealthCheckResponse_UNKNOWN
+	for {
+		current := h.servingStatus()
+		if current != last {
+			err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: current})
+			if err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-stopped:
+			// re-evaluate immediately, but only once as the channel stays closed.
+			stopped = nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthServer) servingStatus() grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if h.healthy() {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
+
+func (h *HealthServer) knownService(service string) bool {
+	return service == "" || service == h.service
+}
+
+// WatchResolverHealth refreshes the health of the registered resolvers every resolverHealthInterval in the background
+// until the api is stopped. The resolvers are checked concurrently, each bounded by resolverHealthTimeout.
+func (api *API) WatchResolverHealth() {
+	go func() {
+		ticker := time.NewTicker(resolverHealthInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-api.done:
+				return
+			case <-ticker.C:
+				api.refreshResolverHealth(resolverHealthTimeout)
+			}
+		}
+	}()
+}
+
+// refreshResolverHealth checks the health of all registered resolvers and stores the result. The result is discarded
+// if the resolvers have been changed in the meantime, as the change triggers a refresh of its own.
+func (api *API) refreshResolverHealth(timeout time.Duration) {
+	api.mu.RLock()
+	resolvers := maps.Clone(api.resolvers)
+	generation := api.resolverGeneration
+	api.mu.RUnlock()
+
+	health := checkResolverHealth(resolvers, timeout)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if generation == api.resolverGeneration {
+		api.resolverHealth = health
+	}
+}
+
+// resolverHealthList returns the health of each registered resolver as of the last refresh.
+func (api *API) resolverHealthList() map[string]bool {
+	api.mu.RLock()
+	defer api.mu.RUnlock()
+
+	health := make(map[string]bool, len(api.resolvers))
+	for name := range api.resolvers {
+		health[name] = api.resolverHealth[name]
+	}
+	return health
+}
+
+// checkResolverHealth checks the health of resolvers concurrently. Resolvers that do not respond within timeout are
+// reported as unhealthy, their checks are left to finish in the background.
+func checkResolverHealth(resolvers map[string]AgentResolver, timeout time.Duration) map[string]bool {
+	type result struct {
+		name    string
+		healthy bool
+	}
+
+	// buffered, so checks that time out do not block.
+	results := make(chan result, len(resolvers))
+	health := make(map[string]bool, len(resolvers))
+	for name, resolver := range resolvers {
+		health[name] = false
+		go func() {
+			results <- result{name: name, healthy: resolver.Healthy()}
+		}()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for range resolvers {
+		select {
+		case res := <-results:
+			health[res.name] = res.healthy
+		case <-timer.C:
+			return health
+		}
+	}
+	return health
+}
@@ -0,0 +1,241 @@
+package pcap
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestAPIHealthCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		resolver   AgentResolver
+		draining   bool
+		service    string
+		wantStatus grpc_health_v1.HealthCheckResponse_ServingStatus
+		wantCode   codes.Code
+	}{
+		{name: "serving", resolver: HealthyResolver{}, wantStatus: grpc_health_v1.HealthCheckResponse_SERVING},
+		{name: "serving api service", resolver: HealthyResolver{}, service: "pcap.API", wantStatus: grpc_health_v1.HealthCheckResponse_SERVING},
+		{name: "draining", resolver: HealthyResolver{}, draining: true, wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{name: "no resolver", wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{name: "unhealthy resolver", resolver: unhealthyResolver{}, wantStatus: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{name: "unknown service", resolver: HealthyResolver{}, service: "pcap.Agent", wantCode: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "api"}, APIConf{ConcurrentCaptures: 1})
+			if err != nil {
+				t.Fatalf("unexpected error during api creation: %v", err)
+			}
+			if tt.resolver != nil {
+				api.RegisterResolver(tt.resolver)
+			}
+			if tt.draining {
+				api.Stop()
+			}
+
+			got, err := NewAPIHealthServer(api).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Check() code = %v, wantCode %v", status.Code(err), tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("Check() status = %v, wantStatus %v", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAgentHealthCheck(t *testing.T) {
+	agent := NewAgent(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "agent"}, AgentConf{})
+	health := NewAgentHealthServer(agent)
+
+	got, err := health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "pcap.Agent"})
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if got.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("Check() status = %v, want SERVING", got.Status)
+	}
+
+	agent.Stop()
+
+	got, err = health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if got.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Check() status = %v, want NOT_SERVING", got.Status)
+	}
+}
+
+// TestHealthWatchStop checks that watchers are informed as soon as the server is stopped, without waiting for the
+// next re-evaluation.
+func TestHealthWatchStop(t *testing.T) {
+	agent := NewAgent(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "agent"}, AgentConf{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &mockHealthWatchServer{ctx: ctx, responses: make(chan *grpc_health_v1.HealthCheckResponse, 2)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewAgentHealthServer(agent).Watch(&grpc_health_v1.HealthCheckRequest{}, stream)
+	}()
+
+	expectHealthStatus(t, stream.responses, grpc_health_v1.HealthCheckResponse_SERVING, healthWatchInterval/2)
+
+	agent.Stop()
+	expectHealthStatus(t, stream.responses, grpc_health_v1.HealthCheckResponse_NOT_SERVING, healthWatchInterval/2)
+
+	cancel()
+	err := <-done
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Watch() code = %v, want %v", status.Code(err), codes.Canceled)
+	}
+}
+
+func TestHealthWatchUnknownService(t *testing.T) {
+	agent := NewAgent(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "agent"}, AgentConf{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &mockHealthWatchServer{ctx: ctx, responses: make(chan *grpc_health_v1.HealthCheckResponse, 1)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewAgentHealthServer(agent).Watch(&grpc_health_v1.HealthCheckRequest{Service: "pcap.API"}, stream)
+	}()
+
+	expectHealthStatus(t, stream.responses, grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, healthWatchInterval/2)
+
+	// the stream is kept open until the client cancels it.
+	select {
+	case err := <-done:
+		t.Fatalf("Watch() returned before the client canceled the stream: %v", err)
+	default:
+	}
+
+	cancel()
+	err := <-done
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Watch() code = %v, want %v", status.Code(err), codes.Canceled)
+	}
+}
+
+func expectHealthStatus(t *testing.T, responses <-chan *grpc_health_v1.HealthCheckResponse, want grpc_health_v1.HealthCheckResponse_ServingStatus, timeout time.Duration) {
+	t.Helper()
+
+	select {
+	case res := <-responses:
+		if res.Status != want {
+			t.Errorf("Watch() status = %v, want %v", res.Status, want)
+		}
+	case <-time.After(timeout):
+		t.Fatalf("Watch() did not send %v within %v", want, timeout)
+	}
+}
+
+type mockHealthWatchServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *grpc_health_v1.HealthCheckResponse
+}
+
+func (m *mockHealthWatchServer) Send(res *grpc_health_v1.HealthCheckResponse) error {
+	m.responses <- res
+	return nil
+}
+
+func (m *mockHealthWatchServer) Context() context.Context {
+	return m.ctx
+}
+
+type unhealthyResolver struct {
+	HealthyResolver
+}
+
+func (unhealthyResolver) Healthy() bool {
+	return false
+}
+
+// countingResolver counts the health checks, which take delay and report healthy.
+type countingResolver struct {
+	HealthyResolver
+	checks  atomic.Int32
+	healthy atomic.Bool
+	delay   time.Duration
+}
+
+func (c *countingResolver) Healthy() bool {
+	c.checks.Add(1)
+	time.Sleep(c.delay)
+	return c.healthy.Load()
+}
+
+// TestAPIHealthCheckCached checks that health checks report the health of the last refresh without checking the
+// resolvers themselves.
+func TestAPIHealthCheckCached(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "api"}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("unexpected error during api creation: %v", err)
+	}
+	resolver := &countingResolver{}
+	resolver.healthy.Store(true)
+	api.RegisterResolver(resolver)
+
+	health := NewAPIHealthServer(api)
+	for i := 0; i < 10; i++ {
+		got, err := health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Check() unexpected error: %v", err)
+		}
+		if got.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+			t.Errorf("Check() status = %v, want SERVING", got.Status)
+		}
+	}
+
+	if checks := resolver.checks.Load(); checks != 1 {
+		t.Errorf("expected the resolver to be checked once on registration, got %d checks", checks)
+	}
+
+	resolver.healthy.Store(false)
+	api.refreshResolverHealth(resolverHealthTimeout)
+
+	got, err := health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check() unexpected error: %v", err)
+	}
+	if got.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Check() status = %v after the resolver became unhealthy, want NOT_SERVING", got.Status)
+	}
+}
+
+func TestCheckResolverHealthTimeout(t *testing.T) {
+	slow := &countingResolver{delay: time.Second}
+	slow.healthy.Store(true)
+
+	start := time.Now()
+	health := checkResolverHealth(map[string]AgentResolver{"slow": slow, "healthy": HealthyResolver{}, "unhealthy": unhealthyResolver{}}, 50*time.Millisecond)
+
+	if elapsed := time.Since(start); elapsed >= slow.delay {
+		t.Errorf("checkResolverHealth() took %v, expected it to be bounded by the timeout", elapsed)
+	}
+
+	expected := map[string]bool{"slow": false, "healthy": true, "unhealthy": false}
+	for name, healthy := range expected {
+		if health[name] != healthy {
+			t.Errorf("checkResolverHealth() health of %s = %t, want %t", name, health[name], healthy)
+		}
+	}
+}
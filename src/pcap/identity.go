@@ -0,0 +1,106 @@
+package pcap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// anonymousIdentity is the identity of clients that neither present a token nor a client certificate.
+const anonymousIdentity = "anonymous"
+
+// captureIdentity returns the identity of the client that requested a capture. The identity is the user of the
+// token in the request, its `user_name` or `sub` claim. Without a token, it is the subject of the client certificate.
+//
+// The token is not verified, it must have been authenticated by resolving the request.
+func captureIdentity(ctx context.Context, request *EndpointRequest) string {
+	token := request.GetBosh().GetToken()
+	if token == "" {
+		token = request.GetCf().GetToken()
+	}
+
+	if user := tokenUser(token); user != "" {
+		return user
+	}
+
+	if subject := certificateSubject(ctx); subject != "" {
+		return subject
+	}
+
+	return anonymousIdentity
+}
+
+// tokenUser returns the user of the JWT token, or an empty string if there is none.
+func tokenUser(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+	if err != nil {
+		return ""
+	}
+
+	for _, claim := range []string{"user_name", "sub"} {
+		if user, ok := claims[claim].(string); ok && user != "" {
+			return user
+		}
+	}
+	return ""
+}
+
+// certificateSubject returns the subject of the verified client certificate of the connection in ctx, or an empty
+// string if there is none.
+func certificateSubject(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.String()
+}
+
+// identityCaptures counts the running captures per identity and enforces an upper bound for each identity.
+type identityCaptures struct {
+	mu      sync.Mutex
+	running map[string]int32
+	// limit is the maximum number of captures per identity, zero means no limit.
+	limit int32
+}
+
+func newIdentityCaptures(limit int32) *identityCaptures {
+	return &identityCaptures{running: make(map[string]int32), limit: limit}
+}
+
+// acquire counts a capture for identity, if the limit of the identity has not been reached. Returns the number of
+// captures of identity including the new one. The returned function must be called once the capture has ended, it
+// is nil if the limit has been reached.
+func (c *identityCaptures) acquire(identity string) (int32, func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	running := c.running[identity]
+	if c.limit > 0 && running >= c.limit {
+		return running, nil
+	}
+
+	c.running[identity] = running + 1
+	return running + 1, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.running[identity]--
+		if c.running[identity] <= 0 {
+			delete(c.running, identity)
+		}
+	}
+}
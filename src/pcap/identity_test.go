@@ -0,0 +1,106 @@
+package pcap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return token
+}
+
+func TestCaptureIdentity(t *testing.T) {
+	certCtx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "pcap-client"}}}},
+	}}})
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		request  *EndpointRequest
+		expected string
+	}{
+		{
+			name:     "bosh token with user name",
+			ctx:      context.Background(),
+			request:  &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{Token: signedToken(t, jwt.MapClaims{"user_name": "alice", "sub": "1234"})}}},
+			expected: "alice",
+		},
+		{
+			name:     "cf token with subject",
+			ctx:      context.Background(),
+			request:  &EndpointRequest{Request: &EndpointRequest_Cf{Cf: &CloudfoundryRequest{Token: signedToken(t, jwt.MapClaims{"sub": "1234"})}}},
+			expected: "1234",
+		},
+		{
+			name:     "invalid token with client certificate",
+			ctx:      certCtx,
+			request:  &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{Token: "garbage"}}},
+			expected: "CN=pcap-client",
+		},
+		{
+			name:     "no token or client certificate",
+			ctx:      context.Background(),
+			request:  &EndpointRequest{Request: &EndpointRequest_Bosh{Bosh: &BoshRequest{}}},
+			expected: anonymousIdentity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := captureIdentity(tt.ctx, tt.request)
+			if got != tt.expected {
+				t.Errorf("captureIdentity() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIdentityCaptures(t *testing.T) {
+	captures := newIdentityCaptures(2) //nolint:mnd // two captures per identity
+
+	_, releaseAlice1 := captures.acquire("alice")
+	running, releaseAlice2 := captures.acquire("alice")
+	if releaseAlice2 == nil || running != 2 {
+		t.Fatalf("acquire() expected the second capture of alice to be counted, got %d", running)
+	}
+
+	running, release := captures.acquire("alice")
+	if release != nil || running != 2 {
+		t.Errorf("acquire() expected the limit of alice to be reached with 2 captures, got %d", running)
+	}
+
+	_, releaseBob := captures.acquire("bob")
+	if releaseBob == nil {
+		t.Errorf("acquire() expected bob not to be limited by the captures of alice")
+	}
+
+	releaseAlice1()
+	_, release = captures.acquire("alice")
+	if release == nil {
+		t.Errorf("acquire() expected a released capture to be available again")
+	}
+}
+
+func TestIdentityCapturesWithoutLimit(t *testing.T) {
+	captures := newIdentityCaptures(0)
+
+	for i := 0; i < 10; i++ {
+		_, release := captures.acquire("alice")
+		if release == nil {
+			t.Fatalf("acquire() expected no limit, got limited after %d captures", i)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package pcap
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcap"
+	"google.golang.org/grpc/credentials"
+)
+
+// Flags of network interfaces as defined by libpcap in pcap/pcap.h.
+const (
+	pcapIfLoopback                     = 0x00000001
+	pcapIfUp                           = 0x00000002
+	pcapIfRunning                      = 0x00000004
+	pcapIfWireless                     = 0x00000008
+	pcapIfConnectionStatus             = 0x00000030
+	pcapIfConnectionStatusConnected    = 0x00000010
+	pcapIfConnectionStatusDisconnected = 0x00000020
+)
+
+// linkTypeSnapLen is the snaplen used to open a device only to determine its link type.
+const linkTypeSnapLen = 65535
+
+var (
+	// findAllDevs returns all devices libpcap is able to capture on. It is a variable to allow mocking in tests.
+	findAllDevs = pcap.FindAllDevs
+	// deviceLinkType returns the link type of the named device. It is a variable to allow mocking in tests.
+	deviceLinkType = openDeviceLinkType
+)
+
+// listInterfaces returns all devices that can be captured on with their addresses, flags and link type.
+//
+// Devices that can not be opened to determine their link type are still listed, without a link type.
+func listInterfaces() ([]*NetworkInterface, error) {
+	devices, err := findAllDevs()
+	if err != nil {
+		return nil, fmt.Errorf("find devices: %w", err)
+	}
+
+	interfaces := make([]*NetworkInterface, 0, len(devices))
+	for _, device := range devices {
+		linkType := ""
+		lt, err := deviceLinkType(device.Name)
+		if err == nil {
+			linkType = lt.String()
+		}
+
+		interfaces = append(interfaces, &NetworkInterface{
+			Name:        device.Name,
+			Description: device.Description,
+			Addresses:   interfaceAddresses(device.Addresses),
+			Flags:       interfaceFlags(device.Flags),
+			LinkType:    linkType,
+		})
+	}
+
+	return interfaces, nil
+}
+
+// openDeviceLinkType opens the device without promiscuous mode to read its link type.
+func openDeviceLinkType(name string) (layers.LinkType, error) {
+	handle, err := pcap.OpenLive(name, linkTypeSnapLen, false, readPacketTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer handle.Close()
+
+	return handle.LinkType(), nil
+}
+
+// interfaceAddresses formats addrs in CIDR notation. Addresses without netmask are formatted as plain IP.
+func interfaceAddresses(addrs []pcap.InterfaceAddress) []string {
+	addresses := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if addr.Netmask == nil {
+			addresses = append(addresses, addr.IP.String())
+			continue
+		}
+		addresses = append(addresses, (&net.IPNet{IP: addr.IP, Mask: addr.Netmask}).String())
+	}
+
+	return addresses
+}
+
+// interfaceFlags converts the libpcap interface flags to human-readable names.
+func interfaceFlags(flags uint32) []string {
+	var names []string
+
+	if flags&pcapIfUp != 0 {
+		names = append(names, "up")
+	}
+	if flags&pcapIfRunning != 0 {
+		names = append(names, "running")
+	}
+	if flags&pcapIfLoopback != 0 {
+		names = append(names, "loopback")
+	}
+	if flags&pcapIfWireless != 0 {
+		names = append(names, "wireless")
+	}
+
+	switch flags & pcapIfConnectionStatus {
+	case pcapIfConnectionStatusConnected:
+		names = append(names, "connected")
+	case pcapIfConnectionStatusDisconnected:
+		names = append(names, "disconnected")
+	}
+
+	return names
+}
+
+// interfaceLister lists the network interfaces of a single pcap-agent.
+type interfaceLister func(context.Context, AgentEndpoint, credentials.TransportCredentials) ([]*NetworkInterface, error)
+
+// listAgentInterfaces requests the network interfaces of the agent at target, using the connection from the pool.
+func (api *API) listAgentInterfaces(ctx context.Context, target AgentEndpoint, creds credentials.TransportCredentials) ([]*NetworkInterface, error) {
+	cc, release, err := api.agentConns.get(target, creds)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultStatusTimeout)
+	defer cancel()
+
+	res, err := NewAgentClient(cc).ListInterfaces(ctx, &ListInterfacesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list interfaces of '%s': %w", target, err)
+	}
+
+	return res.GetInterfaces(), nil
+}
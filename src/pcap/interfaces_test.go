@@ -0,0 +1,116 @@
+package pcap
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcap"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestInterfaceFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags uint32
+		want  []string
+	}{
+		{name: "no flags", flags: 0, want: nil},
+		{name: "up and running", flags: pcapIfUp | pcapIfRunning, want: []string{"up", "running"}},
+		{name: "loopback", flags: pcapIfUp | pcapIfRunning | pcapIfLoopback, want: []string{"up", "running", "loopback"}},
+		{name: "connected", flags: pcapIfUp | pcapIfConnectionStatusConnected, want: []string{"up", "connected"}},
+		{name: "disconnected wireless", flags: pcapIfWireless | pcapIfConnectionStatusDisconnected, want: []string{"wireless", "disconnected"}},
+		{name: "connection status not applicable", flags: pcapIfConnectionStatus, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := interfaceFlags(tt.flags)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interfaceFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceAddresses(t *testing.T) {
+	addrs := []pcap.InterfaceAddress{
+		{IP: net.IPv4(10, 0, 1, 2), Netmask: net.CIDRMask(24, 32)},
+		{IP: net.ParseIP("fe80::1"), Netmask: net.CIDRMask(64, 128)},
+		{IP: net.IPv4(192, 0, 2, 1)},
+	}
+
+	want := []string{"10.0.1.2/24", "fe80::1/64", "192.0.2.1"}
+
+	got := interfaceAddresses(addrs)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interfaceAddresses() = %v, want %v", got, want)
+	}
+}
+
+func TestListInterfaces(t *testing.T) {
+	devices := []pcap.Interface{
+		{Name: "eth0", Flags: pcapIfUp | pcapIfRunning, Addresses: []pcap.InterfaceAddress{{IP: net.IPv4(10, 0, 1, 2), Netmask: net.CIDRMask(24, 32)}}},
+		{Name: "lo", Flags: pcapIfUp | pcapIfRunning | pcapIfLoopback},
+		{Name: "any", Description: "Pseudo-device that captures on all interfaces", Flags: pcapIfUp | pcapIfRunning},
+		{Name: "nflog", Description: "Linux netfilter log (NFLOG) interface"},
+	}
+
+	findAllDevs = func() ([]pcap.Interface, error) {
+		return devices, nil
+	}
+	deviceLinkType = func(name string) (layers.LinkType, error) {
+		switch name {
+		case "eth0":
+			return layers.LinkTypeEthernet, nil
+		case "lo":
+			return layers.LinkTypeEthernet, nil
+		case "any":
+			return layers.LinkTypeLinuxSLL, nil
+		default:
+			return 0, errors.New("permission denied")
+		}
+	}
+	defer func() {
+		findAllDevs = pcap.FindAllDevs
+		deviceLinkType = openDeviceLinkType
+	}()
+
+	want := []*NetworkInterface{
+		{Name: "eth0", Addresses: []string{"10.0.1.2/24"}, Flags: []string{"up", "running"}, LinkType: layers.LinkTypeEthernet.String()},
+		{Name: "lo", Flags: []string{"up", "running", "loopback"}, LinkType: layers.LinkTypeEthernet.String()},
+		{Name: "any", Description: "Pseudo-device that captures on all interfaces", Flags: []string{"up", "running"}, LinkType: layers.LinkTypeLinuxSLL.String()},
+		{Name: "nflog", Description: "Linux netfilter log (NFLOG) interface"},
+	}
+
+	got, err := listInterfaces()
+	if err != nil {
+		t.Fatalf("listInterfaces() unexpected error: %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("listInterfaces() returned %d interfaces, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if !proto.Equal(got[i], want[i]) {
+			t.Errorf("listInterfaces()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestListInterfacesError(t *testing.T) {
+	findAllDevs = func() ([]pcap.Interface, error) {
+		return nil, errors.New("no permission")
+	}
+	defer func() {
+		findAllDevs = pcap.FindAllDevs
+	}()
+
+	_, err := listInterfaces()
+	if err == nil {
+		t.Errorf("listInterfaces() expected error")
+	}
+}
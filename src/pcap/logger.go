@@ -28,7 +28,7 @@ func init() {
 		OutputPaths:      []string{"stderr"},
 		ErrorOutputPaths: []string{"stderr"},
 	}
-	zap.ReplaceGlobals(zap.Must(zapConfig.Build()))
+	zap.ReplaceGlobals(zap.Must(zapConfig.Build(zap.WrapCore(NewRedactingCore))))
 }
 
 func SetLogLevel(log *zap.Logger, logLevel string) {
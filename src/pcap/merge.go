@@ -0,0 +1,225 @@
+package pcap
+
+import (
+	"container/heap"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// heldPacket is a packet response that is held back by orderByTimestamp.
+type heldPacket struct {
+	res       *CaptureResponse
+	origin    string
+	timestamp time.Time
+	// arrival is the order in which the packets arrived, it breaks ties between packets with the same timestamp.
+	arrival uint64
+	// deadline is the time at which the packet must be released at the latest.
+	deadline time.Time
+	released bool
+}
+
+// packetHeap orders the first held packet of each origin by its timestamp, the earliest packet is at the top.
+type packetHeap []*heldPacket
+
+func (h packetHeap) Len() int {
+	return len(h)
+}
+
+func (h packetHeap) Less(i, j int) bool {
+	if h[i].timestamp.Equal(h[j].timestamp) {
+		return h[i].arrival < h[j].arrival
+	}
+	return h[i].timestamp.Before(h[j].timestamp)
+}
+
+func (h packetHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *packetHeap) Push(x any) {
+	*h = append(*h, x.(*heldPacket)) //nolint:errcheck // only heldPacket is pushed
+}
+
+func (h *packetHeap) Pop() any {
+	old := *h
+	n := len(old)
+	p := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return p
+}
+
+// reorderBuffer holds back packets to release them in the order of their timestamps. No packet is held back longer
+// than window and at most maxBytes of packet data are held back at the same time.
+//
+// The packets of an origin are always released in the order they arrived, even if their timestamps are not in
+// order, e.g. because the agent captures on several devices. Otherwise packets would be released out of the order of
+// their sequence numbers, which clients use to detect lost packets.
+type reorderBuffer struct {
+	window   time.Duration
+	maxBytes int
+
+	// packets holds the first held packet of each origin.
+	packets packetHeap
+	// origins holds the packets of each origin in the order they arrived, the first one is in packets.
+	origins map[string][]*heldPacket
+	// arrivals holds the packets in the order they arrived. Packets that have been released are skipped.
+	arrivals []*heldPacket
+	bytes    int
+	count    uint64
+	// last is the timestamp of the last released packet. Packets before it are late.
+	last time.Time
+}
+
+func newReorderBuffer(window time.Duration, maxBytes int) *reorderBuffer {
+	return &reorderBuffer{window: window, maxBytes: maxBytes, origins: make(map[string][]*heldPacket)}
+}
+
+// add holds back the packet response res. Returns false if res is late, i.e. a later packet has already been
+// released and no packet of its origin is held back, in which case it is not held back. Late packets of origins
+// with held packets are held back behind them.
+func (b *reorderBuffer) add(res *CaptureResponse, now time.Time) bool {
+	origin := res.GetPacket().GetOrigin()
+	timestamp := res.GetPacket().GetTimestamp().AsTime()
+	held := b.origins[origin]
+	if len(held) == 0 && timestamp.Before(b.last) {
+		return false
+	}
+
+	b.count++
+	p := &heldPacket{res: res, origin: origin, timestamp: timestamp, arrival: b.count, deadline: now.Add(b.window)}
+	if len(held) == 0 {
+		heap.Push(&b.packets, p)
+	}
+	b.origins[origin] = append(held, p)
+	b.arrivals = append(b.arrivals, p)
+	b.bytes += len(res.GetPacket().GetData())
+	return true
+}
+
+// release returns the packets that are due in the order of their timestamps. Packets are due if they, or a
+// packet with a later timestamp or a later packet of the same origin, have been held back for the window, or if too
+// much data is held back.
+func (b *reorderBuffer) release(now time.Time) []*CaptureResponse {
+	var due []*CaptureResponse
+	for b.packets.Len() > 0 && (b.bytes > b.maxBytes || !b.nextDeadline().After(now)) {
+		due = append(due, b.pop())
+	}
+	return due
+}
+
+// flush returns all held packets in the order of their timestamps.
+func (b *reorderBuffer) flush() []*CaptureResponse {
+	due := make([]*CaptureResponse, 0, b.packets.Len())
+	for b.packets.Len() > 0 {
+		due = append(due, b.pop())
+	}
+	return due
+}
+
+// nextDeadline returns the deadline of the packet that has been held back the longest. Must only be called if
+// packets are held back.
+func (b *reorderBuffer) nextDeadline() time.Time {
+	for b.arrivals[0].released {
+		b.arrivals[0] = nil
+		b.arrivals = b.arrivals[1:]
+	}
+	return b.arrivals[0].deadline
+}
+
+func (b *reorderBuffer) pop() *CaptureResponse {
+	p := heap.Pop(&b.packets).(*heldPacket) //nolint:errcheck // only heldPacket is pushed
+	p.released = true
+	b.bytes -= len(p.res.GetPacket().GetData())
+	if p.timestamp.After(b.last) {
+		b.last = p.timestamp
+	}
+
+	// the next packet of the origin takes its place.
+	held := b.origins[p.origin]
+	held[0] = nil
+	held = held[1:]
+	if len(held) == 0 {
+		delete(b.origins, p.origin)
+	} else {
+		b.origins[p.origin] = held
+		heap.Push(&b.packets, held[0])
+	}
+	return p.res
+}
+
+// orderByTimestamp reads the responses of all targets from src and forwards them to the returned channel, with
+// the packets reordered by their timestamps. Packets are held back for window to wait for earlier packets of other
+// targets. Messages are forwarded immediately.
+//
+// The packets of each origin are forwarded in the order they arrived. Packets that arrive after a later packet has
+// already been released are forwarded immediately and counted as late, unless packets of their origin are held
+// back. The returned channel is closed once src is closed and all held packets have been forwarded.
+func orderByTimestamp(src <-chan *CaptureResponse, window time.Duration, maxBytes int, bufSize int, log *zap.Logger) <-chan *CaptureResponse {
+	out := make(chan *CaptureResponse, bufSize)
+
+	go func() {
+		defer close(out)
+
+		buf := newReorderBuffer(window, maxBytes)
+		timer := time.NewTimer(window)
+		defer timer.Stop()
+
+		var late uint64
+		defer func() {
+			if late > 0 {
+				log.Info("forwarded late packets out of order", zap.Uint64("late", late), zap.Duration("window", window))
+			}
+		}()
+
+		for {
+			select {
+			case res, ok := <-src:
+				if !ok {
+					for _, p := range buf.flush() {
+						out <- p
+					}
+					return
+				}
+
+				if res.GetPacket() == nil {
+					out <- res
+					continue
+				}
+
+				if !buf.add(res, time.Now()) {
+					late++
+					latePackets.WithLabelValues(res.GetPacket().GetOrigin()).Inc()
+					out <- res
+					continue
+				}
+			case <-timer.C:
+			}
+
+			for _, p := range buf.release(time.Now()) {
+				out <- p
+			}
+
+			// wait for the packet that is held back the longest, the timer is not needed if nothing is held back.
+			next := window
+			if buf.packets.Len() > 0 {
+				next = time.Until(buf.nextDeadline())
+			}
+			resetTimer(timer, next)
+		}
+	}()
+
+	return out
+}
+
+// resetTimer resets timer to d, draining its channel if it has fired already.
+func resetTimer(timer *time.Timer, d time.Duration) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(d)
+}
@@ -0,0 +1,161 @@
+package pcap
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+var mergeStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// timedPacket creates a packet response of origin, captured offset after mergeStart.
+func timedPacket(origin string, offset time.Duration, size int) *CaptureResponse {
+	return newPacketResponse(make([]byte, size), gopacket.CaptureInfo{Timestamp: mergeStart.Add(offset)}, origin, 0)
+}
+
+func TestOrderByTimestamp(t *testing.T) {
+	src := make(chan *CaptureResponse, 10)
+	out := orderByTimestamp(src, 50*time.Millisecond, 1024, 10, zap.L())
+
+	src <- timedPacket("router/1", 3*time.Millisecond, 1)
+	src <- timedPacket("router/2", 1*time.Millisecond, 1)
+	src <- timedPacket("router/1", 4*time.Millisecond, 1)
+	src <- timedPacket("router/2", 2*time.Millisecond, 1)
+
+	// the packets are released after the window, without waiting for the end of the capture.
+	var got []time.Duration
+	for i := 0; i < 4; i++ {
+		select {
+		case res := <-out:
+			got = append(got, res.GetPacket().GetTimestamp().AsTime().Sub(mergeStart))
+		case <-time.After(time.Second):
+			t.Fatalf("orderByTimestamp() did not release packet %d", i)
+		}
+	}
+
+	for i := 1; i < len(got); i++ {
+		if got[i] < got[i-1] {
+			t.Errorf("orderByTimestamp() released packets out of order: %v", got)
+		}
+	}
+
+	close(src)
+	if _, ok := <-out; ok {
+		t.Errorf("orderByTimestamp() expected out to be closed")
+	}
+}
+
+// TestOrderByTimestampSequence verifies that packets of an origin with timestamps out of order, e.g. because the agent
+// captures on several devices, are released in the order of their sequence numbers.
+func TestOrderByTimestampSequence(t *testing.T) {
+	src := make(chan *CaptureResponse, 10)
+	out := orderByTimestamp(src, 10*time.Millisecond, 1024, 10, zap.L())
+
+	src <- newPacketResponse([]byte{0}, gopacket.CaptureInfo{Timestamp: mergeStart.Add(3 * time.Millisecond)}, "router/1", 1)
+	src <- newPacketResponse([]byte{0}, gopacket.CaptureInfo{Timestamp: mergeStart.Add(1 * time.Millisecond)}, "router/1", 2)
+	src <- newPacketResponse([]byte{0}, gopacket.CaptureInfo{Timestamp: mergeStart.Add(2 * time.Millisecond)}, "router/2", 1)
+
+	gaps := gapDetector{}
+	var got []string
+	for i := 0; i < 3; i++ {
+		select {
+		case res := <-out:
+			gaps.add(res.GetPacket())
+			got = append(got, fmt.Sprintf("%s#%d", res.GetPacket().GetOrigin(), res.GetPacket().GetSequence()))
+		case <-time.After(time.Second):
+			t.Fatalf("orderByTimestamp() did not release packet %d", i)
+		}
+	}
+
+	expected := []string{"router/2#1", "router/1#1", "router/1#2"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("orderByTimestamp() released %v, expected %v", got, expected)
+	}
+	for origin, seq := range gaps {
+		if seq.received != seq.last || seq.missing != 0 || seq.gaps != 0 {
+			t.Errorf("expected no gaps for %s, got %+v", origin, seq)
+		}
+	}
+	close(src)
+}
+
+func TestOrderByTimestampLatePackets(t *testing.T) {
+	origin := "merge/late"
+	late := testutil.ToFloat64(latePackets.WithLabelValues(origin))
+
+	src := make(chan *CaptureResponse, 10)
+	out := orderByTimestamp(src, 10*time.Millisecond, 1024, 10, zap.L())
+
+	src <- timedPacket(origin, 2*time.Millisecond, 1)
+	<-out
+
+	// a packet before the one that has already been released is still forwarded.
+	src <- timedPacket(origin, 1*time.Millisecond, 1)
+	select {
+	case res := <-out:
+		if !res.GetPacket().GetTimestamp().AsTime().Equal(mergeStart.Add(time.Millisecond)) {
+			t.Errorf("orderByTimestamp() expected the late packet, got %v", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("orderByTimestamp() did not forward the late packet")
+	}
+
+	if got := testutil.ToFloat64(latePackets.WithLabelValues(origin)); got != late+1 {
+		t.Errorf("expected %v late packets, got %v", late+1, got)
+	}
+	close(src)
+}
+
+func TestOrderByTimestampMaxBytes(t *testing.T) {
+	src := make(chan *CaptureResponse, 10)
+	out := orderByTimestamp(src, time.Hour, 4, 10, zap.L())
+
+	src <- timedPacket("router/1", 2*time.Millisecond, 3)
+	src <- timedPacket("router/2", 1*time.Millisecond, 3)
+
+	// the earliest packet is released early as the buffer is full.
+	select {
+	case res := <-out:
+		if !res.GetPacket().GetTimestamp().AsTime().Equal(mergeStart.Add(time.Millisecond)) {
+			t.Errorf("orderByTimestamp() expected the earliest packet, got %v", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("orderByTimestamp() did not release a packet once the buffer was full")
+	}
+
+	select {
+	case res := <-out:
+		t.Errorf("orderByTimestamp() released a packet before the window passed: %v", res)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(src)
+}
+
+func TestOrderByTimestampMessages(t *testing.T) {
+	src := make(chan *CaptureResponse, 10)
+	out := orderByTimestamp(src, time.Hour, 1024, 10, zap.L())
+
+	src <- timedPacket("router/1", time.Millisecond, 1)
+	src <- newMessageResponse(MessageType_INSTANCE_UNAVAILABLE, "unavailable", "router/2")
+
+	select {
+	case res := <-out:
+		if res.GetMessage().GetType() != MessageType_INSTANCE_UNAVAILABLE {
+			t.Errorf("orderByTimestamp() expected the message first, got %v", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("orderByTimestamp() did not forward the message immediately")
+	}
+
+	// held packets are flushed at the end of the capture.
+	close(src)
+	res, ok := <-out
+	if !ok || res.GetPacket() == nil {
+		t.Errorf("orderByTimestamp() expected the held packet to be flushed, got %v", res)
+	}
+}
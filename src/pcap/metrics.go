@@ -0,0 +1,182 @@
+package pcap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const metricsNamespace = "pcap"
+
+// metricsReadHeaderTimeout protects the metrics listener against slow clients.
+const metricsReadHeaderTimeout = 10 * time.Second
+
+// metricsRegistry holds all metrics of pcap-api and pcap-agent. A dedicated registry is used instead of the
+// prometheus default registry to only expose the metrics of this package and the process.
+var metricsRegistry = prometheus.NewRegistry()
+
+var (
+	activeCaptures = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_captures",
+		Help:      "Number of capture streams that are currently running.",
+	})
+	captureDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "capture_duration_seconds",
+		Help:      "Duration of finished capture streams.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 300, 600, 1800, 3600},
+	})
+	forwardedPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "forwarded_packets_total",
+		Help:      "Number of packets forwarded to the client, by origin.",
+	}, []string{"origin"})
+	forwardedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "forwarded_bytes_total",
+		Help:      "Number of packet bytes forwarded to the client, by origin.",
+	}, []string{"origin"})
+	discardedPackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "discarded_packets_total",
+		Help:      "Number of packets discarded due to back pressure of the client, by origin.",
+	}, []string{"origin"})
+	discardedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "discarded_bytes_total",
+		Help:      "Number of packet bytes discarded due to back pressure of the client, by origin.",
+	}, []string{"origin"})
+	latePackets = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "late_packets_total",
+		Help:      "Number of packets forwarded out of order by the ordered merge because they arrived too late, by origin.",
+	}, []string{"origin"})
+	resolveDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "resolve_duration_seconds",
+		Help:      "Latency of resolving agent endpoints, by resolver and result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"resolver", "result"})
+	agentConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "agent_connections",
+		Help:      "Number of open connections from the api to pcap-agents.",
+	})
+	agentConnectionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "agent_connection_failures_total",
+		Help:      "Number of failed attempts to start a capture on a pcap-agent, by gRPC code.",
+	}, []string{"code"})
+)
+
+// resolverHealthDesc describes the health of the resolvers of an API, which is collected by API.Collect.
+var resolverHealthDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(metricsNamespace, "", "resolver_healthy"),
+	"Whether the resolver is healthy (1) or not (0).",
+	[]string{"resolver"}, nil,
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		activeCaptures,
+		captureDuration,
+		forwardedPackets,
+		forwardedBytes,
+		discardedPackets,
+		discardedBytes,
+		latePackets,
+		resolveDuration,
+		agentConnections,
+		agentConnectionFailures,
+	)
+}
+
+// MetricsConf defines the port of the optional HTTP listener for metrics in the prometheus format.
+type MetricsConf struct {
+	Port int `yaml:"port" validate:"gt=0,lte=65535"`
+}
+
+// ServeMetrics serves the metrics at /metrics on the port of conf until the listener fails. Additional collectors,
+// e.g. the API for the health of its resolvers, are registered before serving.
+//
+// Blocks and is meant to be run in a go routine.
+func ServeMetrics(conf MetricsConf, log *zap.Logger, collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		err := metricsRegistry.Register(collector)
+		if err != nil {
+			return fmt.Errorf("register metrics: %w", err)
+		}
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", conf.Port))
+	if err != nil {
+		return fmt.Errorf("create metrics listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: metricsReadHeaderTimeout,
+	}
+
+	log.Info("serving metrics", zap.Int("port", conf.Port))
+	err = server.Serve(lis)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve metrics: %w", err)
+	}
+
+	return nil
+}
+
+// trackCapture counts the capture as active and returns a function that records its duration once it is done.
+func trackCapture() func() {
+	activeCaptures.Inc()
+	start := time.Now()
+
+	return func() {
+		activeCaptures.Dec()
+		captureDuration.Observe(time.Since(start).Seconds())
+	}
+}
+
+// countAgentConnectionFailure records that a capture could not be started on an agent due to err.
+func countAgentConnectionFailure(err error) {
+	code := status.Code(err)
+	if code == codes.Unknown {
+		unwrappedError := errors.Unwrap(err)
+		if unwrappedError != nil {
+			code = status.Code(unwrappedError)
+		}
+	}
+
+	agentConnectionFailures.WithLabelValues(code.String()).Inc()
+}
+
+// Describe implements prometheus.Collector for the health of the resolvers.
+func (api *API) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resolverHealthDesc
+}
+
+// Collect implements prometheus.Collector and reports the health of each registered resolver as of the last refresh.
+func (api *API) Collect(ch chan<- prometheus.Metric) {
+	for name, resolverHealthy := range api.resolverHealthList() {
+		healthy := 0.0
+		if resolverHealthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(resolverHealthDesc, prometheus.GaugeValue, healthy, name)
+	}
+}
@@ -0,0 +1,143 @@
+package pcap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gopacket/gopacket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTrackCapture(t *testing.T) {
+	active := testutil.ToFloat64(activeCaptures)
+	finished := observations(t)
+
+	done := trackCapture()
+	if got := testutil.ToFloat64(activeCaptures); got != active+1 {
+		t.Errorf("expected %v active captures, got %v", active+1, got)
+	}
+
+	done()
+	if got := testutil.ToFloat64(activeCaptures); got != active {
+		t.Errorf("expected %v active captures, got %v", active, got)
+	}
+
+	if got := observations(t); got != finished+1 {
+		t.Errorf("expected %d capture durations, got %d", finished+1, got)
+	}
+}
+
+// observations returns the number of durations recorded by captureDuration.
+func observations(t *testing.T) uint64 {
+	t.Helper()
+
+	var m dto.Metric
+	err := captureDuration.Write(&m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestCountAgentConnectionFailure(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+	}{
+		{name: "status error", err: status.Error(codes.Unavailable, "connection refused"), expectedCode: codes.Unavailable},
+		{name: "wrapped status error", err: fmt.Errorf("start capture: %w", status.Error(codes.FailedPrecondition, "unhealthy")), expectedCode: codes.FailedPrecondition},
+		{name: "plain error", err: errors.New("unexpected"), expectedCode: codes.Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter := agentConnectionFailures.WithLabelValues(test.expectedCode.String())
+			before := testutil.ToFloat64(counter)
+
+			countAgentConnectionFailure(test.err)
+
+			if got := testutil.ToFloat64(counter); got != before+1 {
+				t.Errorf("expected %v failures with code %v, got %v", before+1, test.expectedCode, got)
+			}
+		})
+	}
+}
+
+func TestForwardToStreamMetrics(t *testing.T) {
+	origin := "metrics/forwarded"
+	data := []byte("ABC")
+	packets := 3
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	src := make(chan *CaptureResponse, bufSize)
+	for i := 0; i < packets; i++ {
+		src <- newPacketResponse(data, gopacket.CaptureInfo{}, origin, uint64(i+1))
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	forwardToStream(cancel, src, &mockPacketSender{sentRes: bufSize}, BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, &CaptureLimits{MaxPackets: uint64(packets)}, nil, wg, agentOrigin)
+
+	<-ctx.Done()
+	wg.Wait()
+
+	if got := testutil.ToFloat64(forwardedPackets.WithLabelValues(origin)); got != float64(packets) {
+		t.Errorf("expected %d forwarded packets, got %v", packets, got)
+	}
+	if got := testutil.ToFloat64(forwardedBytes.WithLabelValues(origin)); got != float64(packets*len(data)) {
+		t.Errorf("expected %d forwarded bytes, got %v", packets*len(data), got)
+	}
+}
+
+func TestAPICollectResolverHealth(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "api"}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api.RegisterResolver(HealthyResolver{})
+
+	expected := `
+# HELP pcap_resolver_healthy Whether the resolver is healthy (1) or not (0).
+# TYPE pcap_resolver_healthy gauge
+pcap_resolver_healthy{resolver="healthy"} 1
+`
+
+	err = testutil.CollectAndCompare(api, strings.NewReader(expected))
+	if err != nil {
+		t.Errorf("unexpected metrics: %v", err)
+	}
+}
+
+// TestAPICollectDuringReload collects the resolver health while the resolvers are replaced, which is reported as a
+// data race by the race detector if Collect does not hold the lock of the API.
+func TestAPICollectDuringReload(t *testing.T) {
+	api, err := NewAPI(NodeConfig{Buffer: BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: "api"}, APIConf{ConcurrentCaptures: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	api.RegisterResolver(HealthyResolver{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			api.ReplaceResolvers(HealthyResolver{})
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		if count := testutil.CollectAndCount(api); count != 1 {
+			t.Errorf("expected the health of one resolver, got %d", count)
+		}
+	}
+	wg.Wait()
+}
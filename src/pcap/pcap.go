@@ -25,7 +25,7 @@ const (
 	// that requires both parties to be updated this value MUST be incremented by one. The calling
 	// party has to ensure that the compatibility level of the called party is equal or larger and
 	// refuse operation if it isn't.
-	CompatibilityLevel int64 = 1
+	CompatibilityLevel int64 = 2
 
 	// LogKeyVcapID sets on which field the vcap request id will be logged.
 	LogKeyVcapID = "vcap-id"
@@ -33,8 +33,10 @@ const (
 	LogKeyHandler       = "handler"
 	LogKeyTarget        = "target"
 	LogKeyResolver      = "resolver"
+	LogKeyIdentity      = "identity"
 	HeaderVcapID        = contextKeyVcapID("x-vcap-request-id")
 	maxDeviceNameLength = 16
+	maxDevices          = 8
 	maxFilterLength     = 5000
 
 	DefaultStatusTimeout = time.Minute
@@ -68,26 +70,113 @@ func newMessageResponse(messageType MessageType, message string, origin string)
 }
 
 // newPacketResponse wraps data into a CaptureResponse, which can be sent to the recipient.
-func newPacketResponse(data []byte, captureInfo gopacket.CaptureInfo) *CaptureResponse {
+// The packet is marked with its origin and its sequence number for that origin.
+func newPacketResponse(data []byte, captureInfo gopacket.CaptureInfo, origin string, sequence uint64) *CaptureResponse {
 	return &CaptureResponse{
 		Payload: &CaptureResponse_Packet{
 			Packet: &Packet{
 				Data:      data,
 				Timestamp: timestamppb.New(captureInfo.Timestamp),
 				Length:    int32(captureInfo.Length), //nolint:gosec //this is a size of network packet, well within int32
+				Origin:    origin,
+				Sequence:  sequence,
 			},
 		},
 	}
 }
 
+// limitTracker counts the packets and bytes of a capture and determines whether one of its CaptureLimits
+// has been reached.
+type limitTracker struct {
+	limits  *CaptureLimits
+	packets uint64
+	bytes   uint64
+	timer   *time.Timer
+}
+
+// newLimitTracker creates a limitTracker for limits. The duration limit starts with the creation of the tracker.
+// limits may be nil, in which case no limits are enforced.
+func newLimitTracker(limits *CaptureLimits) *limitTracker {
+	l := &limitTracker{limits: limits}
+
+	if maxDuration := limits.GetMaxDuration().AsDuration(); maxDuration > 0 {
+		l.timer = time.NewTimer(maxDuration)
+	}
+
+	return l
+}
+
+// expired returns a channel that receives a value once the duration limit has been reached.
+// If no duration limit is set, the returned channel is nil and blocks forever.
+func (l *limitTracker) expired() <-chan time.Time {
+	if l.timer == nil {
+		return nil
+	}
+	return l.timer.C
+}
+
+// stop releases the resources of the duration limit.
+func (l *limitTracker) stop() {
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// count adds a packet of size bytes to the counters and returns a description of the limit that has been reached
+// with this packet. An empty string is returned as long as no limit is reached.
+func (l *limitTracker) count(size int) string {
+	l.packets++
+	l.bytes += uint64(size) //nolint:gosec // size is the length of a byte slice and never negative
+
+	if maxPackets := l.limits.GetMaxPackets(); maxPackets > 0 && l.packets >= maxPackets {
+		return fmt.Sprintf("max packets (%d)", maxPackets)
+	}
+
+	if maxBytes := l.limits.GetMaxBytes(); maxBytes > 0 && l.bytes >= maxBytes {
+		return fmt.Sprintf("max bytes (%d)", maxBytes)
+	}
+
+	return ""
+}
+
+// durationLimit returns a description of the duration limit.
+func (l *limitTracker) durationLimit() string {
+	return fmt.Sprintf("max duration (%s)", l.limits.GetMaxDuration().AsDuration())
+}
+
+// newLimitReachedResponse creates the LIMIT_REACHED message for the limit described by limit.
+func newLimitReachedResponse(limit string, origin string) *CaptureResponse {
+	return newMessageResponse(MessageType_LIMIT_REACHED, fmt.Sprintf("capture limit reached: %s", limit), origin)
+}
+
+// newCaptureStoppedResponse creates a CAPTURE_STOPPED message that informs the receiver why origin stopped capturing.
+func newCaptureStoppedResponse(reason StopReason, message string, origin string) *CaptureResponse {
+	res := newMessageResponse(MessageType_CAPTURE_STOPPED, message, origin)
+	res.GetMessage().StopReason = reason
+	return res
+}
+
 func (opts *CaptureOptions) validate() error {
-	if opts.Device == "" {
-		return fmt.Errorf("expected device to be not empty string")
+	devices := opts.captureDevices()
+	if len(devices) > maxDevices {
+		return fmt.Errorf("expected at most %d devices, received %d", maxDevices, len(devices))
 	}
 
-	err := validateDevice(opts.Device)
-	if err != nil {
-		return err
+	seen := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		if device == "" {
+			return fmt.Errorf("expected device to be not empty string")
+		}
+
+		err := validateDevice(device)
+		if err != nil {
+			return err
+		}
+
+		if seen[device] {
+			return fmt.Errorf("expected devices to be unique, %q is listed more than once", device)
+		}
+		seen[device] = true
 	}
 
 	if len(opts.Filter) > maxFilterLength {
@@ -100,6 +189,16 @@ func (opts *CaptureOptions) validate() error {
 	return nil
 }
 
+// captureDevices returns the devices to capture on. CaptureOptions.Devices takes precedence over
+// CaptureOptions.Device, which is kept for compatibility.
+func (opts *CaptureOptions) captureDevices() []string {
+	if len(opts.Devices) > 0 {
+		return opts.Devices
+	}
+
+	return []string{opts.Device}
+}
+
 // setVcapID expands log to include the vcap-id extracted from ctx, if available.
 // When no vcap-id is defined in ctx, a new random GUID is generated and add to context key HeaderVcapID and the logger.
 func setVcapID(ctx context.Context, log *zap.Logger, externalVcapID *string) (context.Context, *zap.Logger) {
@@ -158,7 +257,13 @@ func MakeStopRequest() *CaptureRequest {
 // forwardToStream reads Packets from src until it's closed and writes them to stream.
 // If it encounters an error while doing so the error is set to cause and the cancel function
 // is called. Any data left in src is discarded after a write-error occurred.
-func forwardToStream(cancel context.CancelCauseFunc, src <-chan *CaptureResponse, stream responseSender, bufConf BufferConf, wg *sync.WaitGroup, id string) {
+//
+// If limits are set, forwardToStream stops forwarding once one of the limits is reached. In that case a
+// LIMIT_REACHED message is sent and the cancel function is called without cause.
+//
+// Without credits, packets are discarded while the buffer of src is congested, see FlowControl THRESHOLD.
+// Otherwise, each packet waits for a credit of the receiver, see FlowControl CREDIT.
+func forwardToStream(cancel context.CancelCauseFunc, src <-chan *CaptureResponse, stream responseSender, bufConf BufferConf, limits *CaptureLimits, credits *creditGate, wg *sync.WaitGroup, id string) {
 	go func() {
 		// After this function returns we want to make sure that this channel is
 		// drained properly if there is anything left in it. This avoids responses
@@ -167,48 +272,102 @@ func forwardToStream(cancel context.CancelCauseFunc, src <-chan *CaptureResponse
 		defer purge(src)
 		defer wg.Done()
 
-		discarding := false
-		for res := range src {
-			// we never discard messages, only data
-			_, isMsg := res.Payload.(*CaptureResponse_Message)
-
-			// example (values are probably a bad choice):
-			// buffer size: 10
-			// lower limit: 2
-			// upper limit: 8
-			// len(src)      => fill level of buffer
-			// discarding    => are we currently discarding packet responses?
-			// messages sent => how many messages have been sent up until now
-			// len(src) | discarding | messages sent
-			// 2        | false      | 0
-			// 1        | false      | 1
-			// 7        | false      | 2
-			// 6        | false      | 3
-			// 9        | true       | 4 // last packet was DISCARDING_MESSAGES
-			// 8        | true       | 4
-			// 7        | true       | 4
-			// ...
-			// 3        | true       | 4
-			// 2        | false      | 5
-			// 1        | false      | 6
-
-			switch {
-			case len(src) <= bufConf.LowerLimit: // if buffer size is zero this case will always match
-				discarding = false
-			case discarding && !isMsg:
-				continue
-			case len(src) >= bufConf.UpperLimit && !isMsg:
-				discarding = true
-				// this only is sent when we start discarding (and discards the current data packet)
-				res = newMessageResponse(MessageType_CONGESTED, "too much back pressure, discarding packets", id)
-			}
+		limit := newLimitTracker(limits)
+		defer limit.stop()
 
-			err := stream.Send(res)
-			if err != nil {
-				cancel(errorf(codes.Unknown, "send response: %w", err))
+		discarder := newThresholdDiscarder(bufConf, id)
+		for {
+			var res *CaptureResponse
+			var ok bool
+
+			select {
+			case res, ok = <-src:
+				if !ok {
+					err := sendAll(stream, discarder.totals())
+					if err != nil {
+						cancel(errorf(codes.Unknown, "send response: %w", err))
+						return
+					}
+					cancel(errorf(codes.Aborted, "no data is left to forward"))
+					return
+				}
+			case <-limit.expired():
+				stopOnLimit(cancel, stream, limit.durationLimit(), discarder, id)
 				return
 			}
+
+			responses := []*CaptureResponse{res}
+			if credits == nil {
+				responses = discarder.filter(res, len(src))
+			} else if res.GetPacket() != nil {
+				congested := bufConf.UpperLimit > 0 && len(src) >= bufConf.UpperLimit
+				reached, err := awaitCredit(credits, limit, stream, congested, id)
+				if err != nil {
+					cancel(errorf(codes.Unknown, "send response: %w", err))
+					return
+				}
+				if reached != "" {
+					stopOnLimit(cancel, stream, reached, discarder, id)
+					return
+				}
+			}
+
+			for _, res := range responses {
+				err := stream.Send(res)
+				if err != nil {
+					cancel(errorf(codes.Unknown, "send response: %w", err))
+					return
+				}
+
+				packet := res.GetPacket()
+				if packet == nil {
+					continue
+				}
+
+				forwardedPackets.WithLabelValues(packet.GetOrigin()).Inc()
+				forwardedBytes.WithLabelValues(packet.GetOrigin()).Add(float64(len(packet.GetData())))
+
+				if reached := limit.count(len(packet.GetData())); reached != "" {
+					stopOnLimit(cancel, stream, reached, discarder, id)
+					return
+				}
+			}
 		}
-		cancel(errorf(codes.Aborted, "no data is left to forward"))
 	}()
 }
+
+// sendAll sends responses to stream in order and stops at the first error.
+func sendAll(stream responseSender, responses []*CaptureResponse) error {
+	for _, res := range responses {
+		err := stream.Send(res)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// awaitForwarding waits for forwardToStream to finish. While draining, forwarding is given drainTimeout to flush the
+// remaining responses, a drainTimeout of zero waits without bound. Returns false if forwarding did not finish in time.
+func awaitForwarding(wg *sync.WaitGroup, draining bool, drainTimeout time.Duration) bool {
+	var deadline time.Time
+	if draining && drainTimeout > 0 {
+		deadline = time.Now().Add(drainTimeout)
+	}
+	return waitUntil(wg.Wait, deadline)
+}
+
+// stopOnLimit informs the stream that limit has been reached and stops the capture by calling cancel without cause.
+// The packets discarded so far are reported before.
+func stopOnLimit(cancel context.CancelCauseFunc, stream responseSender, limit string, discarder *thresholdDiscarder, id string) {
+	zap.L().Info("capture limit reached, stopping capture", zap.String("limit", limit))
+
+	err := sendAll(stream, append(discarder.totals(), newLimitReachedResponse(limit, id)))
+	if err != nil {
+		cancel(errorf(codes.Unknown, "send response: %w", err))
+		return
+	}
+
+	// cancel without cause - normal exit
+	cancel(nil)
+}
@@ -9,6 +9,7 @@ package pcap
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -21,6 +22,173 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// FlowControl defines how the sender of packets reacts to a receiver that can
+// not keep up.
+type FlowControl int32
+
+const (
+	// Packets are discarded once the buffer of the sender is filled up to its
+	// upper limit, until it has been drained to its lower limit. A CONGESTED
+	// message with the number of discarded packets is sent.
+	FlowControl_THRESHOLD FlowControl = 0
+	// Packets are only sent as long as the receiver has granted credits with
+	// Credit requests. Each packet consumes one credit, messages do not. The
+	// sender buffers packets while it waits for credits and sends a CONGESTED
+	// message once its buffer is full. Packets are dropped by the capturing
+	// device if the capture still can not keep up, which is reported by the
+	// STATISTICS messages. After the stop request, the remaining packets are
+	// sent without credits.
+	FlowControl_CREDIT FlowControl = 1
+)
+
+// Enum value maps for FlowControl.
+var (
+	FlowControl_name = map[int32]string{
+		0: "THRESHOLD",
+		1: "CREDIT",
+	}
+	FlowControl_value = map[string]int32{
+		"THRESHOLD": 0,
+		"CREDIT":    1,
+	}
+)
+
+func (x FlowControl) Enum() *FlowControl {
+	p := new(FlowControl)
+	*p = x
+	return p
+}
+
+func (x FlowControl) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FlowControl) Descriptor() protoreflect.EnumDescriptor {
+	return file_pcap_proto_enumTypes[0].Descriptor()
+}
+
+func (FlowControl) Type() protoreflect.EnumType {
+	return &file_pcap_proto_enumTypes[0]
+}
+
+func (x FlowControl) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FlowControl.Descriptor instead.
+func (FlowControl) EnumDescriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{0}
+}
+
+// TimestampPrecision defines the precision of the timestamps of captured
+// packets. Devices that do not support nanosecond timestamps fall back to
+// microsecond timestamps.
+type TimestampPrecision int32
+
+const (
+	TimestampPrecision_MICROSECONDS TimestampPrecision = 0
+	TimestampPrecision_NANOSECONDS  TimestampPrecision = 1
+)
+
+// Enum value maps for TimestampPrecision.
+var (
+	TimestampPrecision_name = map[int32]string{
+		0: "MICROSECONDS",
+		1: "NANOSECONDS",
+	}
+	TimestampPrecision_value = map[string]int32{
+		"MICROSECONDS": 0,
+		"NANOSECONDS":  1,
+	}
+)
+
+func (x TimestampPrecision) Enum() *TimestampPrecision {
+	p := new(TimestampPrecision)
+	*p = x
+	return p
+}
+
+func (x TimestampPrecision) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimestampPrecision) Descriptor() protoreflect.EnumDescriptor {
+	return file_pcap_proto_enumTypes[1].Descriptor()
+}
+
+func (TimestampPrecision) Type() protoreflect.EnumType {
+	return &file_pcap_proto_enumTypes[1]
+}
+
+func (x TimestampPrecision) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimestampPrecision.Descriptor instead.
+func (TimestampPrecision) EnumDescriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{1}
+}
+
+// StopReason describes why the capture of an agent stopped.
+type StopReason int32
+
+const (
+	StopReason_UNSPECIFIED_STOP_REASON StopReason = 0
+	// The client requested to stop the capture.
+	StopReason_CLIENT_STOP StopReason = 1
+	// A limit of the capture has been reached.
+	StopReason_LIMIT StopReason = 2
+	// The api or the agent is shutting down.
+	StopReason_DRAINING StopReason = 3
+	// The capture failed or could not be started.
+	StopReason_ERROR StopReason = 4
+)
+
+// Enum value maps for StopReason.
+var (
+	StopReason_name = map[int32]string{
+		0: "UNSPECIFIED_STOP_REASON",
+		1: "CLIENT_STOP",
+		2: "LIMIT",
+		3: "DRAINING",
+		4: "ERROR",
+	}
+	StopReason_value = map[string]int32{
+		"UNSPECIFIED_STOP_REASON": 0,
+		"CLIENT_STOP":             1,
+		"LIMIT":                   2,
+		"DRAINING":                3,
+		"ERROR":                   4,
+	}
+)
+
+func (x StopReason) Enum() *StopReason {
+	p := new(StopReason)
+	*p = x
+	return p
+}
+
+func (x StopReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StopReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_pcap_proto_enumTypes[2].Descriptor()
+}
+
+func (StopReason) Type() protoreflect.EnumType {
+	return &file_pcap_proto_enumTypes[2]
+}
+
+func (x StopReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StopReason.Descriptor instead.
+func (StopReason) EnumDescriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{2}
+}
+
 // MessageType represents the underlying issue for easy assertion of the
 // situation. It should be used by the client to provide a nice message to the
 // end user. Future values will be added to extend functionalities of the API.
@@ -52,6 +220,14 @@ const (
 	// An error happened while attempting communication with PCAP components,
 	// independent of the client.
 	MessageType_CONNECTION_ERROR MessageType = 7
+	// Statistics about received and dropped packets of one agent. Sent
+	// periodically during the capture and once at the end of the capture. The
+	// statistics are contained in the statistics field of the message.
+	MessageType_STATISTICS MessageType = 8
+	// Metadata of the capture on one device of an agent, e.g. the link type of
+	// the captured packets. Sent once per device before the first packet of that
+	// device. The metadata is contained in the metadata field of the message.
+	MessageType_CAPTURE_METADATA MessageType = 9
 )
 
 // Enum value maps for MessageType.
@@ -65,6 +241,8 @@ var (
 		5: "LIMIT_REACHED",
 		6: "CAPTURE_STOPPED",
 		7: "CONNECTION_ERROR",
+		8: "STATISTICS",
+		9: "CAPTURE_METADATA",
 	}
 	MessageType_value = map[string]int32{
 		"UNKNOWN":              0,
@@ -75,6 +253,8 @@ var (
 		"LIMIT_REACHED":        5,
 		"CAPTURE_STOPPED":      6,
 		"CONNECTION_ERROR":     7,
+		"STATISTICS":           8,
+		"CAPTURE_METADATA":     9,
 	}
 )
 
@@ -89,11 +269,11 @@ func (x MessageType) String() string {
 }
 
 func (MessageType) Descriptor() protoreflect.EnumDescriptor {
-	return file_pcap_proto_enumTypes[0].Descriptor()
+	return file_pcap_proto_enumTypes[3].Descriptor()
 }
 
 func (MessageType) Type() protoreflect.EnumType {
-	return &file_pcap_proto_enumTypes[0]
+	return &file_pcap_proto_enumTypes[3]
 }
 
 func (x MessageType) Number() protoreflect.EnumNumber {
@@ -102,7 +282,7 @@ func (x MessageType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use MessageType.Descriptor instead.
 func (MessageType) EnumDescriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{0}
+	return file_pcap_proto_rawDescGZIP(), []int{3}
 }
 
 type CaptureOptions struct {
@@ -110,9 +290,17 @@ type CaptureOptions struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Device  string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
-	Filter  string `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
-	SnapLen uint32 `protobuf:"varint,3,opt,name=snapLen,proto3" json:"snapLen,omitempty"`
+	Device  string         `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Filter  string         `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	SnapLen uint32         `protobuf:"varint,3,opt,name=snapLen,proto3" json:"snapLen,omitempty"`
+	Limits  *CaptureLimits `protobuf:"bytes,4,opt,name=limits,proto3" json:"limits,omitempty"`
+	// Devices to capture on simultaneously. Takes precedence over device if set.
+	Devices            []string           `protobuf:"bytes,5,rep,name=devices,proto3" json:"devices,omitempty"`
+	TimestampPrecision TimestampPrecision `protobuf:"varint,6,opt,name=timestampPrecision,proto3,enum=pcap.TimestampPrecision" json:"timestampPrecision,omitempty"`
+	// Reorders the packets of all targets by their timestamp before they are
+	// sent to the client. Packets are forwarded as they arrive if not set.
+	OrderedMerge *OrderedMerge `protobuf:"bytes,7,opt,name=orderedMerge,proto3" json:"orderedMerge,omitempty"`
+	FlowControl  FlowControl   `protobuf:"varint,8,opt,name=flowControl,proto3,enum=pcap.FlowControl" json:"flowControl,omitempty"`
 }
 
 func (x *CaptureOptions) Reset() {
@@ -168,22 +356,53 @@ func (x *CaptureOptions) GetSnapLen() uint32 {
 	return 0
 }
 
-// CaptureResponse contains either a pcap packet or a message to inform the
-// client of some condition that appeared.
-type CaptureResponse struct {
+func (x *CaptureOptions) GetLimits() *CaptureLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *CaptureOptions) GetDevices() []string {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+func (x *CaptureOptions) GetTimestampPrecision() TimestampPrecision {
+	if x != nil {
+		return x.TimestampPrecision
+	}
+	return TimestampPrecision_MICROSECONDS
+}
+
+func (x *CaptureOptions) GetOrderedMerge() *OrderedMerge {
+	if x != nil {
+		return x.OrderedMerge
+	}
+	return nil
+}
+
+func (x *CaptureOptions) GetFlowControl() FlowControl {
+	if x != nil {
+		return x.FlowControl
+	}
+	return FlowControl_THRESHOLD
+}
+
+// Credit grants the sender of packets permission to send further packets. Only
+// used with FlowControl CREDIT.
+type Credit struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Payload:
-	//
-	//	*CaptureResponse_Packet
-	//	*CaptureResponse_Message
-	Payload isCaptureResponse_Payload `protobuf_oneof:"payload"`
+	Packets uint32 `protobuf:"varint,1,opt,name=packets,proto3" json:"packets,omitempty"`
 }
 
-func (x *CaptureResponse) Reset() {
-	*x = CaptureResponse{}
+func (x *Credit) Reset() {
+	*x = Credit{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pcap_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -191,13 +410,13 @@ func (x *CaptureResponse) Reset() {
 	}
 }
 
-func (x *CaptureResponse) String() string {
+func (x *Credit) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CaptureResponse) ProtoMessage() {}
+func (*Credit) ProtoMessage() {}
 
-func (x *CaptureResponse) ProtoReflect() protoreflect.Message {
+func (x *Credit) ProtoReflect() protoreflect.Message {
 	mi := &file_pcap_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -209,62 +428,36 @@ func (x *CaptureResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CaptureResponse.ProtoReflect.Descriptor instead.
-func (*CaptureResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use Credit.ProtoReflect.Descriptor instead.
+func (*Credit) Descriptor() ([]byte, []int) {
 	return file_pcap_proto_rawDescGZIP(), []int{1}
 }
 
-func (m *CaptureResponse) GetPayload() isCaptureResponse_Payload {
-	if m != nil {
-		return m.Payload
-	}
-	return nil
-}
-
-func (x *CaptureResponse) GetPacket() *Packet {
-	if x, ok := x.GetPayload().(*CaptureResponse_Packet); ok {
-		return x.Packet
-	}
-	return nil
-}
-
-func (x *CaptureResponse) GetMessage() *Message {
-	if x, ok := x.GetPayload().(*CaptureResponse_Message); ok {
-		return x.Message
+func (x *Credit) GetPackets() uint32 {
+	if x != nil {
+		return x.Packets
 	}
-	return nil
-}
-
-type isCaptureResponse_Payload interface {
-	isCaptureResponse_Payload()
-}
-
-type CaptureResponse_Packet struct {
-	Packet *Packet `protobuf:"bytes,1,opt,name=packet,proto3,oneof"`
-}
-
-type CaptureResponse_Message struct {
-	Message *Message `protobuf:"bytes,2,opt,name=message,proto3,oneof"`
+	return 0
 }
 
-func (*CaptureResponse_Packet) isCaptureResponse_Payload() {}
-
-func (*CaptureResponse_Message) isCaptureResponse_Payload() {}
-
-// Packet wraps the raw pcap data stream and some metadata (CaptureInfo: timestamp & length) of one packet. More fields might be
-// added as needed.
-type Packet struct {
+// OrderedMerge defines how packets of multiple targets are merged in order of
+// their timestamps. Packets are held back for the duration of the window to
+// wait for earlier packets of other targets. The api may enforce a shorter
+// window and releases packets early once its buffer is full. Packets that
+// arrive after later packets have been released are still forwarded. The
+// packets of a target are always forwarded in the order of their sequence
+// numbers.
+type OrderedMerge struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Data      []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Length    int32                  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	// The time packets are held back, the default of the api applies if not set.
+	Window *durationpb.Duration `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
 }
 
-func (x *Packet) Reset() {
-	*x = Packet{}
+func (x *OrderedMerge) Reset() {
+	*x = OrderedMerge{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pcap_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -272,13 +465,13 @@ func (x *Packet) Reset() {
 	}
 }
 
-func (x *Packet) String() string {
+func (x *OrderedMerge) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Packet) ProtoMessage() {}
+func (*OrderedMerge) ProtoMessage() {}
 
-func (x *Packet) ProtoReflect() protoreflect.Message {
+func (x *OrderedMerge) ProtoReflect() protoreflect.Message {
 	mi := &file_pcap_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -290,50 +483,34 @@ func (x *Packet) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Packet.ProtoReflect.Descriptor instead.
-func (*Packet) Descriptor() ([]byte, []int) {
+// Deprecated: Use OrderedMerge.ProtoReflect.Descriptor instead.
+func (*OrderedMerge) Descriptor() ([]byte, []int) {
 	return file_pcap_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *Packet) GetData() []byte {
-	if x != nil {
-		return x.Data
-	}
-	return nil
-}
-
-func (x *Packet) GetTimestamp() *timestamppb.Timestamp {
+func (x *OrderedMerge) GetWindow() *durationpb.Duration {
 	if x != nil {
-		return x.Timestamp
+		return x.Window
 	}
 	return nil
 }
 
-func (x *Packet) GetLength() int32 {
-	if x != nil {
-		return x.Length
-	}
-	return 0
-}
-
-// Message represents a control message used by the server to inform the client
-// of something it encountered. The type specifies kind of message it is and the
-// message contains a human readable version with more details that should be
-// shown to the end-user. These messages MUST NOT represent error conditions in
-// which the connection can not be continued, those must be represented using
-// the proper error/status messages.
-type Message struct {
+// CaptureLimits define after which duration, number of packets or number of
+// bytes a capture is stopped. Limits that are not set (zero) are not enforced.
+// When a limit is reached, a LIMIT_REACHED message is sent and the capture is
+// stopped gracefully.
+type CaptureLimits struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Type    MessageType `protobuf:"varint,1,opt,name=type,proto3,enum=pcap.MessageType" json:"type,omitempty"`
-	Message string      `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	Origin  string      `protobuf:"bytes,3,opt,name=origin,proto3" json:"origin,omitempty"`
+	MaxDuration *durationpb.Duration `protobuf:"bytes,1,opt,name=maxDuration,proto3" json:"maxDuration,omitempty"`
+	MaxPackets  uint64               `protobuf:"varint,2,opt,name=maxPackets,proto3" json:"maxPackets,omitempty"`
+	MaxBytes    uint64               `protobuf:"varint,3,opt,name=maxBytes,proto3" json:"maxBytes,omitempty"`
 }
 
-func (x *Message) Reset() {
-	*x = Message{}
+func (x *CaptureLimits) Reset() {
+	*x = CaptureLimits{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pcap_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -341,13 +518,13 @@ func (x *Message) Reset() {
 	}
 }
 
-func (x *Message) String() string {
+func (x *CaptureLimits) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Message) ProtoMessage() {}
+func (*CaptureLimits) ProtoMessage() {}
 
-func (x *Message) ProtoReflect() protoreflect.Message {
+func (x *CaptureLimits) ProtoReflect() protoreflect.Message {
 	mi := &file_pcap_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -359,45 +536,49 @@ func (x *Message) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Message.ProtoReflect.Descriptor instead.
-func (*Message) Descriptor() ([]byte, []int) {
+// Deprecated: Use CaptureLimits.ProtoReflect.Descriptor instead.
+func (*CaptureLimits) Descriptor() ([]byte, []int) {
 	return file_pcap_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *Message) GetType() MessageType {
+func (x *CaptureLimits) GetMaxDuration() *durationpb.Duration {
 	if x != nil {
-		return x.Type
+		return x.MaxDuration
 	}
-	return MessageType_UNKNOWN
+	return nil
 }
 
-func (x *Message) GetMessage() string {
+func (x *CaptureLimits) GetMaxPackets() uint64 {
 	if x != nil {
-		return x.Message
+		return x.MaxPackets
 	}
-	return ""
+	return 0
 }
 
-func (x *Message) GetOrigin() string {
+func (x *CaptureLimits) GetMaxBytes() uint64 {
 	if x != nil {
-		return x.Origin
+		return x.MaxBytes
 	}
-	return ""
+	return 0
 }
 
-type StatusResponse struct {
+// CaptureResponse contains either a pcap packet or a message to inform the
+// client of some condition that appeared.
+type CaptureResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Healthy            bool     `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	CompatibilityLevel int64    `protobuf:"varint,2,opt,name=compatibilityLevel,proto3" json:"compatibilityLevel,omitempty"`
-	Message            string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	Resolvers          []string `protobuf:"bytes,4,rep,name=resolvers,proto3" json:"resolvers,omitempty"`
+	// Types that are assignable to Payload:
+	//
+	//	*CaptureResponse_Packet
+	//	*CaptureResponse_Message
+	//	*CaptureResponse_Summary
+	Payload isCaptureResponse_Payload `protobuf_oneof:"payload"`
 }
 
-func (x *StatusResponse) Reset() {
-	*x = StatusResponse{}
+func (x *CaptureResponse) Reset() {
+	*x = CaptureResponse{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_pcap_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -405,13 +586,13 @@ func (x *StatusResponse) Reset() {
 	}
 }
 
-func (x *StatusResponse) String() string {
+func (x *CaptureResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusResponse) ProtoMessage() {}
+func (*CaptureResponse) ProtoMessage() {}
 
-func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+func (x *CaptureResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_pcap_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -423,62 +604,972 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
-func (*StatusResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use CaptureResponse.ProtoReflect.Descriptor instead.
+func (*CaptureResponse) Descriptor() ([]byte, []int) {
 	return file_pcap_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *StatusResponse) GetHealthy() bool {
-	if x != nil {
-		return x.Healthy
+func (m *CaptureResponse) GetPayload() isCaptureResponse_Payload {
+	if m != nil {
+		return m.Payload
 	}
-	return false
+	return nil
 }
 
-func (x *StatusResponse) GetCompatibilityLevel() int64 {
-	if x != nil {
-		return x.CompatibilityLevel
+func (x *CaptureResponse) GetPacket() *Packet {
+	if x, ok := x.GetPayload().(*CaptureResponse_Packet); ok {
+		return x.Packet
+	}
+	return nil
+}
+
+func (x *CaptureResponse) GetMessage() *Message {
+	if x, ok := x.GetPayload().(*CaptureResponse_Message); ok {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *CaptureResponse) GetSummary() *CaptureSummary {
+	if x, ok := x.GetPayload().(*CaptureResponse_Summary); ok {
+		return x.Summary
+	}
+	return nil
+}
+
+type isCaptureResponse_Payload interface {
+	isCaptureResponse_Payload()
+}
+
+type CaptureResponse_Packet struct {
+	Packet *Packet `protobuf:"bytes,1,opt,name=packet,proto3,oneof"`
+}
+
+type CaptureResponse_Message struct {
+	Message *Message `protobuf:"bytes,2,opt,name=message,proto3,oneof"`
+}
+
+type CaptureResponse_Summary struct {
+	Summary *CaptureSummary `protobuf:"bytes,3,opt,name=summary,proto3,oneof"`
+}
+
+func (*CaptureResponse_Packet) isCaptureResponse_Payload() {}
+
+func (*CaptureResponse_Message) isCaptureResponse_Payload() {}
+
+func (*CaptureResponse_Summary) isCaptureResponse_Payload() {}
+
+// CaptureSummary summarizes a finished capture per agent. It is sent by the api
+// as the last response of the capture.
+type CaptureSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Agents []*AgentSummary `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+}
+
+func (x *CaptureSummary) Reset() {
+	*x = CaptureSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureSummary) ProtoMessage() {}
+
+func (x *CaptureSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureSummary.ProtoReflect.Descriptor instead.
+func (*CaptureSummary) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CaptureSummary) GetAgents() []*AgentSummary {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+// AgentSummary summarizes the capture of one agent.
+type AgentSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The identifier of the agent, e.g. router/abc-123.
+	Origin string `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`
+	// The packets and bytes of the agent that have been forwarded to the client.
+	Packets uint64 `protobuf:"varint,2,opt,name=packets,proto3" json:"packets,omitempty"`
+	Bytes   uint64 `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	// The packets dropped by the capturing devices of the agent, as reported by
+	// the STATISTICS messages.
+	KernelDrops uint64 `protobuf:"varint,4,opt,name=kernelDrops,proto3" json:"kernelDrops,omitempty"`
+	// The packets of the agent that have been discarded due to back pressure by
+	// the agent or the api.
+	DiscardedPackets uint64 `protobuf:"varint,5,opt,name=discardedPackets,proto3" json:"discardedPackets,omitempty"`
+	DiscardedBytes   uint64 `protobuf:"varint,6,opt,name=discardedBytes,proto3" json:"discardedBytes,omitempty"`
+	// The time at which the capture of the agent started and stopped, as seen by
+	// the api. Start is not set if the capture did not start.
+	Start      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=start,proto3" json:"start,omitempty"`
+	Stop       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=stop,proto3" json:"stop,omitempty"`
+	StopReason StopReason             `protobuf:"varint,9,opt,name=stopReason,proto3,enum=pcap.StopReason" json:"stopReason,omitempty"`
+	// Details on the stop reason, e.g. the limit that has been reached or the
+	// error that occurred.
+	StopDetail string `protobuf:"bytes,10,opt,name=stopDetail,proto3" json:"stopDetail,omitempty"`
+}
+
+func (x *AgentSummary) Reset() {
+	*x = AgentSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgentSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentSummary) ProtoMessage() {}
+
+func (x *AgentSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentSummary.ProtoReflect.Descriptor instead.
+func (*AgentSummary) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AgentSummary) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *AgentSummary) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *AgentSummary) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *AgentSummary) GetKernelDrops() uint64 {
+	if x != nil {
+		return x.KernelDrops
+	}
+	return 0
+}
+
+func (x *AgentSummary) GetDiscardedPackets() uint64 {
+	if x != nil {
+		return x.DiscardedPackets
+	}
+	return 0
+}
+
+func (x *AgentSummary) GetDiscardedBytes() uint64 {
+	if x != nil {
+		return x.DiscardedBytes
+	}
+	return 0
+}
+
+func (x *AgentSummary) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *AgentSummary) GetStop() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Stop
+	}
+	return nil
+}
+
+func (x *AgentSummary) GetStopReason() StopReason {
+	if x != nil {
+		return x.StopReason
+	}
+	return StopReason_UNSPECIFIED_STOP_REASON
+}
+
+func (x *AgentSummary) GetStopDetail() string {
+	if x != nil {
+		return x.StopDetail
+	}
+	return ""
+}
+
+// Packet wraps the raw pcap data stream and some metadata (CaptureInfo: timestamp & length) of one packet. More fields might be
+// added as needed.
+type Packet struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data      []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Length    int32                  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	// The identifier of the agent that captured this packet, e.g. router/abc-123.
+	Origin string `protobuf:"bytes,4,opt,name=origin,proto3" json:"origin,omitempty"`
+	// Monotonically increasing number of the packet per origin, starting at 1.
+	// Gaps in the sequence indicate packets that have been discarded or lost on
+	// the way to the client. Zero if unknown.
+	Sequence uint64 `protobuf:"varint,5,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// The device of the agent this packet has been captured on, e.g. eth0.
+	Interface string `protobuf:"bytes,6,opt,name=interface,proto3" json:"interface,omitempty"`
+}
+
+func (x *Packet) Reset() {
+	*x = Packet{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Packet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Packet) ProtoMessage() {}
+
+func (x *Packet) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Packet.ProtoReflect.Descriptor instead.
+func (*Packet) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Packet) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Packet) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Packet) GetLength() int32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *Packet) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *Packet) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Packet) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+// Message represents a control message used by the server to inform the client
+// of something it encountered. The type specifies kind of message it is and the
+// message contains a human readable version with more details that should be
+// shown to the end-user. These messages MUST NOT represent error conditions in
+// which the connection can not be continued, those must be represented using
+// the proper error/status messages.
+type Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type    MessageType `protobuf:"varint,1,opt,name=type,proto3,enum=pcap.MessageType" json:"type,omitempty"`
+	Message string      `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Origin  string      `protobuf:"bytes,3,opt,name=origin,proto3" json:"origin,omitempty"`
+	// Only set for messages of type STATISTICS.
+	Statistics *CaptureStatistics `protobuf:"bytes,4,opt,name=statistics,proto3" json:"statistics,omitempty"`
+	// Only set for messages of type CAPTURE_METADATA.
+	Metadata *CaptureMetadata `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Only set for messages of type CONGESTED that report discarded packets.
+	Discards *DiscardStatistics `protobuf:"bytes,6,opt,name=discards,proto3" json:"discards,omitempty"`
+	// Only set for messages of type CAPTURE_STOPPED, if the reason is known.
+	StopReason StopReason `protobuf:"varint,7,opt,name=stopReason,proto3,enum=pcap.StopReason" json:"stopReason,omitempty"`
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Message) GetType() MessageType {
+	if x != nil {
+		return x.Type
+	}
+	return MessageType_UNKNOWN
+}
+
+func (x *Message) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Message) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *Message) GetStatistics() *CaptureStatistics {
+	if x != nil {
+		return x.Statistics
+	}
+	return nil
+}
+
+func (x *Message) GetMetadata() *CaptureMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Message) GetDiscards() *DiscardStatistics {
+	if x != nil {
+		return x.Discards
+	}
+	return nil
+}
+
+func (x *Message) GetStopReason() StopReason {
+	if x != nil {
+		return x.StopReason
+	}
+	return StopReason_UNSPECIFIED_STOP_REASON
+}
+
+// DiscardStatistics contains the packets that have been discarded by the origin
+// of the message due to back pressure, either during one congestion episode or
+// in total for the whole capture.
+type DiscardStatistics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The discarded packets per origin of the packets.
+	Origins []*OriginDiscards `protobuf:"bytes,1,rep,name=origins,proto3" json:"origins,omitempty"`
+	// The duration of the congestion episode. Not set for the totals.
+	Duration *durationpb.Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// Whether these are the totals of the whole capture.
+	Total bool `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"`
+}
+
+func (x *DiscardStatistics) Reset() {
+	*x = DiscardStatistics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DiscardStatistics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiscardStatistics) ProtoMessage() {}
+
+func (x *DiscardStatistics) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiscardStatistics.ProtoReflect.Descriptor instead.
+func (*DiscardStatistics) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DiscardStatistics) GetOrigins() []*OriginDiscards {
+	if x != nil {
+		return x.Origins
+	}
+	return nil
+}
+
+func (x *DiscardStatistics) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *DiscardStatistics) GetTotal() bool {
+	if x != nil {
+		return x.Total
+	}
+	return false
+}
+
+// OriginDiscards contains the discarded packets of one origin, e.g. router/abc-123.
+type OriginDiscards struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Origin  string `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`
+	Packets uint64 `protobuf:"varint,2,opt,name=packets,proto3" json:"packets,omitempty"`
+	Bytes   uint64 `protobuf:"varint,3,opt,name=bytes,proto3" json:"bytes,omitempty"`
+}
+
+func (x *OriginDiscards) Reset() {
+	*x = OriginDiscards{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OriginDiscards) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OriginDiscards) ProtoMessage() {}
+
+func (x *OriginDiscards) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OriginDiscards.ProtoReflect.Descriptor instead.
+func (*OriginDiscards) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OriginDiscards) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *OriginDiscards) GetPackets() uint64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
+func (x *OriginDiscards) GetBytes() uint64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+// CaptureMetadata describes the packets captured on one device of an agent.
+type CaptureMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The device of the agent, e.g. eth0.
+	Device string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// The link type of the packets captured on the device, as defined by
+	// https://www.tcpdump.org/linktypes.html.
+	LinkType uint32 `protobuf:"varint,2,opt,name=linkType,proto3" json:"linkType,omitempty"`
+	// The precision of the timestamps of the packets captured on the device,
+	// which is MICROSECONDS if the device does not support the requested
+	// NANOSECONDS.
+	TimestampPrecision TimestampPrecision `protobuf:"varint,3,opt,name=timestampPrecision,proto3,enum=pcap.TimestampPrecision" json:"timestampPrecision,omitempty"`
+}
+
+func (x *CaptureMetadata) Reset() {
+	*x = CaptureMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureMetadata) ProtoMessage() {}
+
+func (x *CaptureMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureMetadata.ProtoReflect.Descriptor instead.
+func (*CaptureMetadata) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CaptureMetadata) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *CaptureMetadata) GetLinkType() uint32 {
+	if x != nil {
+		return x.LinkType
+	}
+	return 0
+}
+
+func (x *CaptureMetadata) GetTimestampPrecision() TimestampPrecision {
+	if x != nil {
+		return x.TimestampPrecision
+	}
+	return TimestampPrecision_MICROSECONDS
+}
+
+// CaptureStatistics contains the counters of the packet capturing handle of an
+// agent since the start of the capture.
+type CaptureStatistics struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Number of packets received by the filter.
+	Received uint64 `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	// Number of packets dropped by the kernel, e.g. due to insufficient buffer
+	// space.
+	Dropped uint64 `protobuf:"varint,2,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// Number of packets dropped by the network interface or its driver.
+	IfDropped uint64 `protobuf:"varint,3,opt,name=ifDropped,proto3" json:"ifDropped,omitempty"`
+	// The device the statistics belong to, e.g. eth0.
+	Device string `protobuf:"bytes,4,opt,name=device,proto3" json:"device,omitempty"`
+}
+
+func (x *CaptureStatistics) Reset() {
+	*x = CaptureStatistics{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CaptureStatistics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CaptureStatistics) ProtoMessage() {}
+
+func (x *CaptureStatistics) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CaptureStatistics.ProtoReflect.Descriptor instead.
+func (*CaptureStatistics) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CaptureStatistics) GetReceived() uint64 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *CaptureStatistics) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *CaptureStatistics) GetIfDropped() uint64 {
+	if x != nil {
+		return x.IfDropped
+	}
+	return 0
+}
+
+func (x *CaptureStatistics) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Healthy            bool     `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	CompatibilityLevel int64    `protobuf:"varint,2,opt,name=compatibilityLevel,proto3" json:"compatibilityLevel,omitempty"`
+	Message            string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Resolvers          []string `protobuf:"bytes,4,rep,name=resolvers,proto3" json:"resolvers,omitempty"`
+	// runningCaptures is the number of captures currently running on the pcap-agent.
+	RunningCaptures int32 `protobuf:"varint,5,opt,name=runningCaptures,proto3" json:"runningCaptures,omitempty"`
+	// maxCaptures is the maximum number of concurrent captures of the pcap-agent, zero if there is no limit.
+	MaxCaptures int32 `protobuf:"varint,6,opt,name=maxCaptures,proto3" json:"maxCaptures,omitempty"`
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *StatusResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *StatusResponse) GetCompatibilityLevel() int64 {
+	if x != nil {
+		return x.CompatibilityLevel
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetResolvers() []string {
+	if x != nil {
+		return x.Resolvers
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetRunningCaptures() int32 {
+	if x != nil {
+		return x.RunningCaptures
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetMaxCaptures() int32 {
+	if x != nil {
+		return x.MaxCaptures
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{14}
+}
+
+type ListInstanceInterfacesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Request *EndpointRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+}
+
+func (x *ListInstanceInterfacesRequest) Reset() {
+	*x = ListInstanceInterfacesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListInstanceInterfacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstanceInterfacesRequest) ProtoMessage() {}
+
+func (x *ListInstanceInterfacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstanceInterfacesRequest.ProtoReflect.Descriptor instead.
+func (*ListInstanceInterfacesRequest) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListInstanceInterfacesRequest) GetRequest() *EndpointRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+type ListInstanceInterfacesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Instances []*InstanceInterfaces `protobuf:"bytes,1,rep,name=instances,proto3" json:"instances,omitempty"`
+}
+
+func (x *ListInstanceInterfacesResponse) Reset() {
+	*x = ListInstanceInterfacesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListInstanceInterfacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstanceInterfacesResponse) ProtoMessage() {}
+
+func (x *ListInstanceInterfacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *StatusResponse) GetMessage() string {
-	if x != nil {
-		return x.Message
-	}
-	return ""
+// Deprecated: Use ListInstanceInterfacesResponse.ProtoReflect.Descriptor instead.
+func (*ListInstanceInterfacesResponse) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *StatusResponse) GetResolvers() []string {
+func (x *ListInstanceInterfacesResponse) GetInstances() []*InstanceInterfaces {
 	if x != nil {
-		return x.Resolvers
+		return x.Instances
 	}
 	return nil
 }
 
-type StatusRequest struct {
+// InstanceInterfaces contains the network interfaces of a single instance.
+type InstanceInterfaces struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	// The identifier of the instance, e.g. router/abc-123.
+	Identifier string              `protobuf:"bytes,1,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Interfaces []*NetworkInterface `protobuf:"bytes,2,rep,name=interfaces,proto3" json:"interfaces,omitempty"`
+	// Describes why the interfaces of this instance could not be listed. Empty
+	// if listing succeeded.
+	Error string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 }
 
-func (x *StatusRequest) Reset() {
-	*x = StatusRequest{}
+func (x *InstanceInterfaces) Reset() {
+	*x = InstanceInterfaces{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[5]
+		mi := &file_pcap_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *StatusRequest) String() string {
+func (x *InstanceInterfaces) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusRequest) ProtoMessage() {}
+func (*InstanceInterfaces) ProtoMessage() {}
 
-func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[5]
+func (x *InstanceInterfaces) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -489,9 +1580,30 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
-func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use InstanceInterfaces.ProtoReflect.Descriptor instead.
+func (*InstanceInterfaces) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *InstanceInterfaces) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *InstanceInterfaces) GetInterfaces() []*NetworkInterface {
+	if x != nil {
+		return x.Interfaces
+	}
+	return nil
+}
+
+func (x *InstanceInterfaces) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
 type CaptureRequest struct {
@@ -503,13 +1615,14 @@ type CaptureRequest struct {
 	//
 	//	*CaptureRequest_Start
 	//	*CaptureRequest_Stop
+	//	*CaptureRequest_Credit
 	Operation isCaptureRequest_Operation `protobuf_oneof:"operation"`
 }
 
 func (x *CaptureRequest) Reset() {
 	*x = CaptureRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[6]
+		mi := &file_pcap_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -522,7 +1635,7 @@ func (x *CaptureRequest) String() string {
 func (*CaptureRequest) ProtoMessage() {}
 
 func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[6]
+	mi := &file_pcap_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -535,7 +1648,7 @@ func (x *CaptureRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CaptureRequest.ProtoReflect.Descriptor instead.
 func (*CaptureRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{6}
+	return file_pcap_proto_rawDescGZIP(), []int{18}
 }
 
 func (m *CaptureRequest) GetOperation() isCaptureRequest_Operation {
@@ -559,6 +1672,13 @@ func (x *CaptureRequest) GetStop() *StopCapture {
 	return nil
 }
 
+func (x *CaptureRequest) GetCredit() *Credit {
+	if x, ok := x.GetOperation().(*CaptureRequest_Credit); ok {
+		return x.Credit
+	}
+	return nil
+}
+
 type isCaptureRequest_Operation interface {
 	isCaptureRequest_Operation()
 }
@@ -571,10 +1691,16 @@ type CaptureRequest_Stop struct {
 	Stop *StopCapture `protobuf:"bytes,2,opt,name=stop,proto3,oneof"`
 }
 
+type CaptureRequest_Credit struct {
+	Credit *Credit `protobuf:"bytes,3,opt,name=credit,proto3,oneof"`
+}
+
 func (*CaptureRequest_Start) isCaptureRequest_Operation() {}
 
 func (*CaptureRequest_Stop) isCaptureRequest_Operation() {}
 
+func (*CaptureRequest_Credit) isCaptureRequest_Operation() {}
+
 type StopCapture struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -584,7 +1710,7 @@ type StopCapture struct {
 func (x *StopCapture) Reset() {
 	*x = StopCapture{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[7]
+		mi := &file_pcap_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -597,7 +1723,7 @@ func (x *StopCapture) String() string {
 func (*StopCapture) ProtoMessage() {}
 
 func (x *StopCapture) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[7]
+	mi := &file_pcap_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -610,7 +1736,7 @@ func (x *StopCapture) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopCapture.ProtoReflect.Descriptor instead.
 func (*StopCapture) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{7}
+	return file_pcap_proto_rawDescGZIP(), []int{19}
 }
 
 type EndpointRequest struct {
@@ -628,7 +1754,7 @@ type EndpointRequest struct {
 func (x *EndpointRequest) Reset() {
 	*x = EndpointRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[8]
+		mi := &file_pcap_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -641,7 +1767,7 @@ func (x *EndpointRequest) String() string {
 func (*EndpointRequest) ProtoMessage() {}
 
 func (x *EndpointRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[8]
+	mi := &file_pcap_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -654,7 +1780,7 @@ func (x *EndpointRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EndpointRequest.ProtoReflect.Descriptor instead.
 func (*EndpointRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{8}
+	return file_pcap_proto_rawDescGZIP(), []int{20}
 }
 
 func (m *EndpointRequest) GetRequest() isEndpointRequest_Request {
@@ -701,12 +1827,15 @@ type StartCapture struct {
 
 	Request *EndpointRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
 	Options *CaptureOptions  `protobuf:"bytes,2,opt,name=options,proto3" json:"options,omitempty"`
+	// The policy for starting the capture on multiple targets. The default of the
+	// api applies if it is not set.
+	Policy *StartPolicy `protobuf:"bytes,3,opt,name=policy,proto3" json:"policy,omitempty"`
 }
 
 func (x *StartCapture) Reset() {
 	*x = StartCapture{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[9]
+		mi := &file_pcap_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -719,7 +1848,7 @@ func (x *StartCapture) String() string {
 func (*StartCapture) ProtoMessage() {}
 
 func (x *StartCapture) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[9]
+	mi := &file_pcap_proto_msgTypes[21]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -732,7 +1861,7 @@ func (x *StartCapture) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StartCapture.ProtoReflect.Descriptor instead.
 func (*StartCapture) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{9}
+	return file_pcap_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *StartCapture) GetRequest() *EndpointRequest {
@@ -749,6 +1878,114 @@ func (x *StartCapture) GetOptions() *CaptureOptions {
 	return nil
 }
 
+func (x *StartCapture) GetPolicy() *StartPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+// StartPolicy defines how many targets must start capturing for the capture to
+// succeed. If the policy is not met the capture is stopped on all targets that did
+// start and the request fails.
+type StartPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Policy:
+	//
+	//	*StartPolicy_All
+	//	*StartPolicy_MinTargets
+	//	*StartPolicy_MinPercent
+	Policy isStartPolicy_Policy `protobuf_oneof:"policy"`
+}
+
+func (x *StartPolicy) Reset() {
+	*x = StartPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StartPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartPolicy) ProtoMessage() {}
+
+func (x *StartPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartPolicy.ProtoReflect.Descriptor instead.
+func (*StartPolicy) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{22}
+}
+
+func (m *StartPolicy) GetPolicy() isStartPolicy_Policy {
+	if m != nil {
+		return m.Policy
+	}
+	return nil
+}
+
+func (x *StartPolicy) GetAll() bool {
+	if x, ok := x.GetPolicy().(*StartPolicy_All); ok {
+		return x.All
+	}
+	return false
+}
+
+func (x *StartPolicy) GetMinTargets() uint32 {
+	if x, ok := x.GetPolicy().(*StartPolicy_MinTargets); ok {
+		return x.MinTargets
+	}
+	return 0
+}
+
+func (x *StartPolicy) GetMinPercent() uint32 {
+	if x, ok := x.GetPolicy().(*StartPolicy_MinPercent); ok {
+		return x.MinPercent
+	}
+	return 0
+}
+
+type isStartPolicy_Policy interface {
+	isStartPolicy_Policy()
+}
+
+type StartPolicy_All struct {
+	// The capture must start on all targets.
+	All bool `protobuf:"varint,1,opt,name=all,proto3,oneof"`
+}
+
+type StartPolicy_MinTargets struct {
+	// The capture must start on at least this number of targets.
+	MinTargets uint32 `protobuf:"varint,2,opt,name=minTargets,proto3,oneof"`
+}
+
+type StartPolicy_MinPercent struct {
+	// The capture must start on at least this percentage (0-100) of the targets.
+	MinPercent uint32 `protobuf:"varint,3,opt,name=minPercent,proto3,oneof"`
+}
+
+func (*StartPolicy_All) isStartPolicy_Policy() {}
+
+func (*StartPolicy_MinTargets) isStartPolicy_Policy() {}
+
+func (*StartPolicy_MinPercent) isStartPolicy_Policy() {}
+
 type BoshRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -763,7 +2000,7 @@ type BoshRequest struct {
 func (x *BoshRequest) Reset() {
 	*x = BoshRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[10]
+		mi := &file_pcap_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -776,7 +2013,7 @@ func (x *BoshRequest) String() string {
 func (*BoshRequest) ProtoMessage() {}
 
 func (x *BoshRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[10]
+	mi := &file_pcap_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -789,7 +2026,7 @@ func (x *BoshRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BoshRequest.ProtoReflect.Descriptor instead.
 func (*BoshRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{10}
+	return file_pcap_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *BoshRequest) GetToken() string {
@@ -799,55 +2036,219 @@ func (x *BoshRequest) GetToken() string {
 	return ""
 }
 
-func (x *BoshRequest) GetDeployment() string {
-	if x != nil {
-		return x.Deployment
+func (x *BoshRequest) GetDeployment() string {
+	if x != nil {
+		return x.Deployment
+	}
+	return ""
+}
+
+func (x *BoshRequest) GetGroups() []string {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *BoshRequest) GetInstances() []string {
+	if x != nil {
+		return x.Instances
+	}
+	return nil
+}
+
+type CloudfoundryRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token   string  `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	AppId   string  `protobuf:"bytes,2,opt,name=appId,proto3" json:"appId,omitempty"`
+	AppType *string `protobuf:"bytes,3,opt,name=appType,proto3,oneof" json:"appType,omitempty"`
+	Indices []int32 `protobuf:"varint,4,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+}
+
+func (x *CloudfoundryRequest) Reset() {
+	*x = CloudfoundryRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CloudfoundryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloudfoundryRequest) ProtoMessage() {}
+
+func (x *CloudfoundryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloudfoundryRequest.ProtoReflect.Descriptor instead.
+func (*CloudfoundryRequest) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CloudfoundryRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *CloudfoundryRequest) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+func (x *CloudfoundryRequest) GetAppType() string {
+	if x != nil && x.AppType != nil {
+		return *x.AppType
+	}
+	return ""
+}
+
+func (x *CloudfoundryRequest) GetIndices() []int32 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+type ListInterfacesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListInterfacesRequest) Reset() {
+	*x = ListInterfacesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListInterfacesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInterfacesRequest) ProtoMessage() {}
+
+func (x *ListInterfacesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInterfacesRequest.ProtoReflect.Descriptor instead.
+func (*ListInterfacesRequest) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{25}
+}
+
+type ListInterfacesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Interfaces []*NetworkInterface `protobuf:"bytes,1,rep,name=interfaces,proto3" json:"interfaces,omitempty"`
+}
+
+func (x *ListInterfacesResponse) Reset() {
+	*x = ListInterfacesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pcap_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListInterfacesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInterfacesResponse) ProtoMessage() {}
+
+func (x *ListInterfacesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *BoshRequest) GetGroups() []string {
-	if x != nil {
-		return x.Groups
-	}
-	return nil
+// Deprecated: Use ListInterfacesResponse.ProtoReflect.Descriptor instead.
+func (*ListInterfacesResponse) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *BoshRequest) GetInstances() []string {
+func (x *ListInterfacesResponse) GetInterfaces() []*NetworkInterface {
 	if x != nil {
-		return x.Instances
+		return x.Interfaces
 	}
 	return nil
 }
 
-type CloudfoundryRequest struct {
+// NetworkInterface describes a device that can be used for capturing, as
+// reported by libpcap.
+type NetworkInterface struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Token   string  `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
-	AppId   string  `protobuf:"bytes,2,opt,name=appId,proto3" json:"appId,omitempty"`
-	AppType *string `protobuf:"bytes,3,opt,name=appType,proto3,oneof" json:"appType,omitempty"`
-	Indices []int32 `protobuf:"varint,4,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	// The name of the device, which can be used as CaptureOptions.device.
+	Name        string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// The addresses of the device in CIDR notation.
+	Addresses []string `protobuf:"bytes,3,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	// Flags of the device, e.g. up, running, loopback.
+	Flags []string `protobuf:"bytes,4,rep,name=flags,proto3" json:"flags,omitempty"`
+	// The link type of the device, e.g. Ethernet. Empty if the device could
+	// not be opened to determine it.
+	LinkType string `protobuf:"bytes,5,opt,name=linkType,proto3" json:"linkType,omitempty"`
 }
 
-func (x *CloudfoundryRequest) Reset() {
-	*x = CloudfoundryRequest{}
+func (x *NetworkInterface) Reset() {
+	*x = NetworkInterface{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[11]
+		mi := &file_pcap_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *CloudfoundryRequest) String() string {
+func (x *NetworkInterface) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CloudfoundryRequest) ProtoMessage() {}
+func (*NetworkInterface) ProtoMessage() {}
 
-func (x *CloudfoundryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[11]
+func (x *NetworkInterface) ProtoReflect() protoreflect.Message {
+	mi := &file_pcap_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -858,58 +2259,66 @@ func (x *CloudfoundryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CloudfoundryRequest.ProtoReflect.Descriptor instead.
-func (*CloudfoundryRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use NetworkInterface.ProtoReflect.Descriptor instead.
+func (*NetworkInterface) Descriptor() ([]byte, []int) {
+	return file_pcap_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *CloudfoundryRequest) GetToken() string {
+func (x *NetworkInterface) GetName() string {
 	if x != nil {
-		return x.Token
+		return x.Name
 	}
 	return ""
 }
 
-func (x *CloudfoundryRequest) GetAppId() string {
+func (x *NetworkInterface) GetDescription() string {
 	if x != nil {
-		return x.AppId
+		return x.Description
 	}
 	return ""
 }
 
-func (x *CloudfoundryRequest) GetAppType() string {
-	if x != nil && x.AppType != nil {
-		return *x.AppType
+func (x *NetworkInterface) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
 	}
-	return ""
+	return nil
 }
 
-func (x *CloudfoundryRequest) GetIndices() []int32 {
+func (x *NetworkInterface) GetFlags() []string {
 	if x != nil {
-		return x.Indices
+		return x.Flags
 	}
 	return nil
 }
 
+func (x *NetworkInterface) GetLinkType() string {
+	if x != nil {
+		return x.LinkType
+	}
+	return ""
+}
+
 // AgentRequest contains either the start or stop request.
 type AgentRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// payload wraps the start and stop requests.
+	// payload wraps the start, stop and credit requests.
 	//
 	// Types that are assignable to Payload:
 	//
 	//	*AgentRequest_Start
 	//	*AgentRequest_Stop
+	//	*AgentRequest_Credit
 	Payload isAgentRequest_Payload `protobuf_oneof:"payload"`
 }
 
 func (x *AgentRequest) Reset() {
 	*x = AgentRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[12]
+		mi := &file_pcap_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -922,7 +2331,7 @@ func (x *AgentRequest) String() string {
 func (*AgentRequest) ProtoMessage() {}
 
 func (x *AgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[12]
+	mi := &file_pcap_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -935,7 +2344,7 @@ func (x *AgentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AgentRequest.ProtoReflect.Descriptor instead.
 func (*AgentRequest) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{12}
+	return file_pcap_proto_rawDescGZIP(), []int{28}
 }
 
 func (m *AgentRequest) GetPayload() isAgentRequest_Payload {
@@ -959,6 +2368,13 @@ func (x *AgentRequest) GetStop() *StopAgentCapture {
 	return nil
 }
 
+func (x *AgentRequest) GetCredit() *Credit {
+	if x, ok := x.GetPayload().(*AgentRequest_Credit); ok {
+		return x.Credit
+	}
+	return nil
+}
+
 type isAgentRequest_Payload interface {
 	isAgentRequest_Payload()
 }
@@ -971,10 +2387,16 @@ type AgentRequest_Stop struct {
 	Stop *StopAgentCapture `protobuf:"bytes,2,opt,name=stop,proto3,oneof"`
 }
 
+type AgentRequest_Credit struct {
+	Credit *Credit `protobuf:"bytes,3,opt,name=credit,proto3,oneof"`
+}
+
 func (*AgentRequest_Start) isAgentRequest_Payload() {}
 
 func (*AgentRequest_Stop) isAgentRequest_Payload() {}
 
+func (*AgentRequest_Credit) isAgentRequest_Payload() {}
+
 // StartAgentCapture holds all parameters needed to start a capture.
 type StartAgentCapture struct {
 	state         protoimpl.MessageState
@@ -987,7 +2409,7 @@ type StartAgentCapture struct {
 func (x *StartAgentCapture) Reset() {
 	*x = StartAgentCapture{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[13]
+		mi := &file_pcap_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1000,7 +2422,7 @@ func (x *StartAgentCapture) String() string {
 func (*StartAgentCapture) ProtoMessage() {}
 
 func (x *StartAgentCapture) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[13]
+	mi := &file_pcap_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1013,7 +2435,7 @@ func (x *StartAgentCapture) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StartAgentCapture.ProtoReflect.Descriptor instead.
 func (*StartAgentCapture) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{13}
+	return file_pcap_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *StartAgentCapture) GetCapture() *CaptureOptions {
@@ -1033,7 +2455,7 @@ type StopAgentCapture struct {
 func (x *StopAgentCapture) Reset() {
 	*x = StopAgentCapture{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_pcap_proto_msgTypes[14]
+		mi := &file_pcap_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1046,7 +2468,7 @@ func (x *StopAgentCapture) String() string {
 func (*StopAgentCapture) ProtoMessage() {}
 
 func (x *StopAgentCapture) ProtoReflect() protoreflect.Message {
-	mi := &file_pcap_proto_msgTypes[14]
+	mi := &file_pcap_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1059,7 +2481,7 @@ func (x *StopAgentCapture) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopAgentCapture.ProtoReflect.Descriptor instead.
 func (*StopAgentCapture) Descriptor() ([]byte, []int) {
-	return file_pcap_proto_rawDescGZIP(), []int{14}
+	return file_pcap_proto_rawDescGZIP(), []int{30}
 }
 
 var File_pcap_proto protoreflect.FileDescriptor
@@ -1068,125 +2490,321 @@ var file_pcap_proto_rawDesc = []byte{
 	0x0a, 0x0a, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x04, 0x70, 0x63,
 	0x61, 0x70, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
 	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x22, 0x5a, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4f, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16, 0x0a,
-	0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66,
-	0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6e, 0x61, 0x70, 0x4c, 0x65, 0x6e,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x73, 0x6e, 0x61, 0x70, 0x4c, 0x65, 0x6e, 0x22,
-	0x6f, 0x0a, 0x0f, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x26, 0x0a, 0x06, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
-	0x48, 0x00, 0x52, 0x06, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x29, 0x0a, 0x07, 0x6d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x63,
-	0x61, 0x70, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x07, 0x6d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
-	0x22, 0x6e, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
-	0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x38,
-	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xd8, 0x02, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6e, 0x61, 0x70, 0x4c, 0x65,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x73, 0x6e, 0x61, 0x70, 0x4c, 0x65, 0x6e,
+	0x12, 0x2b, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4c,
+	0x69, 0x6d, 0x69, 0x74, 0x73, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12, 0x48, 0x0a, 0x12, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x18, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x12, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x36, 0x0a, 0x0c, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x4d, 0x65, 0x72, 0x67,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x52, 0x0c, 0x6f, 0x72, 0x64,
+	0x65, 0x72, 0x65, 0x64, 0x4d, 0x65, 0x72, 0x67, 0x65, 0x12, 0x33, 0x0a, 0x0b, 0x66, 0x6c, 0x6f,
+	0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11,
+	0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f,
+	0x6c, 0x52, 0x0b, 0x66, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x22, 0x22,
+	0x0a, 0x06, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x63, 0x6b,
+	0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x70, 0x61, 0x63, 0x6b, 0x65,
+	0x74, 0x73, 0x22, 0x41, 0x0a, 0x0c, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x4d, 0x65, 0x72,
+	0x67, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x77,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x22, 0x88, 0x01, 0x0a, 0x0d, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72,
+	0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x3b, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x44, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65,
+	0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x50, 0x61, 0x63,
+	0x6b, 0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x6d, 0x61, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73,
+	0x22, 0xa1, 0x01, 0x0a, 0x0f, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x06, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x50, 0x61, 0x63, 0x6b,
+	0x65, 0x74, 0x48, 0x00, 0x52, 0x06, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x12, 0x29, 0x0a, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x70, 0x63, 0x61, 0x70, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x00, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x30, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x48, 0x00,
+	0x52, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x22, 0x3c, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53,
+	0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x41, 0x67,
+	0x65, 0x6e, 0x74, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x06, 0x61, 0x67, 0x65, 0x6e,
+	0x74, 0x73, 0x22, 0x80, 0x03, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x53, 0x75, 0x6d, 0x6d,
+	0x61, 0x72, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x70,
+	0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x70, 0x61,
+	0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x6b,
+	0x65, 0x72, 0x6e, 0x65, 0x6c, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0b, 0x6b, 0x65, 0x72, 0x6e, 0x65, 0x6c, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x12, 0x2a, 0x0a,
+	0x10, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x65, 0x64, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64,
+	0x65, 0x64, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x64, 0x69, 0x73,
+	0x63, 0x61, 0x72, 0x64, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x0e, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x30, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x18, 0x08, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74,
-	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67,
-	0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68,
-	0x22, 0x62, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x74,
-	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70,
-	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79,
-	0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06,
-	0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x72,
-	0x69, 0x67, 0x69, 0x6e, 0x22, 0x92, 0x01, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x6c, 0x74,
-	0x68, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68,
-	0x79, 0x12, 0x2e, 0x0a, 0x12, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x69, 0x62, 0x69, 0x6c, 0x69,
-	0x74, 0x79, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x63,
-	0x6f, 0x6d, 0x70, 0x61, 0x74, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x4c, 0x65, 0x76, 0x65,
-	0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72,
-	0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09,
-	0x72, 0x65, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x72, 0x0a, 0x0e, 0x43, 0x61,
-	0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x05,
-	0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x63,
-	0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x48,
-	0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x73, 0x74, 0x6f, 0x70,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74,
-	0x6f, 0x70, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x04, 0x73, 0x74, 0x6f,
-	0x70, 0x42, 0x0b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x0d,
-	0x0a, 0x0b, 0x53, 0x74, 0x6f, 0x70, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x22, 0x72, 0x0a,
-	0x0f, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x27, 0x0a, 0x04, 0x62, 0x6f, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11,
-	0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x42, 0x6f, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x48, 0x00, 0x52, 0x04, 0x62, 0x6f, 0x73, 0x68, 0x12, 0x2b, 0x0a, 0x02, 0x63, 0x66, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x6c, 0x6f,
-	0x75, 0x64, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x48, 0x00, 0x52, 0x02, 0x63, 0x66, 0x42, 0x09, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x22, 0x6f, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72,
-	0x65, 0x12, 0x2f, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69,
-	0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x2e, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75,
-	0x72, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x73, 0x22, 0x79, 0x0a, 0x0b, 0x42, 0x6f, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x65, 0x70, 0x6c, 0x6f,
-	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x70,
-	0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x67, 0x72, 0x6f, 0x75, 0x70,
-	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x73, 0x12,
-	0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03,
-	0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x86, 0x01,
-	0x0a, 0x13, 0x43, 0x6c, 0x6f, 0x75, 0x64, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x72, 0x79, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x61,
-	0x70, 0x70, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x70, 0x70, 0x49,
-	0x64, 0x12, 0x1d, 0x0a, 0x07, 0x61, 0x70, 0x70, 0x54, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x61, 0x70, 0x70, 0x54, 0x79, 0x70, 0x65, 0x88, 0x01, 0x01,
-	0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
-	0x05, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61,
-	0x70, 0x70, 0x54, 0x79, 0x70, 0x65, 0x22, 0x78, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x73,
+	0x74, 0x6f, 0x70, 0x12, 0x30, 0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f,
+	0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53,
+	0x74, 0x6f, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x52,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x44, 0x65, 0x74,
+	0x61, 0x69, 0x6c, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x44,
+	0x65, 0x74, 0x61, 0x69, 0x6c, 0x22, 0xc0, 0x01, 0x0a, 0x06, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x12, 0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16,
+	0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06,
+	0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x1a,
+	0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x22, 0xb5, 0x02, 0x0a, 0x07, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x37, 0x0a,
+	0x0a, 0x73, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x74,
+	0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x12, 0x31, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x33, 0x0a, 0x08, 0x64, 0x69, 0x73,
+	0x63, 0x61, 0x72, 0x64, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x63,
+	0x61, 0x70, 0x2e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73,
+	0x74, 0x69, 0x63, 0x73, 0x52, 0x08, 0x64, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x73, 0x12, 0x30,
+	0x0a, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x10, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65,
+	0x61, 0x73, 0x6f, 0x6e, 0x52, 0x0a, 0x73, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x22, 0x90, 0x01, 0x0a, 0x11, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x53, 0x74, 0x61, 0x74,
+	0x69, 0x73, 0x74, 0x69, 0x63, 0x73, 0x12, 0x2e, 0x0a, 0x07, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x4f,
+	0x72, 0x69, 0x67, 0x69, 0x6e, 0x44, 0x69, 0x73, 0x63, 0x61, 0x72, 0x64, 0x73, 0x52, 0x07, 0x6f,
+	0x72, 0x69, 0x67, 0x69, 0x6e, 0x73, 0x12, 0x35, 0x0a, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x08, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a,
+	0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x22, 0x58, 0x0a, 0x0e, 0x4f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x44, 0x69, 0x73,
+	0x63, 0x61, 0x72, 0x64, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x18, 0x0a,
+	0x07, 0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07,
+	0x70, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x22, 0x8f, 0x01,
+	0x0a, 0x0f, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x69, 0x6e,
+	0x6b, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x69, 0x6e,
+	0x6b, 0x54, 0x79, 0x70, 0x65, 0x12, 0x48, 0x0a, 0x12, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x18, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x12, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x7f, 0x0a, 0x11, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x53, 0x74, 0x61, 0x74, 0x69, 0x73,
+	0x74, 0x69, 0x63, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x66,
+	0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x69,
+	0x66, 0x44, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x22, 0xde, 0x01, 0x0a, 0x0e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x12, 0x2e, 0x0a,
+	0x12, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x74, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x4c, 0x65,
+	0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x63, 0x6f, 0x6d, 0x70, 0x61,
+	0x74, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x18, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x72, 0x65, 0x73, 0x6f, 0x6c,
+	0x76, 0x65, 0x72, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x73, 0x6f,
+	0x6c, 0x76, 0x65, 0x72, 0x73, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67,
+	0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0f,
+	0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12,
+	0x20, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x22, 0x50, 0x0a, 0x1d, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x45, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x58, 0x0a, 0x1e, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x36, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e,
+	0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x63, 0x61, 0x70,
+	0x2e, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61,
+	0x63, 0x65, 0x73, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x82,
+	0x01, 0x0a, 0x12, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72,
+	0x66, 0x61, 0x63, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x66,
+	0x69, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x66, 0x69, 0x65, 0x72, 0x12, 0x36, 0x0a, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61,
+	0x63, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x63, 0x61, 0x70,
+	0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63,
+	0x65, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x22, 0x9a, 0x01, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x6f, 0x70, 0x43, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x48, 0x00, 0x52, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x12, 0x26, 0x0a, 0x06, 0x63,
+	0x72, 0x65, 0x64, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x63,
+	0x61, 0x70, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x69, 0x74, 0x48, 0x00, 0x52, 0x06, 0x63, 0x72, 0x65,
+	0x64, 0x69, 0x74, 0x42, 0x0b, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0x0d, 0x0a, 0x0b, 0x53, 0x74, 0x6f, 0x70, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x22,
+	0x72, 0x0a, 0x0f, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x62, 0x6f, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x42, 0x6f, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x62, 0x6f, 0x73, 0x68, 0x12, 0x2b, 0x0a, 0x02, 0x63,
+	0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43,
+	0x6c, 0x6f, 0x75, 0x64, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x48, 0x00, 0x52, 0x02, 0x63, 0x66, 0x42, 0x09, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x9a, 0x01, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x72, 0x74, 0x43, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x12, 0x2f, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x45, 0x6e, 0x64,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x07, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x29, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61,
+	0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x22, 0x6f, 0x0a, 0x0b, 0x53, 0x74, 0x61, 0x72, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x12, 0x0a, 0x03, 0x61, 0x6c, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x03,
+	0x61, 0x6c, 0x6c, 0x12, 0x20, 0x0a, 0x0a, 0x6d, 0x69, 0x6e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x54, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x73, 0x12, 0x20, 0x0a, 0x0a, 0x6d, 0x69, 0x6e, 0x50, 0x65, 0x72, 0x63,
+	0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x0a, 0x6d, 0x69, 0x6e,
+	0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x42, 0x08, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x22, 0x7e, 0x0a, 0x0b, 0x42, 0x6f, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x19, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42,
+	0x03, 0x80, 0x01, 0x01, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x64,
+	0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x67,
+	0x72, 0x6f, 0x75, 0x70, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x67, 0x72, 0x6f,
+	0x75, 0x70, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x22, 0x8b, 0x01, 0x0a, 0x13, 0x43, 0x6c, 0x6f, 0x75, 0x64, 0x66, 0x6f, 0x75, 0x6e, 0x64,
+	0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x05, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x03, 0x80, 0x01, 0x01, 0x52, 0x05, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x70, 0x70, 0x49, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x61, 0x70, 0x70, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x07, 0x61, 0x70,
+	0x70, 0x54, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x61,
+	0x70, 0x70, 0x54, 0x79, 0x70, 0x65, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x64,
+	0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x05, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x69,
+	0x63, 0x65, 0x73, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61, 0x70, 0x70, 0x54, 0x79, 0x70, 0x65, 0x22,
+	0x17, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x50, 0x0a, 0x16, 0x4c, 0x69, 0x73, 0x74,
+	0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x36, 0x0a, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x4e, 0x65,
+	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x52, 0x0a,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x22, 0x98, 0x01, 0x0a, 0x10, 0x4e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x69, 0x6e,
+	0x6b, 0x54, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x69, 0x6e,
+	0x6b, 0x54, 0x79, 0x70, 0x65, 0x22, 0xa0, 0x01, 0x0a, 0x0c, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52,
 	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61,
 	0x72, 0x74, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00,
 	0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x2c, 0x0a, 0x04, 0x73, 0x74, 0x6f, 0x70, 0x18,
 	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x6f,
 	0x70, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x48, 0x00, 0x52,
-	0x04, 0x73, 0x74, 0x6f, 0x70, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
-	0x22, 0x43, 0x0a, 0x11, 0x53, 0x74, 0x61, 0x72, 0x74, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x43, 0x61,
-	0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61,
-	0x70, 0x74, 0x75, 0x72, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x63, 0x61,
-	0x70, 0x74, 0x75, 0x72, 0x65, 0x22, 0x12, 0x0a, 0x10, 0x53, 0x74, 0x6f, 0x70, 0x41, 0x67, 0x65,
-	0x6e, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2a, 0xb0, 0x01, 0x0a, 0x0b, 0x4d, 0x65,
-	0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b,
-	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x4e, 0x53, 0x54, 0x41, 0x4e,
-	0x43, 0x45, 0x5f, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01,
-	0x12, 0x18, 0x0a, 0x14, 0x53, 0x54, 0x41, 0x52, 0x54, 0x5f, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52,
-	0x45, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x49, 0x4e,
-	0x56, 0x41, 0x4c, 0x49, 0x44, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x03, 0x12,
-	0x0d, 0x0a, 0x09, 0x43, 0x4f, 0x4e, 0x47, 0x45, 0x53, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x11,
-	0x0a, 0x0d, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x43, 0x48, 0x45, 0x44, 0x10,
-	0x05, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x53, 0x54, 0x4f,
-	0x50, 0x50, 0x45, 0x44, 0x10, 0x06, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43,
-	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x07, 0x32, 0x76, 0x0a, 0x03,
-	0x41, 0x50, 0x49, 0x12, 0x33, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x13, 0x2e,
-	0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3a, 0x0a, 0x07, 0x43, 0x61, 0x70, 0x74,
-	0x75, 0x72, 0x65, 0x12, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75,
-	0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70,
-	0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x28, 0x01, 0x30, 0x01, 0x32, 0x76, 0x0a, 0x05, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a,
+	0x04, 0x73, 0x74, 0x6f, 0x70, 0x12, 0x26, 0x0a, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x72, 0x65,
+	0x64, 0x69, 0x74, 0x48, 0x00, 0x52, 0x06, 0x63, 0x72, 0x65, 0x64, 0x69, 0x74, 0x42, 0x09, 0x0a,
+	0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x43, 0x0a, 0x11, 0x53, 0x74, 0x61, 0x72,
+	0x74, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2e, 0x0a,
+	0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x4f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x22, 0x12, 0x0a,
+	0x10, 0x53, 0x74, 0x6f, 0x70, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72,
+	0x65, 0x2a, 0x28, 0x0a, 0x0b, 0x46, 0x6c, 0x6f, 0x77, 0x43, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c,
+	0x12, 0x0d, 0x0a, 0x09, 0x54, 0x48, 0x52, 0x45, 0x53, 0x48, 0x4f, 0x4c, 0x44, 0x10, 0x00, 0x12,
+	0x0a, 0x0a, 0x06, 0x43, 0x52, 0x45, 0x44, 0x49, 0x54, 0x10, 0x01, 0x2a, 0x37, 0x0a, 0x12, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x10, 0x0a, 0x0c, 0x4d, 0x49, 0x43, 0x52, 0x4f, 0x53, 0x45, 0x43, 0x4f, 0x4e, 0x44,
+	0x53, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x41, 0x4e, 0x4f, 0x53, 0x45, 0x43, 0x4f, 0x4e,
+	0x44, 0x53, 0x10, 0x01, 0x2a, 0x5e, 0x0a, 0x0a, 0x53, 0x74, 0x6f, 0x70, 0x52, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x17, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x5f, 0x52, 0x45, 0x41, 0x53, 0x4f, 0x4e, 0x10, 0x00, 0x12,
+	0x0f, 0x0a, 0x0b, 0x43, 0x4c, 0x49, 0x45, 0x4e, 0x54, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x10, 0x01,
+	0x12, 0x09, 0x0a, 0x05, 0x4c, 0x49, 0x4d, 0x49, 0x54, 0x10, 0x02, 0x12, 0x0c, 0x0a, 0x08, 0x44,
+	0x52, 0x41, 0x49, 0x4e, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52,
+	0x4f, 0x52, 0x10, 0x04, 0x2a, 0xd6, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10,
+	0x00, 0x12, 0x18, 0x0a, 0x14, 0x49, 0x4e, 0x53, 0x54, 0x41, 0x4e, 0x43, 0x45, 0x5f, 0x55, 0x4e,
+	0x41, 0x56, 0x41, 0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x18, 0x0a, 0x14, 0x53,
+	0x54, 0x41, 0x52, 0x54, 0x5f, 0x43, 0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x46, 0x41, 0x49,
+	0x4c, 0x45, 0x44, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44,
+	0x5f, 0x52, 0x45, 0x51, 0x55, 0x45, 0x53, 0x54, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x43, 0x4f,
+	0x4e, 0x47, 0x45, 0x53, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x11, 0x0a, 0x0d, 0x4c, 0x49, 0x4d,
+	0x49, 0x54, 0x5f, 0x52, 0x45, 0x41, 0x43, 0x48, 0x45, 0x44, 0x10, 0x05, 0x12, 0x13, 0x0a, 0x0f,
+	0x43, 0x41, 0x50, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x53, 0x54, 0x4f, 0x50, 0x50, 0x45, 0x44, 0x10,
+	0x06, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
+	0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x07, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x54, 0x41, 0x54, 0x49,
+	0x53, 0x54, 0x49, 0x43, 0x53, 0x10, 0x08, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x41, 0x50, 0x54, 0x55,
+	0x52, 0x45, 0x5f, 0x4d, 0x45, 0x54, 0x41, 0x44, 0x41, 0x54, 0x41, 0x10, 0x09, 0x32, 0xd3, 0x01,
+	0x0a, 0x03, 0x41, 0x50, 0x49, 0x12, 0x33, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12,
+	0x13, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3a, 0x0a, 0x07, 0x43, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x14, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x70, 0x63,
+	0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x5b, 0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e,
+	0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x12, 0x23, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e,
+	0x70, 0x63, 0x61, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63,
+	0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x32, 0xc3, 0x01, 0x0a, 0x05, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a,
 	0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x13, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x53,
 	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x70,
 	0x63, 0x61, 0x70, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
 	0x73, 0x65, 0x12, 0x38, 0x0a, 0x07, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x12, 0x12, 0x2e,
 	0x70, 0x63, 0x61, 0x70, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
 	0x74, 0x1a, 0x15, 0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x2f, 0x5a, 0x2d,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6c, 0x6f, 0x75, 0x64,
-	0x66, 0x6f, 0x75, 0x6e, 0x64, 0x72, 0x79, 0x2f, 0x70, 0x63, 0x61, 0x70, 0x2d, 0x72, 0x65, 0x6c,
-	0x65, 0x61, 0x73, 0x65, 0x2f, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x63, 0x61, 0x70, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x12, 0x4b, 0x0a, 0x0e,
+	0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x73, 0x12, 0x1b,
+	0x2e, 0x70, 0x63, 0x61, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x70, 0x63,
+	0x61, 0x70, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x66, 0x6f, 0x75,
+	0x6e, 0x64, 0x72, 0x79, 0x2f, 0x70, 0x63, 0x61, 0x70, 0x2d, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73,
+	0x65, 0x2f, 0x73, 0x72, 0x63, 0x2f, 0x70, 0x63, 0x61, 0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -1201,54 +2819,103 @@ func file_pcap_proto_rawDescGZIP() []byte {
 	return file_pcap_proto_rawDescData
 }
 
-var file_pcap_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pcap_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_pcap_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_pcap_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_pcap_proto_goTypes = []interface{}{
-	(MessageType)(0),              // 0: pcap.MessageType
-	(*CaptureOptions)(nil),        // 1: pcap.CaptureOptions
-	(*CaptureResponse)(nil),       // 2: pcap.CaptureResponse
-	(*Packet)(nil),                // 3: pcap.Packet
-	(*Message)(nil),               // 4: pcap.Message
-	(*StatusResponse)(nil),        // 5: pcap.StatusResponse
-	(*StatusRequest)(nil),         // 6: pcap.StatusRequest
-	(*CaptureRequest)(nil),        // 7: pcap.CaptureRequest
-	(*StopCapture)(nil),           // 8: pcap.StopCapture
-	(*EndpointRequest)(nil),       // 9: pcap.EndpointRequest
-	(*StartCapture)(nil),          // 10: pcap.StartCapture
-	(*BoshRequest)(nil),           // 11: pcap.BoshRequest
-	(*CloudfoundryRequest)(nil),   // 12: pcap.CloudfoundryRequest
-	(*AgentRequest)(nil),          // 13: pcap.AgentRequest
-	(*StartAgentCapture)(nil),     // 14: pcap.StartAgentCapture
-	(*StopAgentCapture)(nil),      // 15: pcap.StopAgentCapture
-	(*timestamppb.Timestamp)(nil), // 16: google.protobuf.Timestamp
+	(FlowControl)(0),                       // 0: pcap.FlowControl
+	(TimestampPrecision)(0),                // 1: pcap.TimestampPrecision
+	(StopReason)(0),                        // 2: pcap.StopReason
+	(MessageType)(0),                       // 3: pcap.MessageType
+	(*CaptureOptions)(nil),                 // 4: pcap.CaptureOptions
+	(*Credit)(nil),                         // 5: pcap.Credit
+	(*OrderedMerge)(nil),                   // 6: pcap.OrderedMerge
+	(*CaptureLimits)(nil),                  // 7: pcap.CaptureLimits
+	(*CaptureResponse)(nil),                // 8: pcap.CaptureResponse
+	(*CaptureSummary)(nil),                 // 9: pcap.CaptureSummary
+	(*AgentSummary)(nil),                   // 10: pcap.AgentSummary
+	(*Packet)(nil),                         // 11: pcap.Packet
+	(*Message)(nil),                        // 12: pcap.Message
+	(*DiscardStatistics)(nil),              // 13: pcap.DiscardStatistics
+	(*OriginDiscards)(nil),                 // 14: pcap.OriginDiscards
+	(*CaptureMetadata)(nil),                // 15: pcap.CaptureMetadata
+	(*CaptureStatistics)(nil),              // 16: pcap.CaptureStatistics
+	(*StatusResponse)(nil),                 // 17: pcap.StatusResponse
+	(*StatusRequest)(nil),                  // 18: pcap.StatusRequest
+	(*ListInstanceInterfacesRequest)(nil),  // 19: pcap.ListInstanceInterfacesRequest
+	(*ListInstanceInterfacesResponse)(nil), // 20: pcap.ListInstanceInterfacesResponse
+	(*InstanceInterfaces)(nil),             // 21: pcap.InstanceInterfaces
+	(*CaptureRequest)(nil),                 // 22: pcap.CaptureRequest
+	(*StopCapture)(nil),                    // 23: pcap.StopCapture
+	(*EndpointRequest)(nil),                // 24: pcap.EndpointRequest
+	(*StartCapture)(nil),                   // 25: pcap.StartCapture
+	(*StartPolicy)(nil),                    // 26: pcap.StartPolicy
+	(*BoshRequest)(nil),                    // 27: pcap.BoshRequest
+	(*CloudfoundryRequest)(nil),            // 28: pcap.CloudfoundryRequest
+	(*ListInterfacesRequest)(nil),          // 29: pcap.ListInterfacesRequest
+	(*ListInterfacesResponse)(nil),         // 30: pcap.ListInterfacesResponse
+	(*NetworkInterface)(nil),               // 31: pcap.NetworkInterface
+	(*AgentRequest)(nil),                   // 32: pcap.AgentRequest
+	(*StartAgentCapture)(nil),              // 33: pcap.StartAgentCapture
+	(*StopAgentCapture)(nil),               // 34: pcap.StopAgentCapture
+	(*durationpb.Duration)(nil),            // 35: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),          // 36: google.protobuf.Timestamp
 }
 var file_pcap_proto_depIdxs = []int32{
-	3,  // 0: pcap.CaptureResponse.packet:type_name -> pcap.Packet
-	4,  // 1: pcap.CaptureResponse.message:type_name -> pcap.Message
-	16, // 2: pcap.Packet.timestamp:type_name -> google.protobuf.Timestamp
-	0,  // 3: pcap.Message.type:type_name -> pcap.MessageType
-	10, // 4: pcap.CaptureRequest.start:type_name -> pcap.StartCapture
-	8,  // 5: pcap.CaptureRequest.stop:type_name -> pcap.StopCapture
-	11, // 6: pcap.EndpointRequest.bosh:type_name -> pcap.BoshRequest
-	12, // 7: pcap.EndpointRequest.cf:type_name -> pcap.CloudfoundryRequest
-	9,  // 8: pcap.StartCapture.request:type_name -> pcap.EndpointRequest
-	1,  // 9: pcap.StartCapture.options:type_name -> pcap.CaptureOptions
-	14, // 10: pcap.AgentRequest.start:type_name -> pcap.StartAgentCapture
-	15, // 11: pcap.AgentRequest.stop:type_name -> pcap.StopAgentCapture
-	1,  // 12: pcap.StartAgentCapture.capture:type_name -> pcap.CaptureOptions
-	6,  // 13: pcap.API.Status:input_type -> pcap.StatusRequest
-	7,  // 14: pcap.API.Capture:input_type -> pcap.CaptureRequest
-	6,  // 15: pcap.Agent.Status:input_type -> pcap.StatusRequest
-	13, // 16: pcap.Agent.Capture:input_type -> pcap.AgentRequest
-	5,  // 17: pcap.API.Status:output_type -> pcap.StatusResponse
-	2,  // 18: pcap.API.Capture:output_type -> pcap.CaptureResponse
-	5,  // 19: pcap.Agent.Status:output_type -> pcap.StatusResponse
-	2,  // 20: pcap.Agent.Capture:output_type -> pcap.CaptureResponse
-	17, // [17:21] is the sub-list for method output_type
-	13, // [13:17] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	7,  // 0: pcap.CaptureOptions.limits:type_name -> pcap.CaptureLimits
+	1,  // 1: pcap.CaptureOptions.timestampPrecision:type_name -> pcap.TimestampPrecision
+	6,  // 2: pcap.CaptureOptions.orderedMerge:type_name -> pcap.OrderedMerge
+	0,  // 3: pcap.CaptureOptions.flowControl:type_name -> pcap.FlowControl
+	35, // 4: pcap.OrderedMerge.window:type_name -> google.protobuf.Duration
+	35, // 5: pcap.CaptureLimits.maxDuration:type_name -> google.protobuf.Duration
+	11, // 6: pcap.CaptureResponse.packet:type_name -> pcap.Packet
+	12, // 7: pcap.CaptureResponse.message:type_name -> pcap.Message
+	9,  // 8: pcap.CaptureResponse.summary:type_name -> pcap.CaptureSummary
+	10, // 9: pcap.CaptureSummary.agents:type_name -> pcap.AgentSummary
+	36, // 10: pcap.AgentSummary.start:type_name -> google.protobuf.Timestamp
+	36, // 11: pcap.AgentSummary.stop:type_name -> google.protobuf.Timestamp
+	2,  // 12: pcap.AgentSummary.stopReason:type_name -> pcap.StopReason
+	36, // 13: pcap.Packet.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 14: pcap.Message.type:type_name -> pcap.MessageType
+	16, // 15: pcap.Message.statistics:type_name -> pcap.CaptureStatistics
+	15, // 16: pcap.Message.metadata:type_name -> pcap.CaptureMetadata
+	13, // 17: pcap.Message.discards:type_name -> pcap.DiscardStatistics
+	2,  // 18: pcap.Message.stopReason:type_name -> pcap.StopReason
+	14, // 19: pcap.DiscardStatistics.origins:type_name -> pcap.OriginDiscards
+	35, // 20: pcap.DiscardStatistics.duration:type_name -> google.protobuf.Duration
+	1,  // 21: pcap.CaptureMetadata.timestampPrecision:type_name -> pcap.TimestampPrecision
+	24, // 22: pcap.ListInstanceInterfacesRequest.request:type_name -> pcap.EndpointRequest
+	21, // 23: pcap.ListInstanceInterfacesResponse.instances:type_name -> pcap.InstanceInterfaces
+	31, // 24: pcap.InstanceInterfaces.interfaces:type_name -> pcap.NetworkInterface
+	25, // 25: pcap.CaptureRequest.start:type_name -> pcap.StartCapture
+	23, // 26: pcap.CaptureRequest.stop:type_name -> pcap.StopCapture
+	5,  // 27: pcap.CaptureRequest.credit:type_name -> pcap.Credit
+	27, // 28: pcap.EndpointRequest.bosh:type_name -> pcap.BoshRequest
+	28, // 29: pcap.EndpointRequest.cf:type_name -> pcap.CloudfoundryRequest
+	24, // 30: pcap.StartCapture.request:type_name -> pcap.EndpointRequest
+	4,  // 31: pcap.StartCapture.options:type_name -> pcap.CaptureOptions
+	26, // 32: pcap.StartCapture.policy:type_name -> pcap.StartPolicy
+	31, // 33: pcap.ListInterfacesResponse.interfaces:type_name -> pcap.NetworkInterface
+	33, // 34: pcap.AgentRequest.start:type_name -> pcap.StartAgentCapture
+	34, // 35: pcap.AgentRequest.stop:type_name -> pcap.StopAgentCapture
+	5,  // 36: pcap.AgentRequest.credit:type_name -> pcap.Credit
+	4,  // 37: pcap.StartAgentCapture.capture:type_name -> pcap.CaptureOptions
+	18, // 38: pcap.API.Status:input_type -> pcap.StatusRequest
+	22, // 39: pcap.API.Capture:input_type -> pcap.CaptureRequest
+	19, // 40: pcap.API.ListInterfaces:input_type -> pcap.ListInstanceInterfacesRequest
+	18, // 41: pcap.Agent.Status:input_type -> pcap.StatusRequest
+	32, // 42: pcap.Agent.Capture:input_type -> pcap.AgentRequest
+	29, // 43: pcap.Agent.ListInterfaces:input_type -> pcap.ListInterfacesRequest
+	17, // 44: pcap.API.Status:output_type -> pcap.StatusResponse
+	8,  // 45: pcap.API.Capture:output_type -> pcap.CaptureResponse
+	20, // 46: pcap.API.ListInterfaces:output_type -> pcap.ListInstanceInterfacesResponse
+	17, // 47: pcap.Agent.Status:output_type -> pcap.StatusResponse
+	8,  // 48: pcap.Agent.Capture:output_type -> pcap.CaptureResponse
+	30, // 49: pcap.Agent.ListInterfaces:output_type -> pcap.ListInterfacesResponse
+	44, // [44:50] is the sub-list for method output_type
+	38, // [38:44] is the sub-list for method input_type
+	38, // [38:38] is the sub-list for extension type_name
+	38, // [38:38] is the sub-list for extension extendee
+	0,  // [0:38] is the sub-list for field type_name
 }
 
 func init() { file_pcap_proto_init() }
@@ -1270,7 +2937,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CaptureResponse); i {
+			switch v := v.(*Credit); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1282,7 +2949,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Packet); i {
+			switch v := v.(*OrderedMerge); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1294,7 +2961,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Message); i {
+			switch v := v.(*CaptureLimits); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1306,7 +2973,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatusResponse); i {
+			switch v := v.(*CaptureResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1318,7 +2985,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StatusRequest); i {
+			switch v := v.(*CaptureSummary); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1330,7 +2997,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CaptureRequest); i {
+			switch v := v.(*AgentSummary); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1342,7 +3009,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StopCapture); i {
+			switch v := v.(*Packet); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1354,7 +3021,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EndpointRequest); i {
+			switch v := v.(*Message); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1366,7 +3033,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartCapture); i {
+			switch v := v.(*DiscardStatistics); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1378,7 +3045,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BoshRequest); i {
+			switch v := v.(*OriginDiscards); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1390,7 +3057,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CloudfoundryRequest); i {
+			switch v := v.(*CaptureMetadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1402,7 +3069,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*AgentRequest); i {
+			switch v := v.(*CaptureStatistics); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1414,7 +3081,7 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*StartAgentCapture); i {
+			switch v := v.(*StatusResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1426,6 +3093,198 @@ func file_pcap_proto_init() {
 			}
 		}
 		file_pcap_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListInstanceInterfacesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListInstanceInterfacesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InstanceInterfaces); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CaptureRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StopCapture); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EndpointRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartCapture); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BoshRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CloudfoundryRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListInterfacesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListInterfacesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NetworkInterface); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AgentRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StartAgentCapture); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pcap_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StopAgentCapture); i {
 			case 0:
 				return &v.state
@@ -1438,30 +3297,38 @@ func file_pcap_proto_init() {
 			}
 		}
 	}
-	file_pcap_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_pcap_proto_msgTypes[4].OneofWrappers = []interface{}{
 		(*CaptureResponse_Packet)(nil),
 		(*CaptureResponse_Message)(nil),
+		(*CaptureResponse_Summary)(nil),
 	}
-	file_pcap_proto_msgTypes[6].OneofWrappers = []interface{}{
+	file_pcap_proto_msgTypes[18].OneofWrappers = []interface{}{
 		(*CaptureRequest_Start)(nil),
 		(*CaptureRequest_Stop)(nil),
+		(*CaptureRequest_Credit)(nil),
 	}
-	file_pcap_proto_msgTypes[8].OneofWrappers = []interface{}{
+	file_pcap_proto_msgTypes[20].OneofWrappers = []interface{}{
 		(*EndpointRequest_Bosh)(nil),
 		(*EndpointRequest_Cf)(nil),
 	}
-	file_pcap_proto_msgTypes[11].OneofWrappers = []interface{}{}
-	file_pcap_proto_msgTypes[12].OneofWrappers = []interface{}{
+	file_pcap_proto_msgTypes[22].OneofWrappers = []interface{}{
+		(*StartPolicy_All)(nil),
+		(*StartPolicy_MinTargets)(nil),
+		(*StartPolicy_MinPercent)(nil),
+	}
+	file_pcap_proto_msgTypes[24].OneofWrappers = []interface{}{}
+	file_pcap_proto_msgTypes[28].OneofWrappers = []interface{}{
 		(*AgentRequest_Start)(nil),
 		(*AgentRequest_Stop)(nil),
+		(*AgentRequest_Credit)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_pcap_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   15,
+			NumEnums:      4,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
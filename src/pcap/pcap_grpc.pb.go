@@ -28,6 +28,10 @@ type APIClient interface {
 	// The Api MUST listen for that close and the stop command and MUST stop sending packets
 	// as soon as possible but SHOULD send packets that it still receives from the agents.
 	Capture(ctx context.Context, opts ...grpc.CallOption) (API_CaptureClient, error)
+	// ListInterfaces resolves the EndpointRequest and lists the network interfaces
+	// of each selected instance. Instances that could not be queried are part of
+	// the response and contain the error instead of interfaces.
+	ListInterfaces(ctx context.Context, in *ListInstanceInterfacesRequest, opts ...grpc.CallOption) (*ListInstanceInterfacesResponse, error)
 }
 
 type aPIClient struct {
@@ -78,6 +82,15 @@ func (x *aPICaptureClient) Recv() (*CaptureResponse, error) {
 	return m, nil
 }
 
+func (c *aPIClient) ListInterfaces(ctx context.Context, in *ListInstanceInterfacesRequest, opts ...grpc.CallOption) (*ListInstanceInterfacesResponse, error) {
+	out := new(ListInstanceInterfacesResponse)
+	err := c.cc.Invoke(ctx, "/pcap.API/ListInterfaces", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // APIServer is the server API for API service.
 // All implementations must embed UnimplementedAPIServer
 // for forward compatibility
@@ -88,6 +101,10 @@ type APIServer interface {
 	// The Api MUST listen for that close and the stop command and MUST stop sending packets
 	// as soon as possible but SHOULD send packets that it still receives from the agents.
 	Capture(API_CaptureServer) error
+	// ListInterfaces resolves the EndpointRequest and lists the network interfaces
+	// of each selected instance. Instances that could not be queried are part of
+	// the response and contain the error instead of interfaces.
+	ListInterfaces(context.Context, *ListInstanceInterfacesRequest) (*ListInstanceInterfacesResponse, error)
 	mustEmbedUnimplementedAPIServer()
 }
 
@@ -101,6 +118,9 @@ func (UnimplementedAPIServer) Status(context.Context, *StatusRequest) (*StatusRe
 func (UnimplementedAPIServer) Capture(API_CaptureServer) error {
 	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
 }
+func (UnimplementedAPIServer) ListInterfaces(context.Context, *ListInstanceInterfacesRequest) (*ListInstanceInterfacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInterfaces not implemented")
+}
 func (UnimplementedAPIServer) mustEmbedUnimplementedAPIServer() {}
 
 // UnsafeAPIServer may be embedded to opt out of forward compatibility for this service.
@@ -158,6 +178,24 @@ func (x *aPICaptureServer) Recv() (*CaptureRequest, error) {
 	return m, nil
 }
 
+func _API_ListInterfaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInstanceInterfacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListInterfaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pcap.API/ListInterfaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListInterfaces(ctx, req.(*ListInstanceInterfacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // API_ServiceDesc is the grpc.ServiceDesc for API service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -169,6 +207,10 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Status",
 			Handler:    _API_Status_Handler,
 		},
+		{
+			MethodName: "ListInterfaces",
+			Handler:    _API_ListInterfaces_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -195,6 +237,9 @@ type AgentClient interface {
 	// The only messages that can be sent next is a StopAgentCapture which stops the capture gracefully
 	// still sending any packets that are remaining and closing the stream afterwards.
 	Capture(ctx context.Context, opts ...grpc.CallOption) (Agent_CaptureClient, error)
+	// ListInterfaces returns the network interfaces the agent is able to capture
+	// on.
+	ListInterfaces(ctx context.Context, in *ListInterfacesRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error)
 }
 
 type agentClient struct {
@@ -245,6 +290,15 @@ func (x *agentCaptureClient) Recv() (*CaptureResponse, error) {
 	return m, nil
 }
 
+func (c *agentClient) ListInterfaces(ctx context.Context, in *ListInterfacesRequest, opts ...grpc.CallOption) (*ListInterfacesResponse, error) {
+	out := new(ListInterfacesResponse)
+	err := c.cc.Invoke(ctx, "/pcap.Agent/ListInterfaces", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AgentServer is the server API for Agent service.
 // All implementations must embed UnimplementedAgentServer
 // for forward compatibility
@@ -259,6 +313,9 @@ type AgentServer interface {
 	// The only messages that can be sent next is a StopAgentCapture which stops the capture gracefully
 	// still sending any packets that are remaining and closing the stream afterwards.
 	Capture(Agent_CaptureServer) error
+	// ListInterfaces returns the network interfaces the agent is able to capture
+	// on.
+	ListInterfaces(context.Context, *ListInterfacesRequest) (*ListInterfacesResponse, error)
 	mustEmbedUnimplementedAgentServer()
 }
 
@@ -272,6 +329,9 @@ func (UnimplementedAgentServer) Status(context.Context, *StatusRequest) (*Status
 func (UnimplementedAgentServer) Capture(Agent_CaptureServer) error {
 	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
 }
+func (UnimplementedAgentServer) ListInterfaces(context.Context, *ListInterfacesRequest) (*ListInterfacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInterfaces not implemented")
+}
 func (UnimplementedAgentServer) mustEmbedUnimplementedAgentServer() {}
 
 // UnsafeAgentServer may be embedded to opt out of forward compatibility for this service.
@@ -329,6 +389,24 @@ func (x *agentCaptureServer) Recv() (*AgentRequest, error) {
 	return m, nil
 }
 
+func _Agent_ListInterfaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInterfacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServer).ListInterfaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pcap.Agent/ListInterfaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServer).ListInterfaces(ctx, req.(*ListInterfacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Agent_ServiceDesc is the grpc.ServiceDesc for Agent service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -340,6 +418,10 @@ var Agent_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Status",
 			Handler:    _Agent_Status_Handler,
 		},
+		{
+			MethodName: "ListInterfaces",
+			Handler:    _Agent_ListInterfaces_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -10,12 +10,15 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestCaptureOptionsValidate(t *testing.T) {
@@ -79,6 +82,36 @@ func TestCaptureOptionsValidate(t *testing.T) {
 			opts:    &CaptureOptions{Device: randomDeviceNameFixedLength(16), Filter: "host 10.0.0.1", SnapLen: 65000},
 			wantErr: false,
 		},
+		{
+			name:    "Multiple devices",
+			opts:    &CaptureOptions{Devices: []string{"eth0", "tun0"}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: false,
+		},
+		{
+			name:    "Devices take precedence over device",
+			opts:    &CaptureOptions{Device: "eth0:", Devices: []string{"eth0"}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: false,
+		},
+		{
+			name:    "Error due to invalid device in devices",
+			opts:    &CaptureOptions{Devices: []string{"eth0", "eth0/"}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: true,
+		},
+		{
+			name:    "Error due to empty device in devices",
+			opts:    &CaptureOptions{Devices: []string{"eth0", ""}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: true,
+		},
+		{
+			name:    "Error due to duplicate devices",
+			opts:    &CaptureOptions{Devices: []string{"eth0", "tun0", "eth0"}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: true,
+		},
+		{
+			name:    "Error due to too many devices",
+			opts:    &CaptureOptions{Devices: []string{"eth0", "eth1", "eth2", "eth3", "eth4", "eth5", "eth6", "eth7", "eth8"}, Filter: "host 10.0.0.1", SnapLen: 65000},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -289,6 +322,53 @@ func Test_patchFilter(t *testing.T) {
 	}
 }
 
+func Test_validateFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		snapLen uint32
+		wantErr bool
+	}{
+		{"empty filter", "", 0, false},
+		{"simple filter", "port 443", 0, false},
+		{"patched filter", "not (ip host 100.100.100.100) and (tcp port 443)", 65000, false},
+		{"syntax error", "port 443 and", 0, true},
+		{"invalid port", "port 99999", 0, true},
+		{"unbalanced parentheses", "(host 10.0.0.1", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFilter(tt.filter, layers.LinkTypeEthernet, tt.snapLen)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_validateFilterForLinkTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    string
+		linkTypes []layers.LinkType
+		wantErr   bool
+	}{
+		{"valid for all link types", "port 443", captureLinkTypes, false},
+		{"valid for ethernet only", "arp", []layers.LinkType{linkTypeRaw, layers.LinkTypeEthernet}, false},
+		{"valid for linux cooked only", "arp", []layers.LinkType{linkTypeRaw, layers.LinkTypeLinuxSLL}, false},
+		{"invalid for raw", "arp", []layers.LinkType{linkTypeRaw}, true},
+		{"invalid for all link types", "port 443 and", captureLinkTypes, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFilterForLinkTypes(tt.filter, tt.linkTypes, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFilterForLinkTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func Test_containsForbiddenRunes(t *testing.T) {
 	tests := []struct {
 		name string
@@ -429,20 +509,21 @@ func TestForwardToStream(t *testing.T) {
 		resToBeSent int
 		stream      responseSender
 		response    *CaptureResponse
+		limits      *CaptureLimits
 		expectedErr error
 	}{
 		{
 			name:        "error during sending of packets",
 			stream:      &mockPacketSender{err: io.EOF, stopAfterErrorOccurs: true},
 			resToBeSent: 2,
-			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}),
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
 			expectedErr: io.EOF,
 		},
 		{
 			name:        "buffer is filled with PacketResponse, one packet discarded",
 			stream:      &mockPacketSender{err: nil, sentRes: bufUpperLimit + 1},
 			resToBeSent: bufUpperLimit + 2,
-			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}),
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
 			expectedErr: errTestEnded,
 		},
 		{
@@ -456,16 +537,39 @@ func TestForwardToStream(t *testing.T) {
 			name:        "buffer is filled with PacketResponse, discarding packets",
 			stream:      &mockPacketSender{err: nil, stopAfterErrorOccurs: true},
 			resToBeSent: bufUpperLimit + 1,
-			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}),
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
 			expectedErr: errDiscardedMsg,
 		},
 		{
 			name:        "happy path",
 			stream:      &mockPacketSender{err: nil, sentRes: bufUpperLimit - 1},
 			resToBeSent: bufUpperLimit - 1,
-			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}),
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
 			expectedErr: errTestEnded,
 		},
+		{
+			name:        "packet limit reached",
+			stream:      &mockPacketSender{err: nil, sentRes: bufSize},
+			resToBeSent: 3,
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
+			limits:      &CaptureLimits{MaxPackets: 2},
+			expectedErr: context.Canceled,
+		},
+		{
+			name:        "byte limit reached",
+			stream:      &mockPacketSender{err: nil, sentRes: bufSize},
+			resToBeSent: 3,
+			response:    newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, agentOrigin, 1),
+			limits:      &CaptureLimits{MaxBytes: 4},
+			expectedErr: context.Canceled,
+		},
+		{
+			name:        "duration limit reached",
+			stream:      &mockPacketSender{err: nil},
+			resToBeSent: 0,
+			limits:      &CaptureLimits{MaxDuration: durationpb.New(10 * time.Millisecond)},
+			expectedErr: context.Canceled,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -482,7 +586,7 @@ func TestForwardToStream(t *testing.T) {
 			wg := &sync.WaitGroup{}
 			wg.Add(1)
 
-			forwardToStream(cancel, src, test.stream, BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, wg, agentOrigin)
+			forwardToStream(cancel, src, test.stream, BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, test.limits, nil, wg, agentOrigin)
 
 			<-ctx.Done()
 
@@ -496,3 +600,34 @@ func TestForwardToStream(t *testing.T) {
 		})
 	}
 }
+
+func TestAwaitForwarding(t *testing.T) {
+	tests := []struct {
+		name         string
+		draining     bool
+		drainTimeout time.Duration
+		finished     bool
+		expected     bool
+	}{
+		{name: "forwarding finished", draining: true, drainTimeout: time.Second, finished: true, expected: true},
+		{name: "drain timeout exceeded", draining: true, drainTimeout: 10 * time.Millisecond, finished: false, expected: false},
+		{name: "not draining", draining: false, drainTimeout: 10 * time.Millisecond, finished: true, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			if tt.finished {
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					wg.Done()
+				}()
+			}
+
+			got := awaitForwarding(wg, tt.draining, tt.drainTimeout)
+			if got != tt.expected {
+				t.Errorf("awaitForwarding() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package pcap
 import (
 	"os"
 	"os/signal"
+	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -30,7 +31,10 @@ type WaitingStoppable interface {
 // When a server is given, it is shut down gracefully.
 //
 // The provided Stoppable can also be a WaitingStoppable. Then the Wait() function is also called.
-func StopOnSignal(log *zap.Logger, stoppable Stoppable, server *grpc.Server, stopSignals ...os.Signal) {
+//
+// Waiting and the graceful shutdown of the server are bounded by drainTimeout, after which the server is stopped
+// forcefully. A drainTimeout of zero waits without bound.
+func StopOnSignal(log *zap.Logger, stoppable Stoppable, server *grpc.Server, drainTimeout time.Duration, stopSignals ...os.Signal) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, stopSignals...)
 
@@ -39,13 +43,77 @@ func StopOnSignal(log *zap.Logger, stoppable Stoppable, server *grpc.Server, sto
 	log.Info("received signal, stopping.", zap.String("signal", sig.String()))
 	stoppable.Stop()
 
+	var deadline time.Time
+	if drainTimeout > 0 {
+		deadline = time.Now().Add(drainTimeout)
+	}
+
+	drained := true
 	if waitingStoppable, ok := stoppable.(WaitingStoppable); ok {
-		log.Info("waiting for stop")
-		waitingStoppable.Wait()
+		log.Info("waiting for stop", zap.Duration("drain-timeout", drainTimeout))
+		drained = waitUntil(waitingStoppable.Wait, deadline)
 	}
 
-	if server != nil {
+	if server == nil {
+		if !drained {
+			log.Warn("drain timeout exceeded, stopping anyway")
+		}
+		return
+	}
+
+	if drained {
 		log.Info("shutting down server")
-		server.GracefulStop()
+		drained = waitUntil(server.GracefulStop, deadline)
+	}
+
+	if !drained {
+		log.Warn("drain timeout exceeded, stopping server forcefully")
+		server.Stop()
+	}
+}
+
+// ReloadOnSignal is a reusable function to handle reload signals.
+//
+// reload is called for each of the reloadSignals that is received. Errors of reload are logged, the process keeps
+// running with the configuration it had before. ReloadOnSignal does not return.
+func ReloadOnSignal(log *zap.Logger, reload func() error, reloadSignals ...os.Signal) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, reloadSignals...)
+
+	for sig := range signals {
+		log.Info("received signal, reloading configuration.", zap.String("signal", sig.String()))
+
+		err := reload()
+		if err != nil {
+			log.Error("reloading configuration failed", zap.Error(err))
+			continue
+		}
+
+		log.Info("configuration reloaded")
+	}
+}
+
+// waitUntil calls wait and waits for it to return until deadline. Returns false if the deadline passed before. A
+// zero deadline waits without bound.
+func waitUntil(wait func(), deadline time.Time) bool {
+	if deadline.IsZero() {
+		wait()
+		return true
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
 	}
 }
@@ -0,0 +1,32 @@
+package pcap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitUntil(t *testing.T) {
+	tests := []struct {
+		name     string
+		wait     time.Duration
+		deadline time.Duration
+		expected bool
+	}{
+		{name: "returns in time", wait: 0, deadline: time.Second, expected: true},
+		{name: "deadline passes", wait: time.Second, deadline: 10 * time.Millisecond, expected: false},
+		{name: "without deadline", wait: 10 * time.Millisecond, deadline: 0, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadline time.Time
+			if tt.deadline > 0 {
+				deadline = time.Now().Add(tt.deadline)
+			}
+
+			got := waitUntil(func() { time.Sleep(tt.wait) }, deadline)
+			if got != tt.expected {
+				t.Errorf("waitUntil() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package pcap
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// redacted replaces secrets in logs and errors.
+const redacted = "[REDACTED]"
+
+var (
+	// jwtPattern matches JSON web tokens, e.g. the bearer tokens issued by UAA.
+	jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+	// authorizationPattern matches the credentials of authorization headers.
+	authorizationPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// sensitiveKeys are parts of the keys of log fields whose values are always redacted.
+	sensitiveKeys = []string{"token", "authorization", "password", "secret"}
+)
+
+// RedactString replaces all tokens and authorization header credentials in s.
+func RedactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	return authorizationPattern.ReplaceAllString(s, "$1 "+redacted)
+}
+
+// RedactProto returns a copy of m in which all fields marked with the debug_redact option are redacted, e.g. the
+// token of BoshRequest. m is returned unchanged if it contains no such field.
+func RedactProto[T proto.Message](m T) T {
+	if !m.ProtoReflect().IsValid() {
+		return m
+	}
+
+	clone := proto.Clone(m).(T) //nolint:errcheck // a clone has the type of the original message
+	if !redactMessage(clone.ProtoReflect()) {
+		return m
+	}
+	return clone
+}
+
+// redactMessage redacts all fields of m and its nested messages marked with the debug_redact option. Returns true
+// if m contains a field that has been redacted.
+func redactMessage(m protoreflect.Message) bool {
+	found := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case isRedacted(fd):
+			found = true
+			if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+				m.Set(fd, protoreflect.ValueOfString(redacted))
+			} else {
+				m.Clear(fd)
+			}
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				found = redactMessage(v.List().Get(i).Message()) || found
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+				found = redactMessage(value.Message()) || found
+				return true
+			})
+		case !fd.IsMap() && fd.Message() != nil:
+			found = redactMessage(v.Message()) || found
+		}
+		return true
+	})
+	return found
+}
+
+func isRedacted(fd protoreflect.FieldDescriptor) bool {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// redactingCore redacts secrets from all log entries before they are written: messages and string values are
+// redacted by RedactString, protobuf messages by RedactProto, and values of fields with sensitive keys are omitted.
+type redactingCore struct {
+	zapcore.Core
+}
+
+// NewRedactingCore wraps core so that no secrets are logged, see zap.WrapCore.
+func NewRedactingCore(core zapcore.Core) zapcore.Core {
+	return redactingCore{Core: core}
+}
+
+func (c redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c redactingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c redactingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = RedactString(ent.Message)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redactedFields := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redactedFields[i] = redactField(field)
+	}
+	return redactedFields
+}
+
+func redactField(field zapcore.Field) zapcore.Field {
+	if isSensitiveKey(field.Key) {
+		return zap.String(field.Key, redacted)
+	}
+
+	switch field.Type { //nolint:exhaustive // other types can not contain secrets
+	case zapcore.StringType:
+		field.String = RedactString(field.String)
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil {
+			return zap.String(field.Key, RedactString(err.Error()))
+		}
+	case zapcore.StringerType, zapcore.ReflectType:
+		if m, ok := field.Interface.(proto.Message); ok {
+			field.Interface = RedactProto(m)
+		}
+	}
+	return field
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package pcap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// tlsFiles contains all certificates and CA pools that have been loaded for TLS configurations. They are re-read by
+// ReloadTLS.
+var tlsFiles = newTLSFileRegistry()
+
+// tlsFileRegistry deduplicates the files referenced by multiple TLS configurations, so each file is read once per
+// reload.
+type tlsFileRegistry struct {
+	sync.Mutex
+	keyPairs map[string]*keyPairFile
+	caPools  map[string]*caPoolFile
+}
+
+func newTLSFileRegistry() *tlsFileRegistry {
+	return &tlsFileRegistry{
+		keyPairs: make(map[string]*keyPairFile),
+		caPools:  make(map[string]*caPoolFile),
+	}
+}
+
+// keyPair returns the key pair loaded from certFile and keyFile. The files are only read when they have not been
+// loaded before.
+func (r *tlsFileRegistry) keyPair(certFile, keyFile string) (*keyPairFile, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	key := certFile + "\x00" + keyFile
+	if keyPair, ok := r.keyPairs[key]; ok {
+		return keyPair, nil
+	}
+
+	keyPair := &keyPairFile{certFile: certFile, keyFile: keyFile}
+	err := keyPair.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.keyPairs[key] = keyPair
+	return keyPair, nil
+}
+
+// caPool returns the CA pool loaded from file. The file is only read when it has not been loaded before.
+func (r *tlsFileRegistry) caPool(file string) (*caPoolFile, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if caPool, ok := r.caPools[file]; ok {
+		return caPool, nil
+	}
+
+	caPool := &caPoolFile{file: file}
+	err := caPool.load()
+	if err != nil {
+		return nil, err
+	}
+
+	r.caPools[file] = caPool
+	return caPool, nil
+}
+
+// reload re-reads all files. Files that can not be loaded keep their previous contents.
+func (r *tlsFileRegistry) reload() error {
+	r.Lock()
+	defer r.Unlock()
+
+	var errs []error
+	for _, keyPair := range r.keyPairs {
+		errs = append(errs, keyPair.load())
+	}
+	for _, caPool := range r.caPools {
+		errs = append(errs, caPool.load())
+	}
+	return errors.Join(errs...)
+}
+
+// ReloadTLS re-reads the certificates, private keys and CA files of all TLS configurations that have been created.
+// New handshakes use the reloaded files, established connections are not affected. Files that can not be loaded
+// keep their previous contents and are reported in the returned error.
+//
+// The paths of the files can not be changed by reloading, as they are part of the TLS configurations. Client
+// configurations must be re-created to use other files or reloaded root CAs, servers must be restarted, see
+// CheckListenTLSReload.
+func ReloadTLS() error {
+	return tlsFiles.reload()
+}
+
+// CheckListenTLSReload returns an error if the TLS configuration of a listener in the reloaded config differs from
+// the current one. The TLS configuration of a listener is only created at startup, so other files or settings can
+// not be applied without a restart.
+func CheckListenTLSReload(current, reloaded *ServerTLS) error {
+	if current == nil && reloaded == nil {
+		return nil
+	}
+	if current == nil || reloaded == nil || *current != *reloaded {
+		return fmt.Errorf("listen tls can only be changed by a restart: %w", errTLSChanged)
+	}
+	return nil
+}
+
+// keyPairFile is a certificate with its private key, which is used by the certificate getter callbacks of
+// tls.Config.
+type keyPairFile struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func (k *keyPairFile) load() error {
+	cert, err := tls.LoadX509KeyPair(k.certFile, k.keyFile)
+	if err != nil {
+		return fmt.Errorf("load x509 key pair: %w", err)
+	}
+	k.cert.Store(&cert)
+	return nil
+}
+
+func (k *keyPairFile) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return k.cert.Load(), nil
+}
+
+func (k *keyPairFile) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return k.cert.Load(), nil
+}
+
+// caPoolFile is a pool of CAs read from a file.
+type caPoolFile struct {
+	file string
+	pool atomic.Pointer[x509.CertPool]
+}
+
+func (c *caPoolFile) load() error {
+	pool, err := createCAPool(c.file)
+	if err != nil {
+		return fmt.Errorf("create CA pool: %w", err)
+	}
+	c.pool.Store(pool)
+	return nil
+}
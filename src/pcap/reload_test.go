@@ -0,0 +1,194 @@
+package pcap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a new self-signed certificate and its private key to certFile and keyFile.
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "pcap"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func certificateSerial(t *testing.T, cert *tls.Certificate) int64 {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func TestReloadTLSServerConfig(t *testing.T) {
+	defer func(registry *tlsFileRegistry) { tlsFiles = registry }(tlsFiles)
+	tlsFiles = newTLSFileRegistry()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	tlsConf, err := (&ServerTLS{Certificate: certFile, PrivateKey: keyFile, ClientCas: certFile, Verify: tls.RequireAndVerifyClientCert}).Config()
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+
+	writeCertificate(t, certFile, keyFile, 2) //nolint:mnd // serial of the rotated certificate
+	err = ReloadTLS()
+	if err != nil {
+		t.Fatalf("ReloadTLS() unexpected error: %v", err)
+	}
+
+	cert, err := tlsConf.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetCertificate() unexpected error: %v", err)
+	}
+	if serial := certificateSerial(t, cert); serial != 2 {
+		t.Errorf("expected the rotated certificate with serial 2, got %d", serial)
+	}
+
+	handshakeConf, err := tlsConf.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetConfigForClient() unexpected error: %v", err)
+	}
+	expectedCAs, err := createCAPool(certFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handshakeConf.ClientCAs.Equal(expectedCAs) || handshakeConf.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("expected the rotated client CAs to be verified")
+	}
+}
+
+func TestReloadTLSKeepsCertificateOnError(t *testing.T) {
+	defer func(registry *tlsFileRegistry) { tlsFiles = registry }(tlsFiles)
+	tlsFiles = newTLSFileRegistry()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	writeCertificate(t, certFile, keyFile, 1)
+
+	tlsConf, err := (&ClientTLS{Certificate: certFile, PrivateKey: keyFile}).Config()
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+
+	err = os.WriteFile(certFile, []byte("garbage"), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = ReloadTLS()
+	if err == nil {
+		t.Errorf("ReloadTLS() expected an error for an invalid certificate")
+	}
+
+	cert, err := tlsConf.GetClientCertificate(&tls.CertificateRequestInfo{})
+	if err != nil {
+		t.Fatalf("GetClientCertificate() unexpected error: %v", err)
+	}
+	if serial := certificateSerial(t, cert); serial != 1 {
+		t.Errorf("expected the previous certificate with serial 1, got %d", serial)
+	}
+}
+
+func TestReloadTLSClientRootCAs(t *testing.T) {
+	defer func(registry *tlsFileRegistry) { tlsFiles = registry }(tlsFiles)
+	tlsFiles = newTLSFileRegistry()
+
+	dir := t.TempDir()
+	caFile, keyFile := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	writeCertificate(t, caFile, keyFile, 1)
+
+	clientTLS := &ClientTLS{RootCas: caFile}
+	_, err := clientTLS.Config()
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+
+	writeCertificate(t, caFile, keyFile, 2) //nolint:mnd // serial of the rotated CA
+	err = ReloadTLS()
+	if err != nil {
+		t.Fatalf("ReloadTLS() unexpected error: %v", err)
+	}
+
+	// configurations that are re-created after reloading use the rotated root CAs.
+	tlsConf, err := clientTLS.Config()
+	if err != nil {
+		t.Fatalf("Config() unexpected error: %v", err)
+	}
+	expectedCAs, err := createCAPool(caFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tlsConf.RootCAs.Equal(expectedCAs) {
+		t.Errorf("expected the rotated root CAs")
+	}
+}
+
+func TestCheckListenTLSReload(t *testing.T) {
+	current := &ServerTLS{Certificate: "server.crt", PrivateKey: "server.key", ClientCas: "ca.crt", Verify: tls.RequireAndVerifyClientCert}
+
+	tests := []struct {
+		name     string
+		current  *ServerTLS
+		reloaded *ServerTLS
+		wantErr  bool
+	}{
+		{name: "without tls", current: nil, reloaded: nil, wantErr: false},
+		{name: "unchanged", current: current, reloaded: &ServerTLS{Certificate: "server.crt", PrivateKey: "server.key", ClientCas: "ca.crt", Verify: tls.RequireAndVerifyClientCert}, wantErr: false},
+		{name: "other certificate", current: current, reloaded: &ServerTLS{Certificate: "other.crt", PrivateKey: "server.key", ClientCas: "ca.crt", Verify: tls.RequireAndVerifyClientCert}, wantErr: true},
+		{name: "other client CAs", current: current, reloaded: &ServerTLS{Certificate: "server.crt", PrivateKey: "server.key", ClientCas: "other.crt", Verify: tls.RequireAndVerifyClientCert}, wantErr: true},
+		{name: "tls enabled", current: nil, reloaded: current, wantErr: true},
+		{name: "tls disabled", current: current, reloaded: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckListenTLSReload(tt.current, tt.reloaded)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckListenTLSReload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
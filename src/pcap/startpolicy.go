@@ -0,0 +1,63 @@
+package pcap
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+)
+
+const maxStartPolicyPercent = 100
+
+// validateStartPolicy checks that the values of policy are in range. A nil policy is valid.
+func validateStartPolicy(policy *StartPolicy) error {
+	if policy.GetMinPercent() > maxStartPolicyPercent {
+		return fmt.Errorf("start policy: min percent %d exceeds %d: %w", policy.GetMinPercent(), maxStartPolicyPercent, errInvalidPayload)
+	}
+	return nil
+}
+
+// requiredTargets returns how many of the targets must start capturing for policy to be met. At least one target
+// is always required, which is also the case without a policy.
+//
+// Returns an error if the policy is invalid or can not be met because it requires more targets than there are.
+func requiredTargets(policy *StartPolicy, targets int) (int, error) {
+	err := validateStartPolicy(policy)
+	if err != nil {
+		return 0, errorf(codes.InvalidArgument, "%w", err)
+	}
+
+	required := 1
+	switch p := policy.GetPolicy().(type) {
+	case *StartPolicy_All:
+		if p.All {
+			required = targets
+		}
+	case *StartPolicy_MinTargets:
+		required = int(p.MinTargets)
+	case *StartPolicy_MinPercent:
+		// round up, a capture on 10 targets with 25% must start on 3 of them.
+		required = (targets*int(p.MinPercent) + maxStartPolicyPercent - 1) / maxStartPolicyPercent
+	}
+
+	required = max(required, 1)
+	if required > targets {
+		return 0, errorf(codes.FailedPrecondition, "start policy requires %d targets, but only %d were selected", required, targets)
+	}
+
+	return required, nil
+}
+
+// describeStartPolicy returns a human-readable description of policy for logs and errors.
+func describeStartPolicy(policy *StartPolicy) string {
+	switch p := policy.GetPolicy().(type) {
+	case *StartPolicy_All:
+		if p.All {
+			return "all targets"
+		}
+	case *StartPolicy_MinTargets:
+		return fmt.Sprintf("at least %d targets", p.MinTargets)
+	case *StartPolicy_MinPercent:
+		return fmt.Sprintf("at least %d%% of targets", p.MinPercent)
+	}
+	return "at least one target"
+}
@@ -0,0 +1,42 @@
+package pcap
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequiredTargets(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *StartPolicy
+		targets  int
+		expected int
+		wantCode codes.Code
+	}{
+		{name: "no policy", policy: nil, targets: 5, expected: 1},
+		{name: "all targets", policy: &StartPolicy{Policy: &StartPolicy_All{All: true}}, targets: 5, expected: 5},
+		{name: "all disabled", policy: &StartPolicy{Policy: &StartPolicy_All{All: false}}, targets: 5, expected: 1},
+		{name: "minimum number of targets", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 3}}, targets: 5, expected: 3},
+		{name: "minimum of zero targets", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 0}}, targets: 5, expected: 1},
+		{name: "more targets than selected", policy: &StartPolicy{Policy: &StartPolicy_MinTargets{MinTargets: 6}}, targets: 5, wantCode: codes.FailedPrecondition},
+		{name: "percentage is rounded up", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 25}}, targets: 10, expected: 3},
+		{name: "percentage of all targets", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 100}}, targets: 10, expected: 10},
+		{name: "zero percent", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 0}}, targets: 10, expected: 1},
+		{name: "percentage above 100", policy: &StartPolicy{Policy: &StartPolicy_MinPercent{MinPercent: 150}}, targets: 10, wantCode: codes.InvalidArgument},
+		{name: "no targets", policy: nil, targets: 0, wantCode: codes.FailedPrecondition},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			required, err := requiredTargets(test.policy, test.targets)
+			if status.Code(err) != test.wantCode {
+				t.Fatalf("requiredTargets() code = %v, wantCode %v", status.Code(err), test.wantCode)
+			}
+			if required != test.expected {
+				t.Errorf("requiredTargets() = %d, expected %d", required, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package pcap
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// captureSummary summarizes a capture per agent from the responses that are sent to the client, see CaptureSummary.
+type captureSummary struct {
+	// id is the origin of the messages of the api itself.
+	id     string
+	agents map[string]*agentRecord
+	// order is the order in which the agents are summarized.
+	order []string
+	// limit is the message of the limit of the api that has been reached, if any.
+	limit string
+	// now returns the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+// agentRecord is the summary of one agent while the capture is running.
+type agentRecord struct {
+	summary *AgentSummary
+	// drops contains the latest kernel drops per device, statistics are cumulative.
+	drops map[string]uint64
+}
+
+// newCaptureSummary creates a captureSummary that contains all targets, also those that never respond.
+func newCaptureSummary(targets []AgentEndpoint, id string) *captureSummary {
+	c := &captureSummary{id: id, agents: make(map[string]*agentRecord), now: time.Now}
+	for _, target := range targets {
+		c.agent(target.Identifier)
+	}
+	return c
+}
+
+func (c *captureSummary) agent(origin string) *agentRecord {
+	agent, ok := c.agents[origin]
+	if !ok {
+		agent = &agentRecord{summary: &AgentSummary{Origin: origin}, drops: make(map[string]uint64)}
+		c.agents[origin] = agent
+		c.order = append(c.order, origin)
+	}
+	return agent
+}
+
+// record adds res, which has been sent to the client, to the summary.
+func (c *captureSummary) record(res *CaptureResponse) {
+	if packet := res.GetPacket(); packet != nil {
+		agent := c.agent(packet.GetOrigin())
+		agent.started(c.now())
+		agent.summary.Packets++
+		agent.summary.Bytes += uint64(len(packet.GetData()))
+		return
+	}
+
+	if message := res.GetMessage(); message != nil {
+		c.recordMessage(message)
+	}
+}
+
+func (c *captureSummary) recordMessage(message *Message) {
+	// the totals of discarded packets are reported by the api and each agent, for the packets of the agents.
+	if message.GetType() == MessageType_CONGESTED && message.GetDiscards().GetTotal() {
+		for _, discards := range message.GetDiscards().GetOrigins() {
+			agent := c.agent(discards.GetOrigin())
+			agent.summary.DiscardedPackets += discards.GetPackets()
+			agent.summary.DiscardedBytes += discards.GetBytes()
+		}
+		return
+	}
+
+	if message.GetOrigin() == c.id {
+		if message.GetType() == MessageType_LIMIT_REACHED {
+			c.limit = message.GetMessage()
+		}
+		return
+	}
+
+	agent := c.agent(message.GetOrigin())
+	switch message.GetType() { //nolint:exhaustive // the other messages do not affect the summary
+	case MessageType_CAPTURE_METADATA:
+		agent.started(c.now())
+	case MessageType_STATISTICS:
+		agent.started(c.now())
+		agent.drops[message.GetStatistics().GetDevice()] = message.GetStatistics().GetDropped() + message.GetStatistics().GetIfDropped()
+	case MessageType_LIMIT_REACHED:
+		agent.stopped(c.now(), StopReason_LIMIT, message.GetMessage())
+	case MessageType_CAPTURE_STOPPED:
+		// without a reason of the agent, the reason is the one of the whole capture.
+		detail := ""
+		if message.GetStopReason() != StopReason_UNSPECIFIED_STOP_REASON {
+			detail = message.GetMessage()
+		}
+		agent.stopped(c.now(), message.GetStopReason(), detail)
+	case MessageType_UNKNOWN, MessageType_INSTANCE_UNAVAILABLE, MessageType_START_CAPTURE_FAILED, MessageType_INVALID_REQUEST, MessageType_CONNECTION_ERROR:
+		agent.stopped(c.now(), StopReason_ERROR, message.GetMessage())
+	}
+}
+
+// started records that the agent has started capturing, if it has not been recorded yet.
+func (r *agentRecord) started(now time.Time) {
+	if r.summary.Start == nil {
+		r.summary.Start = timestamppb.New(now)
+	}
+}
+
+// stopped records that the agent has stopped capturing. The first stop and the first reason are kept.
+func (r *agentRecord) stopped(now time.Time, reason StopReason, detail string) {
+	if r.summary.Stop == nil {
+		r.summary.Stop = timestamppb.New(now)
+	}
+	if r.summary.StopReason == StopReason_UNSPECIFIED_STOP_REASON {
+		r.summary.StopReason = reason
+		r.summary.StopDetail = detail
+	}
+}
+
+// response returns the summary of all agents. Agents that did not stop for a reason of their own stopped for
+// reason, agents that have not stopped yet stop now.
+func (c *captureSummary) response(reason StopReason, detail string) *CaptureResponse {
+	summary := &CaptureSummary{Agents: make([]*AgentSummary, 0, len(c.order))}
+	for _, origin := range c.order {
+		agent := c.agents[origin]
+		agent.stopped(c.now(), reason, detail)
+
+		agent.summary.KernelDrops = 0
+		for _, drops := range agent.drops {
+			agent.summary.KernelDrops += drops
+		}
+
+		summary.Agents = append(summary.Agents, agent.summary)
+	}
+
+	return &CaptureResponse{Payload: &CaptureResponse_Summary{Summary: summary}}
+}
+
+// stopReason returns the reason the capture stopped for, based on the cause of the cancelled capture context.
+func (c *captureSummary) stopReason(cause error) (StopReason, string) {
+	switch {
+	case c.limit != "":
+		return StopReason_LIMIT, c.limit
+	case errors.Is(cause, errDraining):
+		return StopReason_DRAINING, "pcap-api is draining"
+	case cause == nil || errors.Is(cause, context.Canceled):
+		return StopReason_CLIENT_STOP, ""
+	default:
+		return StopReason_ERROR, cause.Error()
+	}
+}
+
+// summarySender records the responses that have been sent successfully in summary.
+type summarySender struct {
+	responseSender
+	summary *captureSummary
+}
+
+func (s summarySender) Send(res *CaptureResponse) error {
+	err := s.responseSender.Send(res)
+	if err != nil {
+		return err
+	}
+
+	s.summary.record(res)
+	return nil
+}
@@ -0,0 +1,131 @@
+package pcap
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+)
+
+func TestCaptureSummary(t *testing.T) {
+	targets := []AgentEndpoint{{Identifier: "router/1"}, {Identifier: "router/2"}, {Identifier: "router/3"}}
+	summary := newCaptureSummary(targets, "pcap-api/1")
+	now := time.Unix(1000, 0)
+	summary.now = func() time.Time { return now }
+
+	discards := func(origin string, packets, bytes uint64) *CaptureResponse {
+		res := newMessageResponse(MessageType_CONGESTED, "discarded", origin)
+		res.GetMessage().Discards = &DiscardStatistics{Total: true, Origins: []*OriginDiscards{{Origin: "router/1", Packets: packets, Bytes: bytes}}}
+		return res
+	}
+	statistics := func(device string, dropped, ifDropped uint64) *CaptureResponse {
+		res := newMessageResponse(MessageType_STATISTICS, "statistics", "router/1")
+		res.GetMessage().Statistics = &CaptureStatistics{Device: device, Dropped: dropped, IfDropped: ifDropped}
+		return res
+	}
+
+	summary.record(newMessageResponse(MessageType_CAPTURE_METADATA, "metadata", "router/1"))
+	now = now.Add(time.Second)
+	summary.record(newPacketResponse([]byte("ABC"), gopacket.CaptureInfo{}, "router/1", 1))
+	summary.record(newPacketResponse([]byte("ABCDE"), gopacket.CaptureInfo{}, "router/1", 2))
+	summary.record(newPacketResponse([]byte("AB"), gopacket.CaptureInfo{}, "router/2", 1))
+	summary.record(statistics("eth0", 1, 1))
+	summary.record(statistics("eth0", 3, 1))
+	summary.record(statistics("eth1", 2, 0))
+	summary.record(discards("router/1", 2, 20))
+	summary.record(discards("pcap-api/1", 1, 10))
+	summary.record(newMessageResponse(MessageType_START_CAPTURE_FAILED, "capture failed", "router/3"))
+	now = now.Add(time.Second)
+	summary.record(newLimitReachedResponse("max packets", "router/2"))
+	summary.record(newMessageResponse(MessageType_CAPTURE_STOPPED, "stopped", "router/2"))
+	summary.record(newMessageResponse(MessageType_CAPTURE_STOPPED, "stopped", "router/1"))
+
+	agents := summary.response(StopReason_CLIENT_STOP, "").GetSummary().GetAgents()
+	if len(agents) != len(targets) {
+		t.Fatalf("expected a summary for each of the %d targets, got %v", len(targets), agents)
+	}
+
+	router1 := agents[0]
+	if router1.Origin != "router/1" || router1.Packets != 2 || router1.Bytes != 8 {
+		t.Errorf("expected 2 packets with 8 bytes of router/1, got %v", router1)
+	}
+	if router1.KernelDrops != 6 {
+		t.Errorf("expected the latest kernel drops of all devices of router/1, got %d", router1.KernelDrops)
+	}
+	if router1.DiscardedPackets != 3 || router1.DiscardedBytes != 30 {
+		t.Errorf("expected the discards of agent and api for router/1, got %d packets with %d bytes", router1.DiscardedPackets, router1.DiscardedBytes)
+	}
+	if !router1.Start.AsTime().Equal(time.Unix(1000, 0)) || !router1.Stop.AsTime().Equal(time.Unix(1002, 0)) {
+		t.Errorf("expected router/1 to run from 1000 to 1002, got %v to %v", router1.Start.AsTime(), router1.Stop.AsTime())
+	}
+	if router1.StopReason != StopReason_CLIENT_STOP {
+		t.Errorf("expected router/1 to stop due to the client, got %v", router1.StopReason)
+	}
+
+	router2 := agents[1]
+	if router2.StopReason != StopReason_LIMIT || router2.StopDetail == "" {
+		t.Errorf("expected router/2 to stop due to its limit, got %v: %s", router2.StopReason, router2.StopDetail)
+	}
+
+	router3 := agents[2]
+	if router3.StopReason != StopReason_ERROR || router3.StopDetail != "capture failed" || router3.Start != nil {
+		t.Errorf("expected router/3 to fail without starting, got %v", router3)
+	}
+}
+
+func TestCaptureSummaryStopReason(t *testing.T) {
+	tests := []struct {
+		name           string
+		limit          string
+		cause          error
+		expectedReason StopReason
+		expectedDetail string
+	}{
+		{name: "client stop", cause: nil, expectedReason: StopReason_CLIENT_STOP},
+		{name: "cancelled", cause: context.Canceled, expectedReason: StopReason_CLIENT_STOP},
+		{name: "api limit", limit: "max duration reached", cause: context.Canceled, expectedReason: StopReason_LIMIT, expectedDetail: "max duration reached"},
+		{name: "draining", cause: errDraining, expectedReason: StopReason_DRAINING, expectedDetail: "pcap-api is draining"},
+		{name: "error", cause: fmt.Errorf("no data is left to forward"), expectedReason: StopReason_ERROR, expectedDetail: "no data is left to forward"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary := newCaptureSummary(nil, "pcap-api/1")
+			summary.limit = tt.limit
+
+			reason, detail := summary.stopReason(tt.cause)
+			if reason != tt.expectedReason || detail != tt.expectedDetail {
+				t.Errorf("stopReason() = %v, %q, want %v, %q", reason, detail, tt.expectedReason, tt.expectedDetail)
+			}
+		})
+	}
+}
+
+func TestSummarySender(t *testing.T) {
+	summary := newCaptureSummary([]AgentEndpoint{{Identifier: "router/1"}}, "pcap-api/1")
+	stream := &recordingSender{sent: make(chan *CaptureResponse, 1)}
+	sender := summarySender{responseSender: stream, summary: summary}
+
+	err := sender.Send(newLimitReachedResponse("max packets", "pcap-api/1"))
+	if err != nil {
+		t.Fatalf("Send() unexpected error: %v", err)
+	}
+
+	<-stream.sent
+	if summary.limit == "" {
+		t.Errorf("Send() expected the limit of the api to be recorded")
+	}
+}
+
+func TestCaptureSummaryAgentDraining(t *testing.T) {
+	summary := newCaptureSummary([]AgentEndpoint{{Identifier: "router/1"}}, "pcap-api/1")
+
+	summary.record(newCaptureStoppedResponse(StopReason_DRAINING, "pcap-agent is draining", "router/1"))
+	summary.record(newMessageResponse(MessageType_CAPTURE_STOPPED, "stopped", "router/1"))
+
+	agent := summary.response(StopReason_CLIENT_STOP, "").GetSummary().GetAgents()[0]
+	if agent.StopReason != StopReason_DRAINING || agent.StopDetail != "pcap-agent is draining" {
+		t.Errorf("expected the agent to stop due to draining, got %v: %s", agent.StopReason, agent.StopDetail)
+	}
+}
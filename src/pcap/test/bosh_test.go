@@ -3,6 +3,7 @@ package test
 import (
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
@@ -117,6 +118,9 @@ func TestAuthenticate(t *testing.T) {
 			if tt.expectedErr != nil && !errors.Is(err, tt.expectedErr) {
 				t.Errorf("expectedErr = %v,\n\t\t\t\t\t\t\t   actualErr = %v", tt.expectedErr, err)
 			}
+			if err != nil && strings.Contains(err.Error(), tt.token) {
+				t.Errorf("expected the error not to contain the token, got %v", err)
+			}
 		})
 	}
 }
@@ -314,7 +318,7 @@ func TestAPIRegisterHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var api *pcap.API
-			api, err = pcap.NewAPI(pcap.BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, nil, origin, 1)
+			api, err = pcap.NewAPI(pcap.NodeConfig{Buffer: pcap.BufferConf{Size: 5, UpperLimit: 4, LowerLimit: 3}, ID: origin}, pcap.APIConf{ConcurrentCaptures: 1})
 			if err != nil {
 				t.Errorf("RegisterResolver() unexpected error during api creation: %v", err)
 			}
@@ -0,0 +1,261 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cloudfoundry/pcap-release/src/pcap"
+	"github.com/cloudfoundry/pcap-release/src/pcap/test/mock"
+
+	"go.uber.org/zap"
+)
+
+const (
+	cfAppID = "c6b2b7f4-9c4e-4d0c-8f0a-3c3e2b1b1f10"
+	cfToken = "cf-test-token"
+)
+
+func TestNewCloudfoundryResolver(t *testing.T) {
+	ccAPI := mock.NewMockCloudControllerAPI(nil, cfToken)
+
+	tests := []struct {
+		name    string
+		config  pcap.CloudfoundryResolverConfig
+		wantErr bool
+	}{
+		{
+			name: "valid environment",
+			config: pcap.CloudfoundryResolverConfig{
+				RawCcURL:  ccAPI.URL,
+				AgentPort: 8083,
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty Cloud Controller URL",
+			config: pcap.CloudfoundryResolverConfig{
+				RawCcURL: "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unreachable Cloud Controller",
+			config: pcap.CloudfoundryResolverConfig{
+				RawCcURL: "localhost:60000",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfResolver, err := pcap.NewCloudfoundryResolver(tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("wantErr = %v, error = %v", tt.wantErr, err)
+			}
+			if err == nil && cfResolver == nil {
+				t.Error("cfResolver is nil")
+			}
+		})
+	}
+}
+
+func TestCloudfoundryResolve(t *testing.T) {
+	endpoints := []pcap.AgentEndpoint{
+		{IP: "10.0.1.1", Port: 8083, Identifier: cfAppID + "/0"},
+		{IP: "10.0.1.2", Port: 8083, Identifier: cfAppID + "/1"},
+		{IP: "10.0.1.3", Port: 8083, Identifier: cfAppID + "/2"},
+	}
+
+	cfResolver, _, err := mock.NewCloudfoundryResolverWithMockCloudControllerWithEndpoints(cfAppID, endpoints, cfToken)
+	if err != nil {
+		t.Fatalf("received unexpected error = %v", err)
+	}
+
+	workerType := "worker"
+
+	tests := []struct {
+		name              string
+		req               *pcap.CloudfoundryRequest
+		expectedEndpoints []pcap.AgentEndpoint
+		expectedErr       error
+	}{
+		{
+			name:              "all instances",
+			req:               &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID},
+			expectedEndpoints: endpoints,
+		},
+		{
+			name:              "selected indices",
+			req:               &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID, Indices: []int32{0, 2}},
+			expectedEndpoints: []pcap.AgentEndpoint{endpoints[0], endpoints[2]},
+		},
+		{
+			name:        "index does not exist",
+			req:         &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID, Indices: []int32{5}},
+			expectedErr: pcap.ErrNoEndpoints,
+		},
+		{
+			name:        "app type does not exist",
+			req:         &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID, AppType: &workerType},
+			expectedErr: pcap.ErrNoEndpoints,
+		},
+		{
+			name:        "unknown app",
+			req:         &pcap.CloudfoundryRequest{Token: cfToken, AppId: "0d5b8b0e-7f3a-4c1e-9a6b-2e4f6a8c0b1d"},
+			expectedErr: pcap.ErrNoEndpoints,
+		},
+		{
+			name:        "app id is not a guid",
+			req:         &pcap.CloudfoundryRequest{Token: cfToken, AppId: "../../v2/info"},
+			expectedErr: pcap.ErrValidationFailed,
+		},
+		{
+			name:        "token rejected",
+			req:         &pcap.CloudfoundryRequest{Token: "invalid", AppId: cfAppID},
+			expectedErr: pcap.ErrNotAuthorized,
+		},
+		{
+			name:        "invalid request",
+			req:         &pcap.CloudfoundryRequest{AppId: cfAppID},
+			expectedErr: pcap.ErrValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &pcap.EndpointRequest{Request: &pcap.EndpointRequest_Cf{Cf: tt.req}}
+
+			agentEndpoints, err := cfResolver.Resolve(request, zap.L())
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Errorf("expectedErr = %v, actualErr = %v", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("received unexpected error = %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.expectedEndpoints, agentEndpoints) {
+				t.Errorf("endpoint mismatch: expected = %v, actual = %v", tt.expectedEndpoints, agentEndpoints)
+			}
+		})
+	}
+}
+
+// TestCloudfoundryResolveForeignNextPage verifies that pages of processes that are not served by the Cloud Controller
+// are not fetched, so the token is not sent to them.
+func TestCloudfoundryResolveForeignNextPage(t *testing.T) {
+	var requests atomic.Int32
+	foreign := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		writer.WriteHeader(http.StatusNotFound)
+	}))
+	defer foreign.Close()
+
+	responses := map[string]string{
+		fmt.Sprintf("/v3/apps/%v", cfAppID):           `{"guid": "` + cfAppID + `", "name": "test-app", "state": "STARTED"}`,
+		fmt.Sprintf("/v3/apps/%v/processes", cfAppID): `{"pagination": {"next": {"href": "` + foreign.URL + `/v3/apps/` + cfAppID + `/processes?page=2"}}, "resources": []}`,
+	}
+
+	cfResolver, ccAPI, err := mock.NewCloudfoundryResolverWithMockCloudController(responses, cfToken)
+	if err != nil {
+		t.Fatalf("received unexpected error = %v", err)
+	}
+	defer ccAPI.Close()
+
+	request := &pcap.EndpointRequest{Request: &pcap.EndpointRequest_Cf{Cf: &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID}}}
+
+	_, err = cfResolver.Resolve(request, zap.L())
+	if err == nil {
+		t.Error("expected an error for a next page that is not served by the Cloud Controller")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no requests to the foreign server, got %d", n)
+	}
+}
+
+// TestCloudfoundryResolveUnexpectedResponse verifies that the body of unexpected responses of the Cloud Controller is
+// not part of the error, which is returned to the client.
+func TestCloudfoundryResolveUnexpectedResponse(t *testing.T) {
+	ccAPI := mock.NewMockCloudControllerAPI(nil, cfToken)
+	defer ccAPI.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/v3/info", ccAPI.Config.Handler)
+	mux.HandleFunc("/", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+		_, _ = writer.Write([]byte("internal details"))
+	})
+	failing := httptest.NewServer(mux)
+	defer failing.Close()
+
+	cfResolver, err := pcap.NewCloudfoundryResolver(pcap.CloudfoundryResolverConfig{RawCcURL: failing.URL, AgentPort: 8083})
+	if err != nil {
+		t.Fatalf("received unexpected error = %v", err)
+	}
+
+	request := &pcap.EndpointRequest{Request: &pcap.EndpointRequest_Cf{Cf: &pcap.CloudfoundryRequest{Token: cfToken, AppId: cfAppID}}}
+
+	_, err = cfResolver.Resolve(request, zap.L())
+	if err == nil {
+		t.Fatal("expected an error for an unexpected response")
+	}
+	if !strings.Contains(err.Error(), "500") || strings.Contains(err.Error(), "internal details") {
+		t.Errorf("expected the status code but not the body in the error, got %v", err)
+	}
+}
+
+func TestCloudfoundryCanResolveEndpointRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		req            *pcap.EndpointRequest
+		expectedResult bool
+	}{
+		{
+			name: "BoshRequest",
+			req: &pcap.EndpointRequest{
+				Request: &pcap.EndpointRequest_Bosh{
+					Bosh: &pcap.BoshRequest{},
+				},
+			},
+			expectedResult: false,
+		},
+		{
+			name: "CFRequest",
+			req: &pcap.EndpointRequest{
+				Request: &pcap.EndpointRequest_Cf{
+					Cf: &pcap.CloudfoundryRequest{},
+				},
+			},
+			expectedResult: true,
+		},
+		{
+			name:           "nil request",
+			req:            nil,
+			expectedResult: false,
+		},
+	}
+
+	cfResolver, _, err := mock.NewCloudfoundryResolverWithMockCloudController(nil, cfToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := cfResolver.CanResolve(tt.req)
+			if tt.expectedResult != result {
+				t.Errorf("expectedResult = %v, result = %v", tt.expectedResult, result)
+			}
+		})
+	}
+}
@@ -17,6 +17,9 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 var apiClient pcap.APIClient
@@ -60,7 +63,7 @@ var _ = Describe("Using LocalResolver", func() {
 				targets = append(targets, agentTarget2)
 
 				apiBuffConf := pcap.BufferConf{Size: 200, UpperLimit: 198, LowerLimit: 180} //nolint:mnd // Values for a test
-				apiClient, apiServer, api, _ = createAPIwithLocalResolver(targets, apiBuffConf, nil, apiID)
+				apiClient, apiServer, api, apiAddr = createAPIwithLocalResolver(targets, apiBuffConf, nil, apiID)
 
 				defaultOptions = &pcap.CaptureOptions{
 					Device:  loopback,
@@ -170,13 +173,108 @@ var _ = Describe("Using LocalResolver", func() {
 				}()
 
 				_, messages, _ := recvCapture(500, stream)
-				Expect(containsMsgTypeWithOrigin(messages, pcap.MessageType_INSTANCE_UNAVAILABLE, agentTarget1.Identifier)).To(BeTrue())
+				Expect(containsCaptureStopped(messages, agentTarget1.Identifier, pcap.StopReason_DRAINING)).To(BeTrue())
 
 				err = stream.Send(pcap.MakeStopRequest())
 				Expect(err).NotTo(HaveOccurred(), "Sending stop message")
 
 				messages = readAndExpectCleanEnd(stream)
 				Expect(containsMsgTypeWithOrigin(messages, pcap.MessageType_CAPTURE_STOPPED, agentTarget2.Identifier)).To(BeTrue())
+
+				summary := messages[len(messages)-1].GetSummary()
+				Expect(summary).NotTo(BeNil(), "capture summary is the last response")
+				for _, agent := range summary.Agents {
+					if agent.Origin == agentTarget1.Identifier {
+						Expect(agent.StopReason).To(Equal(pcap.StopReason_DRAINING))
+					} else {
+						Expect(agent.StopReason).To(Equal(pcap.StopReason_CLIENT_STOP))
+					}
+				}
+			})
+			It("stops when the packet limit is reached", func() {
+				defaultOptions.Limits = &pcap.CaptureLimits{MaxPackets: 20}
+				stream, err := createStreamAndStartCapture(defaultOptions)
+				Expect(err).NotTo(HaveOccurred(), "Sending the request")
+
+				messages := readAndExpectCleanEnd(stream)
+
+				packets := 0
+				for _, message := range messages {
+					if message.GetPacket() != nil {
+						packets++
+					}
+				}
+				Expect(packets).To(Equal(20))
+				Expect(containsMsgTypeWithOrigin(messages, pcap.MessageType_LIMIT_REACHED, apiID)).To(BeTrue())
+			})
+			It("stops when the duration limit is reached", func() {
+				// no traffic is expected for this filter, the capture must stop anyway.
+				defaultOptions.Filter = "host 192.0.2.1"
+				defaultOptions.Limits = &pcap.CaptureLimits{MaxDuration: durationpb.New(2 * time.Second)}
+				stream, err := createStreamAndStartCapture(defaultOptions)
+				Expect(err).NotTo(HaveOccurred(), "Sending the request")
+
+				messages := readAndExpectCleanEnd(stream)
+				Expect(containsMsgTypeWithOrigin(messages, pcap.MessageType_LIMIT_REACHED, apiID)).To(BeTrue())
+			})
+			It("captures on multiple devices", func() {
+				defaultOptions.Devices = []string{loopback, "any"}
+				stream, err := createStreamAndStartCapture(defaultOptions)
+				Expect(err).NotTo(HaveOccurred(), "Sending the request")
+
+				_, messages, err := recvCapture(100, stream)
+				Expect(err).NotTo(HaveOccurred(), "Receiving the first messages")
+
+				devices := map[string]bool{}
+				for _, message := range messages {
+					if packet := message.GetPacket(); packet != nil {
+						devices[packet.Interface] = true
+					}
+				}
+				Expect(devices).To(HaveKey(loopback))
+				Expect(devices).To(HaveKey("any"))
+
+				err = stream.Send(pcap.MakeStopRequest())
+				Expect(err).NotTo(HaveOccurred(), "Sending stop message")
+				_ = readAndExpectCleanEnd(stream)
+			})
+			It("rejects an invalid filter", func() {
+				defaultOptions.Filter = "port 443 and"
+				stream, err := createStreamAndStartCapture(defaultOptions)
+				Expect(err).NotTo(HaveOccurred(), "Sending the request")
+
+				errCode, messages, err := recvCapture(10, stream)
+				Expect(err).To(HaveOccurred(), "Error occurred due to invalid filter")
+				Expect(errCode).To(Equal(codes.InvalidArgument))
+				Expect(containsMsgTypeWithOrigin(messages, pcap.MessageType_CAPTURE_STOPPED, agentTarget1.Identifier)).To(BeFalse())
+			})
+			It("lists the interfaces of all agents", func() {
+				res, err := apiClient.ListInterfaces(context.Background(), &pcap.ListInstanceInterfacesRequest{
+					Request: &pcap.EndpointRequest{Request: &pcap.EndpointRequest_Bosh{Bosh: &pcap.BoshRequest{
+						Token:      "123",
+						Deployment: "cf",
+						Groups:     []string{"router"},
+					}}},
+				})
+				Expect(err).NotTo(HaveOccurred(), "Listing interfaces")
+				Expect(res.Instances).To(HaveLen(2))
+
+				for _, instance := range res.Instances {
+					Expect(instance.Error).To(BeEmpty())
+					Expect(instance.Interfaces).To(ContainElement(HaveField("Name", loopback)))
+				}
+
+				agentServer2.GracefulStop()
+
+				res, err = apiClient.ListInterfaces(context.Background(), &pcap.ListInstanceInterfacesRequest{
+					Request: &pcap.EndpointRequest{Request: &pcap.EndpointRequest_Bosh{Bosh: &pcap.BoshRequest{
+						Token:      "123",
+						Deployment: "cf",
+						Groups:     []string{"router"},
+					}}},
+				})
+				Expect(err).NotTo(HaveOccurred(), "Listing interfaces with one agent unavailable")
+				Expect(res.Instances).To(ContainElement(And(HaveField("Identifier", agentID2), HaveField("Error", Not(BeEmpty())))))
 			})
 			It("api drains", func() {
 				stream, err := createStreamAndStartCapture(defaultOptions)
@@ -201,6 +299,36 @@ var _ = Describe("Using LocalResolver", func() {
 				Expect(statusResponse.Healthy).To(BeFalse())
 
 			})
+			It("reuses the connections to the agents for repeated captures", func() {
+				for i := 0; i < 3; i++ {
+					stream, err := createStreamAndStartCapture(defaultOptions)
+					Expect(err).NotTo(HaveOccurred(), "Sending the request")
+
+					readAndExpectFirstMessages(stream)
+
+					err = stream.Send(pcap.MakeStopRequest())
+					Expect(err).NotTo(HaveOccurred(), "Sending stop message")
+					_ = readAndExpectCleanEnd(stream)
+
+					Expect(api.AgentConnections()).To(Equal(2), "one connection per agent")
+				}
+			})
+			It("reports the serving status through the grpc health service", func() {
+				cc, err := grpc.Dial(apiAddr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+				Expect(err).NotTo(HaveOccurred())
+				defer cc.Close()
+				healthClient := grpc_health_v1.NewHealthClient(cc)
+
+				res, err := healthClient.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "pcap.API"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_SERVING))
+
+				api.Stop()
+
+				res, err = healthClient.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.Status).To(Equal(grpc_health_v1.HealthCheckResponse_NOT_SERVING))
+			})
 		})
 
 		Context("with one agent and one API", func() {
@@ -393,7 +521,7 @@ var _ = Describe("Using LocalResolver", func() {
 				_ = os.Remove(file) // remove test-file
 
 				logger, _ := zap.NewDevelopment(zap.IncreaseLevel(zap.InfoLevel))
-				client, err := pcap.NewClient(file, logger, pcap.LogMessageWriter{Log: logger})
+				client, err := pcap.NewClient(file, pcap.FormatPcap, logger, pcap.LogMessageWriter{Log: logger})
 				Expect(err).To(BeNil())
 
 				apiURL := mock.MustParseURL(fmt.Sprintf("http://%s", apiAddr.String()))
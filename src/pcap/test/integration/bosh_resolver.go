@@ -3,15 +3,19 @@ package integration
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"net"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"time"
 
 	"github.com/cloudfoundry/pcap-release/src/pcap"
 	"github.com/cloudfoundry/pcap-release/src/pcap/test/mock"
 
+	"github.com/gopacket/gopacket/layers"
+	gopcap "github.com/gopacket/gopacket/pcap"
 	. "github.com/onsi/ginkgo/v2" //nolint:revive,stylecheck // this is the common way to import ginkgo and gomega
 	. "github.com/onsi/gomega"    //nolint:revive,stylecheck // this is the common way to import ginkgo and gomega
 	"go.uber.org/zap"
@@ -97,7 +101,7 @@ var _ = Describe("Client to API with Bosh Resolver", func() {
 				Expect(err).ShouldNot(HaveOccurred(), "failed getting API status")
 
 				logger, _ := zap.NewDevelopment()
-				client, err = pcap.NewClient("test.pcap", logger, messageWriter)
+				client, err = pcap.NewClient("test.pcap", pcap.FormatPcap, logger, messageWriter)
 				Expect(err).ShouldNot(HaveOccurred(), "failed initializing client")
 
 				err = client.ConnectToAPI(apiURL, false)
@@ -144,6 +148,21 @@ var _ = Describe("Client to API with Bosh Resolver", func() {
 					Expect(messageWriter.Filter(pcap.MessageType_CAPTURE_STOPPED)).Should(HaveLen(2))
 				})
 
+				It("summarizes the capture of each agent", func() {
+					ctx, cancel := context.WithCancelCause(context.Background())
+					err := client.CaptureRequest(ctx, cancel, endpointRequest, defaultOptions)
+					Expect(err).ShouldNot(HaveOccurred(), "capture request failed")
+
+					summary := client.Summary()
+					Expect(summary).ShouldNot(BeNil(), "capture summary was not received")
+					Expect(summary.Agents).Should(HaveLen(2))
+					for _, agent := range summary.Agents {
+						Expect(agent.StopReason).Should(Equal(pcap.StopReason_CLIENT_STOP))
+						Expect(agent.Start).ShouldNot(BeNil())
+						Expect(agent.Stop).ShouldNot(BeNil())
+					}
+				})
+
 				It("completes successfully for one agent in an instance group", func() {
 					endpointRequest.GetBosh().Groups = []string{"router"}
 
@@ -189,6 +208,60 @@ var _ = Describe("Client to API with Bosh Resolver", func() {
 					Expect(messages[0].Origin).Should(Equal(agentID2))
 				})
 
+				It("writes the link type of the agents to the file header", func() {
+					endpointRequest.GetBosh().Groups = []string{"router"}
+					options := &pcap.CaptureOptions{
+						Device:  "any",
+						Filter:  defaultOptions.Filter,
+						SnapLen: defaultOptions.SnapLen,
+					}
+
+					ctx, cancel := context.WithCancelCause(context.Background())
+					err := client.CaptureRequest(ctx, cancel, endpointRequest, options)
+					Expect(err).ShouldNot(HaveOccurred(), "capture request failed")
+
+					handle, err := gopcap.OpenOffline("test.pcap")
+					Expect(err).ShouldNot(HaveOccurred(), "could not open capture file")
+					defer handle.Close()
+
+					Expect(handle.LinkType()).To(Equal(layers.LinkTypeLinuxSLL))
+				})
+
+				It("writes the nanosecond pcap format when requested", func() {
+					options := &pcap.CaptureOptions{
+						Device:             defaultOptions.Device,
+						Filter:             defaultOptions.Filter,
+						SnapLen:            defaultOptions.SnapLen,
+						TimestampPrecision: pcap.TimestampPrecision_NANOSECONDS,
+					}
+
+					ctx, cancel := context.WithCancelCause(context.Background())
+					err := client.CaptureRequest(ctx, cancel, endpointRequest, options)
+					Expect(err).ShouldNot(HaveOccurred(), "capture request failed")
+
+					file, err := os.ReadFile("test.pcap")
+					Expect(err).ShouldNot(HaveOccurred(), "could not read capture file")
+					Expect(len(file)).To(BeNumerically(">=", 4))
+					Expect(binary.LittleEndian.Uint32(file[:4])).To(Equal(uint32(0xa1b23c4d)), "nanosecond pcap magic")
+				})
+
+				It("captures with credit-based flow control", func() {
+					options := &pcap.CaptureOptions{
+						Device:      defaultOptions.Device,
+						Filter:      defaultOptions.Filter,
+						SnapLen:     defaultOptions.SnapLen,
+						FlowControl: pcap.FlowControl_CREDIT,
+					}
+
+					ctx, cancel := context.WithCancelCause(context.Background())
+					err := client.CaptureRequest(ctx, cancel, endpointRequest, options)
+					Expect(err).ShouldNot(HaveOccurred(), "capture request failed")
+
+					handle, err := gopcap.OpenOffline("test.pcap")
+					Expect(err).ShouldNot(HaveOccurred(), "could not open capture file")
+					handle.Close()
+				})
+
 				It("fails when selecting a non-existent instance ID", func() {
 					// instance IDs are taken from agentID1 and agentID2
 					endpointRequest.GetBosh().Instances = []string{"this-id-does-not-exist"}
@@ -260,7 +333,7 @@ var _ = Describe("Client to API with Bosh Resolver", func() {
 				Expect(err).ShouldNot(HaveOccurred(), "failed getting API status")
 
 				logger, _ := zap.NewDevelopment()
-				client, err = pcap.NewClient("test.pcap", logger, messageWriter)
+				client, err = pcap.NewClient("test.pcap", pcap.FormatPcap, logger, messageWriter)
 				Expect(err).ShouldNot(HaveOccurred(), "failed initializing client")
 
 				err = client.ConnectToAPI(apiURL, false)
@@ -306,7 +379,7 @@ var _ = Describe("Client to API with Bosh Resolver", func() {
 				Expect(err).ShouldNot(HaveOccurred(), "failed getting API status")
 
 				logger, _ := zap.NewDevelopment()
-				client, err = pcap.NewClient("test.pcap", logger, messageWriter)
+				client, err = pcap.NewClient("test.pcap", pcap.FormatPcap, logger, messageWriter)
 				Expect(err).ShouldNot(HaveOccurred(), "failed initializing client")
 
 				err = client.ConnectToAPI(apiURL, false)
@@ -32,6 +32,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -247,7 +248,7 @@ func createAgent(port int, id string, tlsCreds credentials.TransportCredentials)
 	var err error
 	var server *grpc.Server
 
-	agent := pcap.NewAgent(pcap.BufferConf{Size: 10000, UpperLimit: 9800, LowerLimit: 8000}, id)
+	agent := pcap.NewAgent(pcap.NodeConfig{Buffer: pcap.BufferConf{Size: 10000, UpperLimit: 9800, LowerLimit: 8000}, ID: id}, pcap.AgentConf{})
 
 	listener := localNodeListener(port)
 	tcpAddr, ok := listener.Addr().(*net.TCPAddr)
@@ -256,11 +257,12 @@ func createAgent(port int, id string, tlsCreds credentials.TransportCredentials)
 
 	target := pcap.AgentEndpoint{IP: tcpAddr.IP.String(), Port: tcpAddr.Port, Identifier: id}
 	if tlsCreds != nil {
-		server = grpc.NewServer(grpc.Creds(tlsCreds))
+		server = grpc.NewServer(grpc.Creds(tlsCreds), pcap.AgentServerKeepalive())
 	} else {
-		server = grpc.NewServer()
+		server = grpc.NewServer(pcap.AgentServerKeepalive())
 	}
 	pcap.RegisterAgentServer(server, agent)
+	grpc_health_v1.RegisterHealthServer(server, pcap.NewAgentHealthServer(agent))
 	go func() {
 		err = server.Serve(listener)
 		if err != nil {
@@ -279,10 +281,11 @@ func createAgent(port int, id string, tlsCreds credentials.TransportCredentials)
 
 func createAPI(resolver pcap.AgentResolver, bufConf pcap.BufferConf, mTLSConfig *pcap.ClientTLS, id string) (pcap.APIClient, *grpc.Server, *pcap.API, net.Addr) {
 	var server *grpc.Server
-	api, err := pcap.NewAPI(bufConf, mTLSConfig, id, MaxConcurrentCaptures)
+	api, err := pcap.NewAPI(pcap.NodeConfig{Buffer: bufConf, ID: id}, pcap.APIConf{AgentsMTLS: mTLSConfig, ConcurrentCaptures: MaxConcurrentCaptures})
 	Expect(err).NotTo(HaveOccurred())
 
 	api.RegisterResolver(resolver)
+	api.WatchResolverHealth()
 
 	listener := localNodeListener(APIPort)
 
@@ -290,6 +293,7 @@ func createAPI(resolver pcap.AgentResolver, bufConf pcap.BufferConf, mTLSConfig
 
 	server = grpc.NewServer()
 	pcap.RegisterAPIServer(server, api)
+	grpc_health_v1.RegisterHealthServer(server, pcap.NewAPIHealthServer(api))
 
 	go func() {
 		err = server.Serve(listener)
@@ -346,6 +350,17 @@ func containsMsgTypeWithOrigin(messages []*pcap.CaptureResponse, msgType pcap.Me
 	return false
 }
 
+// containsCaptureStopped returns true if messages contain a CAPTURE_STOPPED message of origin with the given reason.
+func containsCaptureStopped(messages []*pcap.CaptureResponse, origin string, reason pcap.StopReason) bool {
+	for _, msg := range messages {
+		if msg.GetMessage().GetType() == pcap.MessageType_CAPTURE_STOPPED && msg.GetMessage().GetOrigin() == origin && msg.GetMessage().GetStopReason() == reason {
+			return true
+		}
+	}
+
+	return false
+}
+
 func NewMemoryMessageWriter() *MemoryMessageWriter {
 	return &MemoryMessageWriter{Messages: make([]*pcap.Message, 0, 10)} //nolint:mnd // Default configuration
 }
@@ -15,6 +15,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -188,6 +189,107 @@ func NewMockBoshDirectorAPI(responses map[string]string, url string) *httptest.S
 	return ts
 }
 
+// NewCloudfoundryResolverWithMockCloudController creates a CloudfoundryResolver that is connected to a mock Cloud Controller,
+// which serves responses for requests that use the bearer token.
+func NewCloudfoundryResolverWithMockCloudController(responses map[string]string, token string) (*pcap.CloudfoundryResolver, *httptest.Server, error) {
+	ccAPI := NewMockCloudControllerAPI(responses, token)
+
+	config := pcap.CloudfoundryResolverConfig{
+		RawCcURL:  ccAPI.URL,
+		AgentPort: 8083,
+	}
+
+	cfResolver, err := pcap.NewCloudfoundryResolver(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfResolver, ccAPI, nil
+}
+
+// NewCloudfoundryResolverWithMockCloudControllerWithEndpoints creates a CloudfoundryResolver with a mock Cloud Controller,
+// which contains the app with appID with a single web process. Each endpoint is a running instance of that process,
+// the index is taken from the endpoint identifier (app-guid/index).
+func NewCloudfoundryResolverWithMockCloudControllerWithEndpoints(appID string, endpoints []pcap.AgentEndpoint, token string) (*pcap.CloudfoundryResolver, *httptest.Server, error) {
+	processID := "proc-" + appID
+
+	var instances []pcap.CloudfoundryProcessInstance
+	for _, endpoint := range endpoints {
+		parts := strings.Split(endpoint.Identifier, "/")
+		index, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			panic(err)
+		}
+
+		instances = append(instances, pcap.CloudfoundryProcessInstance{
+			Type:               pcap.DefaultCloudfoundryAppType,
+			Index:              index,
+			State:              "RUNNING",
+			Host:               "10.0.16.1",
+			InstanceInternalIP: endpoint.IP,
+		})
+	}
+
+	app := mustMarshal(pcap.CloudfoundryApp{GUID: appID, Name: "test-app", State: "STARTED"})
+	processes := mustMarshal(map[string]any{
+		"pagination": map[string]any{"next": nil},
+		"resources": []pcap.CloudfoundryProcess{
+			{GUID: processID, Type: pcap.DefaultCloudfoundryAppType, Instances: len(instances)},
+		},
+	})
+	stats := mustMarshal(map[string]any{"resources": instances})
+
+	responses := map[string]string{
+		fmt.Sprintf("/v3/apps/%v", appID):                app,
+		fmt.Sprintf("/v3/apps/%v/processes", appID):      processes,
+		fmt.Sprintf("/v3/processes/%v/stats", processID): stats,
+	}
+
+	return NewCloudfoundryResolverWithMockCloudController(responses, token)
+}
+
+// NewMockCloudControllerAPI creates a mock Cloud Controller that serves /v3/info without authentication and
+// all other responses only if the request carries token as bearer token.
+func NewMockCloudControllerAPI(responses map[string]string, token string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/info", func(writer http.ResponseWriter, _ *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		_, err := writer.Write([]byte(`{"name": "cf-deployment", "build": "v30.0.0", "version": 30, "description": "mock cloud controller"}`))
+		if err != nil {
+			zap.L().Panic("failed to write cc /v3/info response", zap.Error(err))
+		}
+	})
+
+	mux.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		if request.Header.Get("Authorization") != "Bearer "+token {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		response, ok := responses[request.URL.Path]
+		if !ok {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_, err := writer.Write([]byte(response))
+		if err != nil {
+			zap.L().Panic("failed to write cc / response", zap.Error(err))
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func mustMarshal(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
 func verifyJWTTokenMock(jku string) (string, string) {
 	type payload struct {
 		Scope     []string  `json:"scope"`
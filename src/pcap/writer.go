@@ -0,0 +1,292 @@
+package pcap
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+)
+
+// OutputFormat defines the file format the Client writes captured packets in.
+type OutputFormat string
+
+const (
+	// FormatPcap writes classic pcap files with a single link type header. The origin of packets is lost and
+	// all agents must capture packets with the same link type.
+	FormatPcap OutputFormat = "pcap"
+	// FormatPcapng writes pcapng files with one interface per agent and device, which allows to identify the
+	// origin of each packet.
+	FormatPcapng OutputFormat = "pcapng"
+)
+
+// PacketWriter writes packets received from the pcap-api to the output file.
+type PacketWriter interface {
+	// SetLinkType records the link type of the packets captured by origin on device. It must be called before
+	// the first packet of origin and device is written, packets of unknown origins are written as Ethernet.
+	// Returns an error if the link type can not be represented in the output file.
+	SetLinkType(origin string, device string, linkType layers.LinkType) error
+	// SetTimestampPrecision records the precision of the timestamps of the packets captured by origin on device,
+	// which may be lower than requested. Like SetLinkType, it must be called before the first packet of origin and
+	// device is written.
+	SetTimestampPrecision(origin string, device string, precision TimestampPrecision) error
+	// WritePacket writes a single packet.
+	WritePacket(packet *Packet) error
+	// Flush writes any buffered data. Must be called before the output file is closed.
+	Flush() error
+}
+
+// NewPacketWriter creates the PacketWriter for format, which writes to w. Timestamps are written with the requested
+// precision, unless an agent reports a lower precision, see PacketWriter.SetTimestampPrecision.
+func NewPacketWriter(w io.Writer, format OutputFormat, snapLen uint32, precision TimestampPrecision) (PacketWriter, error) {
+	switch format {
+	case FormatPcap, "":
+		return newPcapWriter(w, snapLen, precision), nil
+	case FormatPcapng:
+		return newPcapngWriter(w, snapLen, precision), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// captureInfo converts the metadata of packet to gopacket.CaptureInfo for the given interface index.
+func captureInfo(packet *Packet, interfaceIndex int) gopacket.CaptureInfo {
+	return gopacket.CaptureInfo{
+		Timestamp:      packet.Timestamp.AsTime(),
+		CaptureLength:  len(packet.Data),
+		Length:         int(packet.Length),
+		InterfaceIndex: interfaceIndex,
+		AncillaryData:  nil,
+	}
+}
+
+// pcapWriter writes classic pcap files. The file header contains a single link type, which is why the packets of
+// all origins must have the same link type.
+//
+// The file header is written lazily as the link type and timestamp precision are not known upfront.
+type pcapWriter struct {
+	out           io.Writer
+	w             *pcapgo.Writer
+	snapLen       uint32
+	linkType      *layers.LinkType
+	precision     TimestampPrecision
+	headerWritten bool
+}
+
+// newPcapWriter creates a pcapWriter, which writes the nanosecond pcap format if precision is NANOSECONDS and all
+// agents capture with nanosecond timestamps.
+func newPcapWriter(w io.Writer, snapLen uint32, precision TimestampPrecision) *pcapWriter {
+	return &pcapWriter{
+		out:       w,
+		snapLen:   snapLen,
+		precision: precision,
+	}
+}
+
+// SetLinkType sets the link type of the file header. Returns an error if a different link type has been set before,
+// as pcap files only support a single link type.
+func (p *pcapWriter) SetLinkType(origin string, device string, linkType layers.LinkType) error {
+	if p.linkType == nil && !p.headerWritten {
+		p.linkType = &linkType
+		return nil
+	}
+
+	if current := p.currentLinkType(); current != linkType {
+		return fmt.Errorf("%s captures on %s with link type %s, which differs from %s: use the pcapng format to mix link types", origin, device, linkType, current)
+	}
+
+	return nil
+}
+
+// SetTimestampPrecision lowers the precision of the file header to MICROSECONDS if an origin captures with microsecond
+// timestamps. Once the header has been written, its precision can not be changed anymore: microsecond timestamps are
+// still written correctly to nanosecond files.
+func (p *pcapWriter) SetTimestampPrecision(_ string, _ string, precision TimestampPrecision) error {
+	if !p.headerWritten && precision == TimestampPrecision_MICROSECONDS {
+		p.precision = precision
+	}
+	return nil
+}
+
+func (p *pcapWriter) WritePacket(packet *Packet) error {
+	err := p.writeHeader()
+	if err != nil {
+		return err
+	}
+
+	return p.w.WritePacket(captureInfo(packet, 0), packet.Data)
+}
+
+// Flush writes the file header if no packet has been written. pcapgo.Writer does not buffer.
+func (p *pcapWriter) Flush() error {
+	return p.writeHeader()
+}
+
+// writeHeader writes the file header with the current link type once.
+func (p *pcapWriter) writeHeader() error {
+	if p.headerWritten {
+		return nil
+	}
+
+	p.w = pcapgo.NewWriter(p.out)
+	if p.precision == TimestampPrecision_NANOSECONDS {
+		p.w = pcapgo.NewWriterNanos(p.out)
+	}
+
+	err := p.w.WriteFileHeader(p.snapLen, p.currentLinkType())
+	if err != nil {
+		return err
+	}
+
+	p.headerWritten = true
+	return nil
+}
+
+// currentLinkType returns the link type that has been set or Ethernet if none has been set.
+func (p *pcapWriter) currentLinkType() layers.LinkType {
+	if p.linkType == nil {
+		return layers.LinkTypeEthernet
+	}
+	return *p.linkType
+}
+
+// pcapngSource identifies the device of an origin, which is written as one interface.
+type pcapngSource struct {
+	origin string
+	device string
+}
+
+// name of the interface, the origin and device separated by a slash, or only the origin if the device is unknown.
+func (s pcapngSource) name() string {
+	if s.device == "" {
+		return s.origin
+	}
+	return s.origin + "/" + s.device
+}
+
+// pcapngWriter writes pcapng files. Each device of each origin is written as separate Interface Description Block,
+// which is named after the origin and device and has the link type of that device. Packets are tagged with the
+// interface of their origin and device.
+//
+// pcapgo always writes nanosecond timestamps (if_tsresol 9). Interfaces that capture with microsecond timestamps are
+// marked with a comment, their timestamps are multiples of a microsecond.
+//
+// The section header and interfaces are written lazily as the origins are not known upfront.
+type pcapngWriter struct {
+	out        io.Writer
+	w          *pcapgo.NgWriter
+	snapLen    uint32
+	interfaces map[pcapngSource]int
+	linkTypes  map[pcapngSource]layers.LinkType
+	// precision is the requested precision, which is used for interfaces without a precision.
+	precision  TimestampPrecision
+	precisions map[pcapngSource]TimestampPrecision
+}
+
+func newPcapngWriter(w io.Writer, snapLen uint32, precision TimestampPrecision) *pcapngWriter {
+	return &pcapngWriter{
+		out:        w,
+		snapLen:    snapLen,
+		interfaces: make(map[pcapngSource]int),
+		linkTypes:  make(map[pcapngSource]layers.LinkType),
+		precision:  precision,
+		precisions: make(map[pcapngSource]TimestampPrecision),
+	}
+}
+
+// SetLinkType records the link type of the interface of origin and device. Each interface has its own link type,
+// so any combination of link types is supported.
+func (p *pcapngWriter) SetLinkType(origin string, device string, linkType layers.LinkType) error {
+	source := pcapngSource{origin: origin, device: device}
+	if _, ok := p.interfaces[source]; ok && p.linkType(source) != linkType {
+		return fmt.Errorf("link type of %q changed after packets have been written", source.name())
+	}
+
+	p.linkTypes[source] = linkType
+	return nil
+}
+
+// SetTimestampPrecision records the timestamp precision of the interface of origin and device.
+func (p *pcapngWriter) SetTimestampPrecision(origin string, device string, precision TimestampPrecision) error {
+	source := pcapngSource{origin: origin, device: device}
+	if _, ok := p.interfaces[source]; ok && p.timestampPrecision(source) != precision {
+		return fmt.Errorf("timestamp precision of %q changed after packets have been written", source.name())
+	}
+
+	p.precisions[source] = precision
+	return nil
+}
+
+func (p *pcapngWriter) WritePacket(packet *Packet) error {
+	id, err := p.interfaceID(pcapngSource{origin: packet.Origin, device: packet.Interface})
+	if err != nil {
+		return err
+	}
+
+	return p.w.WritePacket(captureInfo(packet, id), packet.Data)
+}
+
+// Flush writes the buffered data. If no packet has been written, an empty section with a single interface is written,
+// to ensure the output is a valid pcapng file.
+func (p *pcapngWriter) Flush() error {
+	if p.w == nil {
+		_, err := p.interfaceID(pcapngSource{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return p.w.Flush()
+}
+
+// interfaceID returns the interface id for source and adds a new interface if this source has not been seen yet.
+func (p *pcapngWriter) interfaceID(source pcapngSource) (int, error) {
+	if id, ok := p.interfaces[source]; ok {
+		return id, nil
+	}
+
+	intf := pcapgo.NgInterface{
+		Name:                source.name(),
+		LinkType:            p.linkType(source),
+		SnapLength:          p.snapLen,
+		TimestampResolution: pcapgo.DefaultNgInterface.TimestampResolution,
+	}
+	if p.timestampPrecision(source) == TimestampPrecision_MICROSECONDS {
+		intf.Comment = "captured with microsecond timestamps"
+	}
+
+	var (
+		id  int
+		err error
+	)
+	if p.w == nil {
+		p.w, err = pcapgo.NewNgWriterInterface(p.out, intf, pcapgo.DefaultNgWriterOptions)
+	} else {
+		id, err = p.w.AddInterface(intf)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("add interface for %q: %w", source.name(), err)
+	}
+
+	p.interfaces[source] = id
+	return id, nil
+}
+
+// linkType returns the link type of source or Ethernet if none has been set.
+func (p *pcapngWriter) linkType(source pcapngSource) layers.LinkType {
+	linkType, ok := p.linkTypes[source]
+	if !ok {
+		return layers.LinkTypeEthernet
+	}
+	return linkType
+}
+
+// timestampPrecision returns the timestamp precision of source or the requested precision if none has been set.
+func (p *pcapngWriter) timestampPrecision(source pcapngSource) TimestampPrecision {
+	precision, ok := p.precisions[source]
+	if !ok {
+		return p.precision
+	}
+	return precision
+}
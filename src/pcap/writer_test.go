@@ -0,0 +1,410 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestNewPacketWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  OutputFormat
+		wantErr bool
+	}{
+		{name: "default format", format: "", wantErr: false},
+		{name: "pcap", format: FormatPcap, wantErr: false},
+		{name: "pcapng", format: FormatPcapng, wantErr: false},
+		{name: "unknown format", format: "txt", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := NewPacketWriter(&buf, test.format, 65000, TimestampPrecision_MICROSECONDS)
+			if (err != nil) != test.wantErr {
+				t.Errorf("wantErr = %v, error = %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestPcapngWriterInterfacePerOrigin writes packets of several origins and devices and checks that each device of
+// an origin results in one interface, which is named after the origin and device, and that each packet is tagged
+// with the interface of its origin and device.
+func TestPcapngWriterInterfacePerOrigin(t *testing.T) {
+	sources := []pcapngSource{
+		{origin: "router/abc-123", device: "eth0"},
+		{origin: "router/def-456"},
+		{origin: "router/abc-123", device: "eth0"},
+		{origin: "diego-cell/0"},
+		{origin: "router/abc-123", device: "tun0"},
+	}
+	expectedInterfaces := []string{"router/abc-123/eth0", "router/def-456", "diego-cell/0", "router/abc-123/tun0"}
+	expectedInterfaceIndex := []int{0, 1, 0, 2, 3}
+
+	var buf bytes.Buffer
+	writer, err := NewPacketWriter(&buf, FormatPcapng, 65000, TimestampPrecision_MICROSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, source := range sources {
+		packet := &Packet{
+			Data:      examplePacket,
+			Timestamp: timestamppb.New(time.Now()),
+			Length:    int32(len(examplePacket)),
+			Origin:    source.origin,
+			Interface: source.device,
+		}
+		err = writer.WritePacket(packet)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatalf("could not parse pcapng file: %v", err)
+	}
+
+	var interfaceIndices []int
+	for {
+		_, ci, readErr := reader.ReadPacketData()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			t.Fatalf("unexpected error: %v", readErr)
+		}
+		interfaceIndices = append(interfaceIndices, ci.InterfaceIndex)
+	}
+
+	if reader.NInterfaces() != len(expectedInterfaces) {
+		t.Fatalf("expected %d interfaces, got %d", len(expectedInterfaces), reader.NInterfaces())
+	}
+
+	for i, name := range expectedInterfaces {
+		intf, intfErr := reader.Interface(i)
+		if intfErr != nil {
+			t.Fatalf("unexpected error: %v", intfErr)
+		}
+		if intf.Name != name {
+			t.Errorf("expected interface %d to be named %q, got %q", i, name, intf.Name)
+		}
+	}
+
+	if len(interfaceIndices) != len(expectedInterfaceIndex) {
+		t.Fatalf("expected %d packets, got %d", len(expectedInterfaceIndex), len(interfaceIndices))
+	}
+
+	for i, index := range expectedInterfaceIndex {
+		if interfaceIndices[i] != index {
+			t.Errorf("expected packet %d on interface %d, got %d", i, index, interfaceIndices[i])
+		}
+	}
+}
+
+// TestPcapngWriterEmpty ensures that a capture without packets results in a valid pcapng file.
+func TestPcapngWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewPacketWriter(&buf, FormatPcapng, 65000, TimestampPrecision_MICROSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Errorf("could not parse pcapng file: %v", err)
+	}
+}
+
+func TestPcapWriterLinkType(t *testing.T) {
+	tests := []struct {
+		name             string
+		linkTypes        []layers.LinkType
+		expectedLinkType layers.LinkType
+		wantErr          bool
+	}{
+		{name: "unknown link type", linkTypes: nil, expectedLinkType: layers.LinkTypeEthernet},
+		{name: "single link type", linkTypes: []layers.LinkType{layers.LinkTypeLinuxSLL}, expectedLinkType: layers.LinkTypeLinuxSLL},
+		{name: "same link types", linkTypes: []layers.LinkType{layers.LinkTypeRaw, layers.LinkTypeRaw}, expectedLinkType: layers.LinkTypeRaw},
+		{name: "mixed link types", linkTypes: []layers.LinkType{layers.LinkTypeEthernet, layers.LinkTypeLinuxSLL}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewPacketWriter(&buf, FormatPcap, 65000, TimestampPrecision_MICROSECONDS)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, linkType := range test.linkTypes {
+				err = writer.SetLinkType("router/abc-123", "eth0", linkType)
+				if err != nil {
+					break
+				}
+			}
+			if (err != nil) != test.wantErr {
+				t.Fatalf("wantErr = %v, error = %v", test.wantErr, err)
+			}
+			if test.wantErr {
+				return
+			}
+
+			err = writer.Flush()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			reader, err := pcapgo.NewReader(&buf)
+			if err != nil {
+				t.Fatalf("could not parse pcap file: %v", err)
+			}
+			if reader.LinkType() != test.expectedLinkType {
+				t.Errorf("expected link type %v, got %v", test.expectedLinkType, reader.LinkType())
+			}
+		})
+	}
+}
+
+// TestPcapngWriterLinkTypePerInterface checks that each interface is written with the link type of its origin and
+// device, and that interfaces without known link type default to Ethernet.
+func TestPcapngWriterLinkTypePerInterface(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewPacketWriter(&buf, FormatPcapng, 65000, TimestampPrecision_MICROSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.SetLinkType("router/abc-123", "any", layers.LinkTypeLinuxSLL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, source := range []pcapngSource{{origin: "router/abc-123", device: "any"}, {origin: "router/def-456", device: "eth0"}} {
+		err = writer.WritePacket(&Packet{
+			Data:      examplePacket,
+			Timestamp: timestamppb.New(time.Now()),
+			Length:    int32(len(examplePacket)),
+			Origin:    source.origin,
+			Interface: source.device,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	err = writer.SetLinkType("router/abc-123", "any", layers.LinkTypeEthernet)
+	if err == nil {
+		t.Errorf("expected error when changing the link type of an interface with packets")
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatalf("could not parse pcapng file: %v", err)
+	}
+
+	// interfaces are only known to the reader once packets have been read.
+	for {
+		_, _, readErr := reader.ReadPacketData()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			t.Fatalf("unexpected error: %v", readErr)
+		}
+	}
+
+	for i, expected := range []layers.LinkType{layers.LinkTypeLinuxSLL, layers.LinkTypeEthernet} {
+		intf, intfErr := reader.Interface(i)
+		if intfErr != nil {
+			t.Fatalf("unexpected error: %v", intfErr)
+		}
+		if intf.LinkType != expected {
+			t.Errorf("expected interface %d to have link type %v, got %v", i, expected, intf.LinkType)
+		}
+	}
+}
+
+// TestPcapWriterTimestampPrecision checks that the pcap magic matches the requested precision and that nanosecond
+// timestamps are written without loss.
+func TestPcapWriterTimestampPrecision(t *testing.T) {
+	timestamp := time.Date(2023, 5, 4, 10, 11, 12, 123456789, time.UTC)
+
+	tests := []struct {
+		name              string
+		precision         TimestampPrecision
+		agentPrecision    TimestampPrecision
+		expectedMagic     uint32
+		expectedTimestamp time.Time
+	}{
+		{name: "microseconds", precision: TimestampPrecision_MICROSECONDS, agentPrecision: TimestampPrecision_MICROSECONDS, expectedMagic: 0xa1b2c3d4, expectedTimestamp: timestamp.Truncate(time.Microsecond)},
+		{name: "nanoseconds", precision: TimestampPrecision_NANOSECONDS, agentPrecision: TimestampPrecision_NANOSECONDS, expectedMagic: 0xa1b23c4d, expectedTimestamp: timestamp},
+		{name: "agent falls back to microseconds", precision: TimestampPrecision_NANOSECONDS, agentPrecision: TimestampPrecision_MICROSECONDS, expectedMagic: 0xa1b2c3d4, expectedTimestamp: timestamp.Truncate(time.Microsecond)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewPacketWriter(&buf, FormatPcap, 65000, test.precision)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = writer.SetTimestampPrecision("router/abc-123", "eth0", test.agentPrecision)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = writer.WritePacket(&Packet{
+				Data:      examplePacket,
+				Timestamp: timestamppb.New(timestamp),
+				Length:    int32(len(examplePacket)),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			magic := binary.LittleEndian.Uint32(buf.Bytes()[:4])
+			if magic != test.expectedMagic {
+				t.Errorf("expected magic %#x, got %#x", test.expectedMagic, magic)
+			}
+
+			reader, err := pcapgo.NewReader(&buf)
+			if err != nil {
+				t.Fatalf("could not parse pcap file: %v", err)
+			}
+
+			_, ci, err := reader.ReadPacketData()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !ci.Timestamp.Equal(test.expectedTimestamp) {
+				t.Errorf("expected timestamp %v, got %v", test.expectedTimestamp, ci.Timestamp)
+			}
+		})
+	}
+}
+
+// TestPcapngWriterNanosecondTimestamps checks that pcapng files keep the full precision of timestamps.
+func TestPcapngWriterNanosecondTimestamps(t *testing.T) {
+	timestamp := time.Date(2023, 5, 4, 10, 11, 12, 123456789, time.UTC)
+
+	var buf bytes.Buffer
+	writer, err := NewPacketWriter(&buf, FormatPcapng, 65000, TimestampPrecision_NANOSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.WritePacket(&Packet{
+		Data:      examplePacket,
+		Timestamp: timestamppb.New(timestamp),
+		Length:    int32(len(examplePacket)),
+		Origin:    "router/abc-123",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatalf("could not parse pcapng file: %v", err)
+	}
+
+	_, ci, err := reader.ReadPacketData()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ci.Timestamp.Equal(timestamp) {
+		t.Errorf("expected timestamp %v, got %v", timestamp, ci.Timestamp)
+	}
+}
+
+// TestPcapngWriterTimestampPrecision checks that interfaces of agents that fall back to microsecond timestamps are
+// marked and that their precision can not change once packets have been written.
+func TestPcapngWriterTimestampPrecision(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewPacketWriter(&buf, FormatPcapng, 65000, TimestampPrecision_NANOSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = writer.SetTimestampPrecision("router/abc-123", "eth0", TimestampPrecision_MICROSECONDS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, origin := range []string{"router/abc-123", "router/def-456"} {
+		err = writer.WritePacket(&Packet{
+			Data:      examplePacket,
+			Timestamp: timestamppb.Now(),
+			Length:    int32(len(examplePacket)),
+			Origin:    origin,
+			Interface: "eth0",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	err = writer.SetTimestampPrecision("router/abc-123", "eth0", TimestampPrecision_NANOSECONDS)
+	if err == nil {
+		t.Errorf("expected an error when changing the precision after packets have been written")
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reader, err := pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatalf("could not parse pcapng file: %v", err)
+	}
+
+	for {
+		_, _, err = reader.ReadPacketData()
+		if err != nil {
+			break
+		}
+	}
+
+	for i, expectedComment := range []string{"captured with microsecond timestamps", ""} {
+		intf, err := reader.Interface(i)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if intf.Comment != expectedComment {
+			t.Errorf("expected comment %q for interface %s, got %q", expectedComment, intf.Name, intf.Comment)
+		}
+	}
+}